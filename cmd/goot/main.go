@@ -2,10 +2,13 @@ package main
 
 import (
 	"log"
+	"log/slog"
 
+	_ "github.com/zeerodex/goot/internal/apis/providers"
 	"github.com/zeerodex/goot/internal/cli"
 	"github.com/zeerodex/goot/internal/config"
 	"github.com/zeerodex/goot/internal/database"
+	"github.com/zeerodex/goot/internal/logging"
 	"github.com/zeerodex/goot/internal/repositories"
 	"github.com/zeerodex/goot/internal/services"
 )
@@ -16,17 +19,24 @@ func main() {
 		log.Fatalf("Unable to load config: %v", err)
 	}
 
+	logger, logFile, err := logging.New(cfg.Log)
+	if err != nil {
+		log.Fatalf("Unable to init logger: %v", err)
+	}
+	defer logFile.Close()
+	slog.SetDefault(logger)
+
 	db, err := database.InitDB()
 	if err != nil {
 		log.Fatalf("Unable to init database: %v", err)
 	}
 	defer db.Close()
 
-	service, err := services.NewTaskService(repositories.NewTaskRepository(db), cfg)
+	service, err := services.NewTaskService(repositories.NewTaskRepository(db, logger), cfg, logger)
 	if err != nil {
 		log.Fatalf("Unable to initialize service: %v", err)
 	}
-	defer service.WP().Stop()
+	defer service.Close()
 
-	cli.Execute(service, cfg)
+	cli.Execute(service, cfg, logger)
 }
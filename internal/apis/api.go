@@ -1,23 +1,42 @@
 package apis
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/zeerodex/goot/internal/tasks"
 )
 
+// API is a remote task provider. Every call must return once ctx is done.
 type API interface {
-	CreateTask(*tasks.Task) (*tasks.Task, error)
-	// GetAllLists() (tasks.TasksLists, error)
-	GetTaskByID(id string) (*tasks.Task, error)
-	GetAllTasks() (tasks.Tasks, error)
-	GetAllTasksWithDeleted() (tasks.Tasks, error)
-	PatchTask(task *tasks.Task) (*tasks.Task, error)
-	SetTaskCompleted(id string, completed bool) error
-	DeleteTaskByID(id string) error
+	CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error)
+	// GetAllLists(ctx context.Context) (tasks.TasksLists, error)
+	GetTaskByID(ctx context.Context, id string) (*tasks.Task, error)
+	GetAllTasks(ctx context.Context) (tasks.Tasks, error)
+	GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error)
+	PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error)
+	SetTaskCompleted(ctx context.Context, id string, completed bool) error
+	DeleteTaskByID(ctx context.Context, id string) error
+
+	Capabilities() Capabilities
+}
+
+// BatchAPI is implemented by APIs able to apply many changes in a single
+// request, which sync prefers to calling API once per task.
+type BatchAPI interface {
+	API
+	// CreateTasks creates tasks and returns them with their API IDs set,
+	// in the same order. On error, the tasks created before the failure
+	// are returned with their API IDs set too.
+	CreateTasks(ctx context.Context, tasks []*tasks.Task) ([]*tasks.Task, error)
 }
 
+// ErrNotFound is returned, wrapped, when a task or another resource does not
+// exist in an API.
+var ErrNotFound = errors.New("resource not found")
+
 func HandleResponseStatusCode(statusCode int) error {
 	if statusCode != http.StatusOK && statusCode != http.StatusNoContent {
 		baseErr := fmt.Errorf("API request failed with status: %d", statusCode)
@@ -30,7 +49,9 @@ func HandleResponseStatusCode(statusCode int) error {
 		case http.StatusForbidden:
 			return fmt.Errorf("%w: access denied, insufficient permissions", baseErr)
 		case http.StatusNotFound:
-			return fmt.Errorf("%w: resource not found", baseErr)
+			return fmt.Errorf("%w: %w", baseErr, ErrNotFound)
+		case http.StatusTooManyRequests:
+			return fmt.Errorf("%w: rate limit exceeded, please try again later", baseErr)
 		case http.StatusInternalServerError:
 			return fmt.Errorf("%w: internal server error, please try again later", baseErr)
 		default:
@@ -0,0 +1,529 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/ical"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+	"github.com/zeerodex/goot/internal/ratelimit"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// Name of the provider, under which task IDs are stored.
+const Name = "caldav"
+
+// DefaultStateFile is where the cache of the collection is kept between
+// runs, relative to the working directory like the database.
+const DefaultStateFile = "caldav_state.json"
+
+// multigetSize is the maximum number of resources fetched per
+// calendar-multiget REPORT.
+const multigetSize = 100
+
+// DefaultRateLimit disables limiting, CalDAV servers are usually self-hosted.
+var DefaultRateLimit = config.RateLimit{}
+
+// ErrConflict is returned when a task was changed on the server since it
+// was last fetched. The next sync picks up the new version.
+var ErrConflict = errors.New("task was modified on the server")
+
+var errInvalidSyncToken = errors.New("invalid sync token")
+
+// CalDAVAPI syncs the VTODOs of a CalDAV collection. The collection is
+// cached and kept up to date with sync-collection REPORTs, so only
+// resources whose ETag changed are downloaded. The cache is persisted to
+// stateFile, if set, so that resources removed while goot was not running
+// are still reported.
+type CalDAVAPI struct {
+	client     *http.Client
+	collection *url.URL
+	stateFile  string
+
+	// syncMu serializes refreshes of the cache.
+	syncMu sync.Mutex
+	// saveMu serializes writes of the state file.
+	saveMu sync.Mutex
+
+	mu        sync.Mutex
+	syncToken string
+	entries   map[string]*entry
+	// removed holds the resources removed from the collection since the
+	// last call to GetAllTasksWithDeleted.
+	removed map[string]*ical.Component
+}
+
+type entry struct {
+	etag string
+	cal  *ical.Component
+}
+
+// state is the cache as stored in the state file.
+type state struct {
+	// Collection is the URL the state belongs to, the state of another
+	// collection is ignored.
+	Collection string                   `json:"collection"`
+	SyncToken  string                   `json:"sync_token"`
+	Resources  map[string]stateResource `json:"resources"`
+	// Removed holds the calendar data of the pending tombstones.
+	Removed map[string]string `json:"removed,omitempty"`
+}
+
+type stateResource struct {
+	ETag string `json:"etag"`
+	Data string `json:"data"`
+}
+
+// authTransport authenticates requests with a bearer token if set, or
+// with basic auth.
+type authTransport struct {
+	base               http.RoundTripper
+	username, password string
+	token              string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	} else if t.username != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	return t.base.RoundTrip(req)
+}
+
+func NewCalDAVAPI(s *Settings, limiter *ratelimit.Limiter, logger *slog.Logger) (*CalDAVAPI, error) {
+	client := &http.Client{Transport: &authTransport{
+		base:     http.DefaultTransport,
+		username: s.Username,
+		password: s.Password,
+		token:    s.Token,
+	}}
+
+	logger = logger.With("api", Name)
+	client = metrics.WrapClient(logging.WrapClient(client, logger), Name)
+	return newCalDAVAPI(s.URL, s.StateFile, ratelimit.WrapClient(client, limiter, Name, logger))
+}
+
+func newCalDAVAPI(collection, stateFile string, client *http.Client) (*CalDAVAPI, error) {
+	if collection == "" {
+		return nil, errors.New("caldav collection url is not set")
+	}
+	u, err := url.Parse(collection)
+	if err != nil {
+		return nil, fmt.Errorf("invalid caldav collection url: %w", err)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	c := &CalDAVAPI{
+		client:     client,
+		collection: u,
+		stateFile:  stateFile,
+		entries:    make(map[string]*entry),
+		removed:    make(map[string]*ical.Component),
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load restores the cache from the state file.
+func (c *CalDAVAPI) load() error {
+	if c.stateFile == "" {
+		return nil
+	}
+	var s state
+	if err := apis.LoadState(c.stateFile, &s); err != nil {
+		return err
+	}
+	if s.Collection != c.collection.String() {
+		return nil
+	}
+	for id, r := range s.Resources {
+		cal, err := ical.Decode(strings.NewReader(r.Data))
+		if err != nil {
+			return fmt.Errorf("invalid calendar data for '%s' in state file '%s': %w", id, c.stateFile, err)
+		}
+		c.entries[id] = &entry{etag: r.ETag, cal: cal}
+	}
+	for id, data := range s.Removed {
+		cal, err := ical.Decode(strings.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("invalid calendar data for '%s' in state file '%s': %w", id, c.stateFile, err)
+		}
+		c.removed[id] = cal
+	}
+	c.syncToken = s.SyncToken
+	return nil
+}
+
+// save writes the cache to the state file.
+func (c *CalDAVAPI) save() error {
+	if c.stateFile == "" {
+		return nil
+	}
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	c.mu.Lock()
+	s := state{
+		Collection: c.collection.String(),
+		SyncToken:  c.syncToken,
+		Resources:  make(map[string]stateResource, len(c.entries)),
+		Removed:    make(map[string]string, len(c.removed)),
+	}
+	for id, e := range c.entries {
+		s.Resources[id] = stateResource{ETag: e.etag, Data: e.cal.String()}
+	}
+	for id, cal := range c.removed {
+		s.Removed[id] = cal.String()
+	}
+	c.mu.Unlock()
+
+	return apis.SaveState(c.stateFile, s)
+}
+
+func (c *CalDAVAPI) resourceURL(id string) string {
+	return c.collection.JoinPath(id).String()
+}
+
+func (c *CalDAVAPI) makeRequest(ctx context.Context, method, u string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	return resp, nil
+}
+
+func checkStatus(resp *http.Response, ok ...int) error {
+	if slices.Contains(ok, resp.StatusCode) {
+		return nil
+	}
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return ErrConflict
+	}
+	if err := apis.HandleResponseStatusCode(resp.StatusCode); err != nil {
+		return err
+	}
+	return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+}
+
+func (c *CalDAVAPI) report(ctx context.Context, body []byte) (*multistatus, error) {
+	resp, err := c.makeRequest(ctx, "REPORT", c.collection.String(), body, http.Header{
+		"Content-Type": {"application/xml; charset=utf-8"},
+		"Depth":        {"1"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusConflict {
+		return nil, errInvalidSyncToken
+	}
+	if err := checkStatus(resp, http.StatusMultiStatus); err != nil {
+		return nil, err
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("error decoding multistatus: %w", err)
+	}
+	return &ms, nil
+}
+
+// refresh brings the cache up to date with the collection.
+func (c *CalDAVAPI) refresh(ctx context.Context) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	c.mu.Lock()
+	token := c.syncToken
+	c.mu.Unlock()
+
+	ms, err := c.report(ctx, syncCollectionBody(token))
+	if errors.Is(err, errInvalidSyncToken) && token != "" {
+		// The token expired, list the whole collection again.
+		token = ""
+		ms, err = c.report(ctx, syncCollectionBody(token))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync collection: %w", err)
+	}
+
+	c.mu.Lock()
+	seen := make(map[string]bool)
+	var changed []string
+	for _, r := range ms.Responses {
+		if strings.HasSuffix(r.Href, "/") {
+			continue
+		}
+		id := resourceID(r.Href)
+		if r.removed() {
+			c.remove(id)
+			continue
+		}
+		seen[id] = true
+		p, ok := r.prop()
+		if e := c.entries[id]; ok && e != nil && e.etag != "" && e.etag == p.ETag {
+			continue
+		}
+		changed = append(changed, r.Href)
+	}
+	if token == "" {
+		// A full listing does not report removals.
+		for id := range c.entries {
+			if !seen[id] {
+				c.remove(id)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	for chunk := range slices.Chunk(changed, multigetSize) {
+		got, err := c.report(ctx, multigetBody(chunk))
+		if err != nil {
+			return fmt.Errorf("failed to fetch %d changed tasks: %w", len(chunk), err)
+		}
+
+		c.mu.Lock()
+		for _, r := range got.Responses {
+			p, ok := r.prop()
+			if !ok {
+				continue
+			}
+			cal, err := ical.Decode(strings.NewReader(p.CalendarData))
+			if err != nil {
+				c.mu.Unlock()
+				return fmt.Errorf("invalid calendar data in %s: %w", r.Href, err)
+			}
+			c.entries[resourceID(r.Href)] = &entry{etag: p.ETag, cal: cal}
+		}
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	c.syncToken = ms.SyncToken
+	c.mu.Unlock()
+	return c.save()
+}
+
+// remove drops id from the cache, keeping it as a tombstone. c.mu must be
+// held.
+func (c *CalDAVAPI) remove(id string) {
+	if e, ok := c.entries[id]; ok {
+		c.removed[id] = e.cal
+		delete(c.entries, id)
+	}
+}
+
+// Capabilities of CalDAV. Removals are known for the resources seen since
+// the state file was created.
+func (c *CalDAVAPI) Capabilities() apis.Capabilities {
+	return apis.Capabilities{Description: true, DueTime: true, Priority: true, Recurrence: true, Tombstones: true}
+}
+
+func (c *CalDAVAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var tasksList tasks.Tasks
+	for id, e := range c.entries {
+		if t, ok := toTask(id, e.cal); ok {
+			tasksList = append(tasksList, *t)
+		}
+	}
+	return tasksList, nil
+}
+
+func (c *CalDAVAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	tasksList, err := c.GetAllTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	for id, cal := range c.removed {
+		if t, ok := toTask(id, cal); ok {
+			t.Deleted = true
+			tasksList = append(tasksList, *t)
+		}
+		delete(c.removed, id)
+	}
+	c.mu.Unlock()
+
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return tasksList, nil
+}
+
+// get returns a copy of the cached resource id, fetching it if needed.
+func (c *CalDAVAPI) get(ctx context.Context, id string) (*entry, error) {
+	c.mu.Lock()
+	e, ok := c.entries[id]
+	c.mu.Unlock()
+	if ok {
+		cal, err := ical.Decode(strings.NewReader(e.cal.String()))
+		if err != nil {
+			return nil, err
+		}
+		return &entry{etag: e.etag, cal: cal}, nil
+	}
+
+	resp, err := c.makeRequest(ctx, "GET", c.resourceURL(id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	cal, err := ical.Decode(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid calendar data: %w", err)
+	}
+	return &entry{etag: resp.Header.Get("ETag"), cal: cal}, nil
+}
+
+// put stores cal as the resource id. The resource must not exist if
+// create is set, and must still match etag otherwise, if known.
+func (c *CalDAVAPI) put(ctx context.Context, id string, cal *ical.Component, etag string, create bool) error {
+	header := http.Header{"Content-Type": {"text/calendar; charset=utf-8"}}
+	if create {
+		header.Set("If-None-Match", "*")
+	} else if etag != "" {
+		header.Set("If-Match", etag)
+	}
+
+	resp, err := c.makeRequest(ctx, "PUT", c.resourceURL(id), []byte(cal.String()), header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK, http.StatusCreated, http.StatusNoContent); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.entries[id] = &entry{etag: etag, cal: cal}
+	} else {
+		// The stored version may differ, fetch it on next use.
+		delete(c.entries, id)
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *CalDAVAPI) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	e, err := c.get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve task '%s': %w", id, err)
+	}
+	t, ok := toTask(id, e.cal)
+	if !ok {
+		return nil, fmt.Errorf("resource '%s' holds no VTODO", id)
+	}
+	return t, nil
+}
+
+func (c *CalDAVAPI) CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	cal := newCalendar(task)
+	id := cal.Child("VTODO").Text("UID") + ".ics"
+	if err := c.put(ctx, id, cal, "", true); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	task.SetAPIID(Name, id)
+	t, _ := toTask(id, cal)
+	return t, nil
+}
+
+// modify applies f to the VTODO of the resource id and stores it.
+func (c *CalDAVAPI) modify(ctx context.Context, id string, f func(todo *ical.Component)) (*tasks.Task, error) {
+	e, err := c.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	todo := e.cal.Child("VTODO")
+	if todo == nil {
+		return nil, fmt.Errorf("resource '%s' holds no VTODO", id)
+	}
+	f(todo)
+	if err := c.put(ctx, id, e.cal, e.etag, false); err != nil {
+		return nil, err
+	}
+	t, _ := toTask(id, e.cal)
+	return t, nil
+}
+
+func (c *CalDAVAPI) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	id := task.APIID(Name)
+	t, err := c.modify(ctx, id, func(todo *ical.Component) { applyTask(todo, task) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task '%s': %w", id, err)
+	}
+	return t, nil
+}
+
+func (c *CalDAVAPI) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
+	_, err := c.modify(ctx, id, func(todo *ical.Component) {
+		now := time.Now()
+		setCompleted(todo, completed, now)
+		todo.SetTime("DTSTAMP", now, false)
+		todo.SetTime("LAST-MODIFIED", now, false)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set completion of task '%s': %w", id, err)
+	}
+	return nil
+}
+
+func (c *CalDAVAPI) DeleteTaskByID(ctx context.Context, id string) error {
+	c.mu.Lock()
+	var header http.Header
+	if e, ok := c.entries[id]; ok && e.etag != "" {
+		header = http.Header{"If-Match": {e.etag}}
+	}
+	c.mu.Unlock()
+
+	resp, err := c.makeRequest(ctx, "DELETE", c.resourceURL(id), nil, header)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp, http.StatusOK, http.StatusNoContent); err != nil {
+		return fmt.Errorf("failed to delete task '%s': %w", id, err)
+	}
+
+	c.mu.Lock()
+	delete(c.entries, id)
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,417 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// fakeServer is a CalDAV collection at /tasks/ supporting PUT, GET,
+// DELETE, sync-collection and calendar-multiget.
+type fakeServer struct {
+	mu        sync.Mutex
+	resources map[string]*resource
+	version   int
+	// changes lists the resource changed by each version, in order.
+	changes []string
+	// fetched counts the resources returned by calendar-multiget.
+	fetched int
+}
+
+type resource struct {
+	data    string
+	version int
+	deleted bool
+}
+
+func (r *resource) etag() string {
+	return `"v` + strconv.Itoa(r.version) + `"`
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *httptest.Server) {
+	f := &fakeServer{resources: make(map[string]*resource)}
+	srv := httptest.NewServer(http.StripPrefix("/tasks/", http.HandlerFunc(f.serveHTTP)))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// set stores data as the resource name, as another client would.
+func (f *fakeServer) set(name, data string) *resource {
+	f.version++
+	r := &resource{data: data, version: f.version}
+	f.resources[name] = r
+	f.changes = append(f.changes, name)
+	return r
+}
+
+func (f *fakeServer) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name := req.URL.Path
+	r, exists := f.resources[name]
+	if exists && r.deleted {
+		r, exists = nil, false
+	}
+
+	switch req.Method {
+	case "PUT":
+		if req.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if m := req.Header.Get("If-Match"); m != "" && (!exists || m != r.etag()) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		r := f.set(name, string(body))
+		w.Header().Set("ETag", r.etag())
+		w.WriteHeader(http.StatusCreated)
+	case "GET":
+		if !exists {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("ETag", r.etag())
+		io.WriteString(w, r.data)
+	case "DELETE":
+		if !exists {
+			http.NotFound(w, req)
+			return
+		}
+		if m := req.Header.Get("If-Match"); m != "" && m != r.etag() {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.version++
+		f.resources[name] = &resource{version: f.version, deleted: true}
+		f.changes = append(f.changes, name)
+		w.WriteHeader(http.StatusNoContent)
+	case "REPORT":
+		f.report(w, req)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeServer) report(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		XMLName   xml.Name
+		SyncToken string   `xml:"sync-token"`
+		Hrefs     []string `xml:"href"`
+	}
+	if err := xml.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+	switch body.XMLName.Local {
+	case "sync-collection":
+		since := 0
+		if body.SyncToken != "" {
+			var err error
+			since, err = strconv.Atoi(strings.TrimPrefix(body.SyncToken, "token-"))
+			if err != nil || since > f.version {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		}
+		names := make(map[string]bool)
+		for _, name := range f.changes[since:] {
+			names[name] = true
+		}
+		for name := range names {
+			r := f.resources[name]
+			if r.deleted {
+				if since > 0 {
+					fmt.Fprintf(&b, `<d:response><d:href>/tasks/%s</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>`, name)
+				}
+				continue
+			}
+			fmt.Fprintf(&b, `<d:response><d:href>/tasks/%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, name, r.etag())
+		}
+		fmt.Fprintf(&b, `<d:sync-token>token-%d</d:sync-token>`, f.version)
+	case "calendar-multiget":
+		for _, href := range body.Hrefs {
+			r := f.resources[path.Base(href)]
+			f.fetched++
+			fmt.Fprintf(&b, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag><c:calendar-data>`, href, r.etag())
+			xml.EscapeText(&b, []byte(r.data))
+			b.WriteString(`</c:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`)
+		}
+	}
+	b.WriteString(`</d:multistatus>`)
+
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, b.String())
+}
+
+const externalTodo = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//other//EN\r\nBEGIN:VTODO\r\nUID:ext\r\n" +
+	"SUMMARY:Water\\, the plants\r\nDUE;VALUE=DATE:20250601\r\nPRIORITY:1\r\nRRULE:FREQ=WEEKLY\r\n" +
+	"X-OTHER-CLIENT:kept\r\nLAST-MODIFIED:20250501T100000Z\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"
+
+func newTestAPI(t *testing.T, srv *httptest.Server) *CalDAVAPI {
+	api, err := newCalDAVAPI(srv.URL+"/tasks", "", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api
+}
+
+func TestIncrementalSync(t *testing.T) {
+	f, srv := newFakeServer(t)
+	api := newTestAPI(t, srv)
+	ctx := context.Background()
+
+	f.set("ext.ics", externalTodo)
+	task := &tasks.Task{Title: "report", Due: time.Date(2025, 6, 2, 14, 30, 0, 0, time.Local)}
+	if _, err := api.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	all, err := api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted() error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(all))
+	}
+	// The created task is cached with its ETag, only the other is fetched.
+	if f.fetched != 1 {
+		t.Errorf("fetched %d resources, want 1", f.fetched)
+	}
+	var ext *tasks.Task
+	for _, at := range all {
+		if at.APIID(Name) == "ext.ics" {
+			ext = &at
+		}
+	}
+	if ext == nil {
+		t.Fatal("external task not returned")
+	}
+	if ext.Title != "Water, the plants" || ext.Priority != tasks.PriorityHigh || ext.Recurrence != "FREQ=WEEKLY" ||
+		!ext.Due.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("external task = %+v", ext)
+	}
+
+	// Nothing changed, nothing is fetched.
+	f.fetched = 0
+	if _, err := api.GetAllTasks(ctx); err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if f.fetched != 0 {
+		t.Errorf("fetched %d unchanged resources", f.fetched)
+	}
+
+	f.mu.Lock()
+	f.version++
+	f.resources["ext.ics"] = &resource{version: f.version, deleted: true}
+	f.changes = append(f.changes, "ext.ics")
+	f.mu.Unlock()
+
+	all, err = api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted() error = %v", err)
+	}
+	var deleted []string
+	for _, at := range all {
+		if at.Deleted {
+			deleted = append(deleted, at.APIID(Name))
+		}
+	}
+	if len(all) != 2 || len(deleted) != 1 || deleted[0] != "ext.ics" {
+		t.Errorf("got %d tasks with deleted %v, want the tombstone of ext.ics", len(all), deleted)
+	}
+}
+
+// TestStateFile checks that a resource removed while goot was not running
+// is reported as deleted from the persisted state.
+func TestStateFile(t *testing.T) {
+	f, srv := newFakeServer(t)
+	ctx := context.Background()
+	stateFile := path.Join(t.TempDir(), "state.json")
+
+	api, err := newCalDAVAPI(srv.URL+"/tasks", stateFile, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.set("ext.ics", externalTodo)
+	if _, err := api.GetAllTasksWithDeleted(ctx); err != nil {
+		t.Fatalf("GetAllTasksWithDeleted() error = %v", err)
+	}
+
+	f.mu.Lock()
+	f.version++
+	f.resources["ext.ics"] = &resource{version: f.version, deleted: true}
+	f.changes = append(f.changes, "ext.ics")
+	f.mu.Unlock()
+
+	api, err = newCalDAVAPI(srv.URL+"/tasks", stateFile, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted() error = %v", err)
+	}
+	if len(all) != 1 || !all[0].Deleted || all[0].APIID(Name) != "ext.ics" {
+		t.Errorf("got %+v, want the tombstone of ext.ics", all)
+	}
+}
+
+func TestPatchKeepsUnknownProperties(t *testing.T) {
+	f, srv := newFakeServer(t)
+	api := newTestAPI(t, srv)
+	ctx := context.Background()
+
+	f.set("ext.ics", externalTodo)
+	if _, err := api.GetAllTasks(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	task := &tasks.Task{Title: "Water the plants", Completed: true}
+	task.SetAPIID(Name, "ext.ics")
+	if _, err := api.PatchTask(ctx, task); err != nil {
+		t.Fatalf("PatchTask() error = %v", err)
+	}
+
+	data := f.resources["ext.ics"].data
+	for _, want := range []string{"X-OTHER-CLIENT:kept", "UID:ext", "STATUS:COMPLETED", "SUMMARY:Water the plants"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("stored VTODO lacks %q:\n%s", want, data)
+		}
+	}
+
+	if err := api.SetTaskCompleted(ctx, "ext.ics", false); err != nil {
+		t.Fatalf("SetTaskCompleted() error = %v", err)
+	}
+	if data := f.resources["ext.ics"].data; !strings.Contains(data, "STATUS:NEEDS-ACTION") || strings.Contains(data, "COMPLETED:") {
+		t.Errorf("task not reopened:\n%s", data)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	f, srv := newFakeServer(t)
+	api := newTestAPI(t, srv)
+	ctx := context.Background()
+
+	f.set("ext.ics", externalTodo)
+	if _, err := api.GetAllTasks(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f.set("ext.ics", strings.Replace(externalTodo, "PRIORITY:1", "PRIORITY:9", 1))
+
+	task := &tasks.Task{Title: "stale"}
+	task.SetAPIID(Name, "ext.ics")
+	if _, err := api.PatchTask(ctx, task); !errors.Is(err, ErrConflict) {
+		t.Errorf("PatchTask() of a stale task error = %v, want ErrConflict", err)
+	}
+	if err := api.DeleteTaskByID(ctx, "ext.ics"); !errors.Is(err, ErrConflict) {
+		t.Errorf("DeleteTaskByID() of a stale task error = %v, want ErrConflict", err)
+	}
+}
+
+func TestInvalidSyncToken(t *testing.T) {
+	f, srv := newFakeServer(t)
+	api := newTestAPI(t, srv)
+	ctx := context.Background()
+
+	f.set("ext.ics", externalTodo)
+	api.syncToken = "token-99"
+	all, err := api.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("got %d tasks after resync, want 1", len(all))
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport *authTransport
+		want      string
+	}{
+		{name: "basic", transport: &authTransport{username: "user", password: "pass"}, want: "Basic dXNlcjpwYXNz"},
+		{name: "bearer", transport: &authTransport{username: "user", token: "tok"}, want: "Bearer tok"},
+		{name: "none", transport: &authTransport{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get("Authorization")
+			}))
+			defer srv.Close()
+
+			tt.transport.base = http.DefaultTransport
+			client := &http.Client{Transport: tt.transport}
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got != tt.want {
+				t.Errorf("Authorization = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRadicale runs against a real server, e.g. a local Radicale started
+// with "radicale --auth-type none", given the URL of an existing task
+// collection in GOOT_CALDAV_TEST_URL.
+func TestRadicale(t *testing.T) {
+	collection := os.Getenv("GOOT_CALDAV_TEST_URL")
+	if collection == "" {
+		t.Skip("GOOT_CALDAV_TEST_URL is not set")
+	}
+	client := &http.Client{Transport: &authTransport{
+		base:     http.DefaultTransport,
+		username: os.Getenv("GOOT_CALDAV_TEST_USERNAME"),
+		password: os.Getenv("GOOT_CALDAV_TEST_PASSWORD"),
+	}}
+	api, err := newCalDAVAPI(collection, "", client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := api.GetAllTasks(ctx); err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	task := &tasks.Task{Title: "goot test", Due: time.Now().Truncate(time.Minute), Priority: tasks.PriorityLow}
+	if _, err := api.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	id := task.APIID(Name)
+	t.Cleanup(func() { api.DeleteTaskByID(ctx, id) })
+
+	if err := api.SetTaskCompleted(ctx, id, true); err != nil {
+		t.Fatalf("SetTaskCompleted() error = %v", err)
+	}
+	got, err := api.GetTaskByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetTaskByID() error = %v", err)
+	}
+	if !got.Completed || got.Title != task.Title || got.Priority != task.Priority {
+		t.Errorf("GetTaskByID() = %+v, want the completed task", got)
+	}
+}
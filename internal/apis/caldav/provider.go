@@ -0,0 +1,39 @@
+package caldav
+
+import (
+	"os"
+
+	"github.com/zeerodex/goot/internal/apis"
+)
+
+// Settings are read from the "caldav" config section. Password and Token
+// default to the CALDAV_PASSWORD and CALDAV_TOKEN environment variables.
+type Settings struct {
+	// URL of the task collection, e.g.
+	// https://cloud.example.com/remote.php/dav/calendars/user/tasks/
+	URL      string `mapstructure:"url"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Token is sent as a bearer token instead of basic auth if set.
+	Token string `mapstructure:"token"`
+	// StateFile keeps the sync state of the collection between runs.
+	StateFile string `mapstructure:"state-file"`
+}
+
+func init() {
+	apis.Register(apis.Provider{
+		Name:        Name,
+		NewSettings: func() any { return &Settings{StateFile: DefaultStateFile} },
+		New: func(settings any, opts apis.Options) (apis.API, error) {
+			s := settings.(*Settings)
+			if s.Password == "" {
+				s.Password = os.Getenv("CALDAV_PASSWORD")
+			}
+			if s.Token == "" {
+				s.Token = os.Getenv("CALDAV_TOKEN")
+			}
+			return NewCalDAVAPI(s, opts.Limiter, opts.Logger)
+		},
+		RateLimit: DefaultRateLimit,
+	})
+}
@@ -0,0 +1,139 @@
+package caldav
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/zeerodex/goot/internal/ical"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+const prodID = "-//goot//goot//EN"
+
+// iCalendar priorities, mapped to tasks.Priority by position: 1 is the
+// highest and 9 the lowest, 0 is undefined.
+var priorities = []int{0, 9, 5, 1}
+
+// newCalendar returns a VCALENDAR holding a new VTODO for task.
+func newCalendar(task *tasks.Task) *ical.Component {
+	todo := ical.NewComponent("VTODO")
+	todo.Set("UID", newUID(), nil)
+	applyTask(todo, task)
+
+	cal := ical.NewComponent("VCALENDAR")
+	cal.Set("VERSION", "2.0", nil)
+	cal.Set("PRODID", prodID, nil)
+	cal.Children = append(cal.Children, todo)
+	return cal
+}
+
+// applyTask sets the properties of todo mapped from task, leaving the
+// others untouched.
+func applyTask(todo *ical.Component, task *tasks.Task) {
+	now := time.Now()
+	todo.SetTime("DTSTAMP", now, false)
+	todo.SetTime("LAST-MODIFIED", now, false)
+	todo.SetText("SUMMARY", task.Title)
+
+	if task.Description != "" {
+		todo.SetText("DESCRIPTION", task.Description)
+	} else {
+		todo.Del("DESCRIPTION")
+	}
+
+	if task.Due.IsZero() {
+		todo.Del("DUE")
+	} else {
+		dateOnly := timeutil.IsOnlyDate(task.Due)
+		todo.SetTime("DUE", task.Due, dateOnly)
+		// DTSTART must not be after DUE and have the same value type.
+		if start, startDateOnly, err := todo.Time("DTSTART", time.Local); err == nil && !start.IsZero() &&
+			(start.After(task.Due) || startDateOnly != dateOnly) {
+			todo.SetTime("DTSTART", task.Due, dateOnly)
+		}
+	}
+
+	if task.Recurrence != "" {
+		todo.Set("RRULE", task.Recurrence, nil)
+		// Recurrence is computed from DTSTART.
+		if todo.Get("DTSTART") == nil && !task.Due.IsZero() {
+			todo.SetTime("DTSTART", task.Due, timeutil.IsOnlyDate(task.Due))
+		}
+	} else {
+		todo.Del("RRULE")
+	}
+
+	if task.Priority > tasks.PriorityNone && int(task.Priority) < len(priorities) {
+		todo.Set("PRIORITY", strconv.Itoa(priorities[task.Priority]), nil)
+	} else {
+		todo.Del("PRIORITY")
+	}
+
+	setCompleted(todo, task.Completed, now)
+}
+
+func setCompleted(todo *ical.Component, completed bool, now time.Time) {
+	if completed {
+		if todo.Text("STATUS") != "COMPLETED" {
+			todo.Set("STATUS", "COMPLETED", nil)
+			todo.SetTime("COMPLETED", now, false)
+			todo.Set("PERCENT-COMPLETE", "100", nil)
+		}
+		return
+	}
+	todo.Set("STATUS", "NEEDS-ACTION", nil)
+	todo.Del("COMPLETED")
+	todo.Del("PERCENT-COMPLETE")
+}
+
+// toTask converts the VTODO of cal, stored under id, or returns false if
+// cal holds none.
+func toTask(id string, cal *ical.Component) (*tasks.Task, bool) {
+	todo := cal.Child("VTODO")
+	if todo == nil {
+		return nil, false
+	}
+
+	t := &tasks.Task{
+		Title:       todo.Text("SUMMARY"),
+		Description: todo.Text("DESCRIPTION"),
+		Recurrence:  todo.Text("RRULE"),
+		Completed:   todo.Text("STATUS") == "COMPLETED" || todo.Get("COMPLETED") != nil,
+	}
+	t.SetAPIID(Name, id)
+
+	if due, _, err := todo.Time("DUE", time.Local); err == nil && !due.IsZero() {
+		t.Due = due.In(time.Local)
+	}
+
+	if p, err := strconv.Atoi(todo.Text("PRIORITY")); err == nil && p > 0 {
+		switch {
+		case p < 5:
+			t.Priority = tasks.PriorityHigh
+		case p == 5:
+			t.Priority = tasks.PriorityMedium
+		default:
+			t.Priority = tasks.PriorityLow
+		}
+	}
+
+	for _, name := range []string{"LAST-MODIFIED", "DTSTAMP"} {
+		if modified, _, err := todo.Time(name, time.UTC); err == nil && !modified.IsZero() {
+			t.LastModified = modified
+			break
+		}
+	}
+	return t, true
+}
+
+// newUID returns a random UUID as recommended for UID values.
+func newUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,89 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// WebDAV (RFC 4918), collection synchronization (RFC 6578) and CalDAV
+// (RFC 4791) request bodies and responses.
+
+type multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []response `xml:"DAV: response"`
+	SyncToken string     `xml:"DAV: sync-token"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Status    string     `xml:"DAV: status"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"DAV: prop"`
+	Status string `xml:"DAV: status"`
+}
+
+type prop struct {
+	ETag         string `xml:"DAV: getetag"`
+	CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+}
+
+// removed reports a member removed from the collection, which
+// sync-collection returns with a 404 status and no properties.
+func (r *response) removed() bool {
+	return statusCode(r.Status) == "404"
+}
+
+// prop returns the properties found for r.
+func (r *response) prop() (prop, bool) {
+	for _, ps := range r.Propstats {
+		if ps.Status == "" || statusCode(ps.Status) == "200" {
+			return ps.Prop, true
+		}
+	}
+	return prop{}, false
+}
+
+// statusCode returns the code of a status line such as "HTTP/1.1 200 OK".
+func statusCode(status string) string {
+	fields := strings.Fields(status)
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+// resourceID returns the name of the resource at href, the ID of the task
+// it holds.
+func resourceID(href string) string {
+	if u, err := url.Parse(href); err == nil {
+		href = u.Path
+	}
+	return path.Base(href)
+}
+
+func syncCollectionBody(syncToken string) []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<d:sync-collection xmlns:d="DAV:"><d:sync-token>`)
+	xml.EscapeText(&b, []byte(syncToken))
+	b.WriteString(`</d:sync-token><d:sync-level>1</d:sync-level><d:prop><d:getetag/></d:prop></d:sync-collection>`)
+	return []byte(b.String())
+}
+
+func multigetBody(hrefs []string) []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav"><d:prop><d:getetag/><c:calendar-data/></d:prop>`)
+	for _, href := range hrefs {
+		b.WriteString("<d:href>")
+		xml.EscapeText(&b, []byte(href))
+		b.WriteString("</d:href>")
+	}
+	b.WriteString(`</c:calendar-multiget>`)
+	return []byte(b.String())
+}
@@ -0,0 +1,49 @@
+package apis
+
+import (
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+// Capabilities describes which task features an API can store, so that
+// callers can tell what survives a round-trip through it.
+type Capabilities struct {
+	// Description is false for APIs storing only a title, whose tasks keep
+	// their local description through sync.
+	Description bool `json:"description"`
+	// DueTime is false for APIs storing due dates without a time of day.
+	DueTime    bool `json:"due_time"`
+	Priority   bool `json:"priority"`
+	Labels     bool `json:"labels"`
+	Recurrence bool `json:"recurrence"`
+	Subtasks   bool `json:"subtasks"`
+	// Tombstones means GetAllTasksWithDeleted returns deleted tasks, which
+	// lets sync propagate remote deletions.
+	Tombstones bool `json:"tombstones"`
+	// ReadMostly APIs only import their own tasks. Local tasks are not
+	// created in them and only the completion of imported tasks is
+	// written back.
+	ReadMostly bool `json:"read_mostly"`
+}
+
+// Lossy returns the fields of task that would be lost or altered by a
+// round-trip through an API with capabilities c.
+func (c Capabilities) Lossy(task *tasks.Task) []string {
+	var fields []string
+	if !c.Description && task.Description != "" {
+		fields = append(fields, "description")
+	}
+	if !c.DueTime && !task.Due.IsZero() && !timeutil.IsOnlyDate(task.Due) {
+		fields = append(fields, "due time")
+	}
+	if !c.Priority && task.Priority != tasks.PriorityNone {
+		fields = append(fields, "priority")
+	}
+	if !c.Recurrence && task.Recurrence != "" {
+		fields = append(fields, "recurrence")
+	}
+	if !c.Labels && len(task.Tags) > 0 {
+		fields = append(fields, "tags")
+	}
+	return fields
+}
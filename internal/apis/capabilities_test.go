@@ -0,0 +1,56 @@
+package apis
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func TestLossy(t *testing.T) {
+	all := Capabilities{Description: true, DueTime: true, Priority: true, Labels: true, Recurrence: true}
+	task := &tasks.Task{
+		Title:       "report",
+		Description: "quarterly",
+		Due:         time.Date(2025, 6, 1, 14, 30, 0, 0, time.Local),
+		Priority:    tasks.PriorityHigh,
+		Recurrence:  "FREQ=MONTHLY",
+		Tags:        []string{"work"},
+	}
+
+	tests := []struct {
+		name string
+		caps func(c *Capabilities)
+		task *tasks.Task
+		want []string
+	}{
+		{name: "none", caps: func(*Capabilities) {}, task: task},
+		{name: "description", caps: func(c *Capabilities) { c.Description = false }, task: task, want: []string{"description"}},
+		{name: "due time", caps: func(c *Capabilities) { c.DueTime = false }, task: task, want: []string{"due time"}},
+		{
+			name: "due date only",
+			caps: func(c *Capabilities) { c.DueTime = false },
+			task: &tasks.Task{Title: "report", Due: time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)},
+		},
+		{name: "priority", caps: func(c *Capabilities) { c.Priority = false }, task: task, want: []string{"priority"}},
+		{name: "recurrence", caps: func(c *Capabilities) { c.Recurrence = false }, task: task, want: []string{"recurrence"}},
+		{name: "tags", caps: func(c *Capabilities) { c.Labels = false }, task: task, want: []string{"tags"}},
+		{name: "unset fields", caps: func(c *Capabilities) { *c = Capabilities{} }, task: &tasks.Task{Title: "report"}},
+		{
+			name: "every field",
+			caps: func(c *Capabilities) { *c = Capabilities{} },
+			task: task,
+			want: []string{"description", "due time", "priority", "recurrence", "tags"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps := all
+			tt.caps(&caps)
+			if got := caps.Lossy(tt.task); !slices.Equal(got, tt.want) {
+				t.Errorf("Lossy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
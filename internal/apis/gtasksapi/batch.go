@@ -0,0 +1,192 @@
+package gtasksapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	gtasks "google.golang.org/api/tasks/v1"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const (
+	batchURL = "https://tasks.googleapis.com/batch/tasks/v1"
+	// maxBatchSize is the number of calls sent per batch request, Google
+	// allows up to 1000 but recommends keeping batches small.
+	maxBatchSize = 50
+)
+
+type batchCall struct {
+	method string
+	path   string
+	body   any
+}
+
+// CreateTasks creates tasks using the batch endpoint. If some calls fail,
+// the tasks created by the others still have their GoogleID set.
+func (api *GTasksApi) CreateTasks(ctx context.Context, ts []*tasks.Task) ([]*tasks.Task, error) {
+	path := "/tasks/v1/lists/" + url.PathEscape(api.ListId) + "/tasks"
+	calls := make([]batchCall, len(ts))
+	for i, t := range ts {
+		calls[i] = batchCall{method: http.MethodPost, path: path, body: t.GTask()}
+	}
+
+	bodies, err := api.batch(ctx, calls)
+	if err != nil {
+		err = fmt.Errorf("failed to create tasks in list '%s': %w", api.ListId, err)
+	}
+	for i, data := range bodies {
+		if data == nil {
+			continue
+		}
+		var g gtasks.Task
+		if derr := json.Unmarshal(data, &g); derr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to decode created task: %w", derr))
+			continue
+		}
+		ts[i].GoogleID = g.Id
+	}
+	return ts, err
+}
+
+// batch sends calls in as few batch requests as possible and returns the
+// response body of every call, in order. The bodies of failed calls, and of
+// the calls not sent after a batch request failed, are nil.
+func (api *GTasksApi) batch(ctx context.Context, calls []batchCall) ([][]byte, error) {
+	bodies := make([][]byte, len(calls))
+	var errs []error
+	for i := 0; i < len(calls); i += maxBatchSize {
+		chunk := calls[i:min(i+maxBatchSize, len(calls))]
+		b, err := api.sendBatch(ctx, chunk)
+		copy(bodies[i:], b)
+		if err != nil {
+			errs = append(errs, err)
+			if b == nil {
+				// The request itself failed, the next ones would too.
+				break
+			}
+		}
+	}
+	return bodies, errors.Join(errs...)
+}
+
+func (api *GTasksApi) sendBatch(ctx context.Context, calls []batchCall) ([][]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for i, call := range calls {
+		data, err := json.Marshal(call.body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Type", "application/http")
+		h.Set("Content-ID", fmt.Sprintf("<item%d>", i))
+		pw, err := mw.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(pw, "%s %s HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s\r\n",
+			call.method, call.path, len(data), data)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	endpoint := api.batchURL
+	if endpoint == "" {
+		endpoint = batchURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := apis.HandleResponseStatusCode(resp.StatusCode); err != nil {
+		return nil, err
+	}
+
+	return readBatchResponse(resp, len(calls))
+}
+
+func readBatchResponse(resp *http.Response, n int) ([][]byte, error) {
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch response content type: %w", err)
+	}
+
+	bodies := make([][]byte, n)
+	var errs []error
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Keep the bodies read so far, their calls succeeded.
+			errs = append(errs, fmt.Errorf("failed to read batch response: %w", err))
+			break
+		}
+		i, data, err := readBatchPart(part, n)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		bodies[i] = data
+	}
+
+	if len(errs) == 0 {
+		for i, b := range bodies {
+			if b == nil {
+				errs = append(errs, fmt.Errorf("missing batch response for call %d", i))
+			}
+		}
+	}
+	// The calls that succeeded are returned with the errors of the others.
+	return bodies, errors.Join(errs...)
+}
+
+// readBatchPart returns the index of the call answered by part and its
+// response body.
+func readBatchPart(part *multipart.Part, n int) (int, []byte, error) {
+	id := strings.TrimPrefix(strings.Trim(part.Header.Get("Content-ID"), "<>"), "response-item")
+	i, err := strconv.Atoi(id)
+	if err != nil || i < 0 || i >= n {
+		return 0, nil, fmt.Errorf("unexpected batch response part '%s'", part.Header.Get("Content-ID"))
+	}
+
+	r, err := http.ReadResponse(bufio.NewReader(part), nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read batch response part %d: %w", i, err)
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read batch response part %d: %w", i, err)
+	}
+	if err := apis.HandleResponseStatusCode(r.StatusCode); err != nil {
+		return 0, nil, fmt.Errorf("batch call %d: %w", i, err)
+	}
+	return i, data, nil
+}
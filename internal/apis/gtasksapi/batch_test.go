@@ -0,0 +1,118 @@
+package gtasksapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gtasks "google.golang.org/api/tasks/v1"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// batchServer answers every call of a batch request by creating a task
+// whose ID is derived from its title, failing the calls of tasks titled
+// "fail".
+func batchServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Fatalf("invalid content type: %v", err)
+		}
+		type call struct {
+			id    string
+			title string
+		}
+		var calls []call
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			req, err := http.ReadRequest(bufio.NewReader(part))
+			if err != nil {
+				t.Fatalf("invalid batch part: %v", err)
+			}
+			if want := "/tasks/v1/lists/list%201/tasks"; req.URL.EscapedPath() != want {
+				t.Errorf("part path = %s, want %s", req.URL.EscapedPath(), want)
+			}
+			var g gtasks.Task
+			json.NewDecoder(req.Body).Decode(&g)
+			calls = append(calls, call{id: part.Header.Get("Content-ID"), title: g.Title})
+		}
+
+		// The request is read in full first, the server may close its body
+		// once the response is being written.
+		mw := multipart.NewWriter(w)
+		w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+		for _, c := range calls {
+			body, _ := json.Marshal(gtasks.Task{Id: "id-" + c.title, Title: c.title})
+			status := "200 OK"
+			if c.title == "fail" {
+				body, status = []byte(`{}`), "400 Bad Request"
+			}
+			pw, _ := mw.CreatePart(map[string][]string{
+				"Content-Type": {"application/http"},
+				"Content-ID":   {"<response-" + c.id[1:]},
+			})
+			fmt.Fprintf(pw, "HTTP/1.1 %s\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", status, len(body), body)
+		}
+		mw.Close()
+	}))
+}
+
+func TestCreateTasks(t *testing.T) {
+	srv := batchServer(t)
+	defer srv.Close()
+
+	api := &GTasksApi{client: srv.Client(), batchURL: srv.URL, ListId: "list 1"}
+
+	var ts []*tasks.Task
+	for i := range maxBatchSize + 3 {
+		ts = append(ts, &tasks.Task{ID: i, Title: fmt.Sprint(i)})
+	}
+	created, err := api.CreateTasks(context.Background(), ts)
+	if err != nil {
+		t.Fatalf("CreateTasks() error = %v", err)
+	}
+	for i, task := range created {
+		if want := fmt.Sprintf("id-%d", i); task.GoogleID != want {
+			t.Errorf("task %d GoogleID = %q, want %q", i, task.GoogleID, want)
+		}
+	}
+}
+
+// TestCreateTasksPartialFailure checks that the tasks created by a batch
+// have their ID set even though other calls failed.
+func TestCreateTasksPartialFailure(t *testing.T) {
+	srv := batchServer(t)
+	defer srv.Close()
+
+	api := &GTasksApi{client: srv.Client(), batchURL: srv.URL, ListId: "list 1"}
+
+	var ts []*tasks.Task
+	for i := range maxBatchSize + 3 {
+		ts = append(ts, &tasks.Task{ID: i, Title: fmt.Sprint(i)})
+	}
+	ts[1].Title = "fail"
+	created, err := api.CreateTasks(context.Background(), ts)
+	if err == nil {
+		t.Fatal("CreateTasks() succeeded, want the error of the failed call")
+	}
+	for i, task := range created {
+		want := fmt.Sprintf("id-%d", i)
+		if i == 1 {
+			want = ""
+		}
+		if task.GoogleID != want {
+			t.Errorf("task %d GoogleID = %q, want %q", i, task.GoogleID, want)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package gtasksapi
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"time"
 
@@ -10,6 +12,10 @@ import (
 	gtasks "google.golang.org/api/tasks/v1"
 
 	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+	"github.com/zeerodex/goot/internal/ratelimit"
 	"github.com/zeerodex/goot/internal/tasks"
 )
 
@@ -19,7 +25,10 @@ var (
 	tokenURL = "https://oauth2.googleapis.com/token"
 )
 
-func GetService() (*gtasks.Service, error) {
+// DefaultRateLimit stays well below the per user quota of the Tasks API.
+var DefaultRateLimit = config.RateLimit{Rate: 5, Burst: 10}
+
+func newClient(limiter *ratelimit.Limiter, logger *slog.Logger) (*http.Client, error) {
 	clientID, clientSecret := os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET")
 	client, err := apis.NewOAuthHandler(
 		clientID,
@@ -33,6 +42,19 @@ func GetService() (*gtasks.Service, error) {
 		// HACK:
 		return nil, fmt.Errorf("failed to init oauth handler: %w", err)
 	}
+	client = metrics.WrapClient(logging.WrapClient(client, logger), "gtasks")
+	return ratelimit.WrapClient(client, limiter, "gtasks", logger), nil
+}
+
+func GetService(limiter *ratelimit.Limiter, logger *slog.Logger) (*gtasks.Service, error) {
+	client, err := newClient(limiter, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newService(client)
+}
+
+func newService(client *http.Client) (*gtasks.Service, error) {
 	srv, err := gtasks.NewService(context.Background(), option.WithHTTPClient(client))
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve tasks service: %w", err)
@@ -41,21 +63,33 @@ func GetService() (*gtasks.Service, error) {
 }
 
 type GTasksApi struct {
-	srv *gtasks.Service
+	srv    *gtasks.Service
+	client *http.Client
+	// batchURL overrides the batch endpoint in tests.
+	batchURL string
 
 	ListId string
 }
 
-func NewGTasksApi(listId string) (apis.API, error) {
-	srv, err := GetService()
+func NewGTasksApi(listId string, limiter *ratelimit.Limiter, logger *slog.Logger) (apis.BatchAPI, error) {
+	client, err := newClient(limiter, logger.With("api", "gtasks"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gtasks service: %w", err)
+	}
+	srv, err := newService(client)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gtasks service: %w", err)
 	}
-	return &GTasksApi{srv: srv, ListId: listId}, nil
+	return &GTasksApi{srv: srv, client: client, ListId: listId}, nil
+}
+
+// Capabilities of Google Tasks, which discards the time of due dates.
+func (api *GTasksApi) Capabilities() apis.Capabilities {
+	return apis.Capabilities{Description: true, Subtasks: true, Tombstones: true}
 }
 
-func (api *GTasksApi) CreateTask(task *tasks.Task) (*tasks.Task, error) {
-	gtask, err := api.srv.Tasks.Insert(api.ListId, task.GTask()).Do()
+func (api *GTasksApi) CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	gtask, err := api.srv.Tasks.Insert(api.ListId, task.GTask()).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create task in list '%s': %w", api.ListId, err)
 	}
@@ -63,16 +97,16 @@ func (api *GTasksApi) CreateTask(task *tasks.Task) (*tasks.Task, error) {
 	return task, nil
 }
 
-func (api *GTasksApi) GetTaskByID(id string) (*tasks.Task, error) {
-	gtask, err := api.srv.Tasks.Get(api.ListId, id).Do()
+func (api *GTasksApi) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	gtask, err := api.srv.Tasks.Get(api.ListId, id).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve task '%s' from list '%s': %w", id, api.ListId, err)
 	}
 	return ConvertGTask(gtask), nil
 }
 
-func (api *GTasksApi) GetAllLists() (tasks.TasksLists, error) {
-	glists, err := api.srv.Tasklists.List().Do()
+func (api *GTasksApi) GetAllLists(ctx context.Context) (tasks.TasksLists, error) {
+	glists, err := api.srv.Tasklists.List().Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve all task lists: %w", err)
 	}
@@ -83,8 +117,8 @@ func (api *GTasksApi) GetAllLists() (tasks.TasksLists, error) {
 	return lists, nil
 }
 
-func (api *GTasksApi) GetAllTasks() (tasks.Tasks, error) {
-	gtasks, err := api.srv.Tasks.List(api.ListId).ShowCompleted(true).Do()
+func (api *GTasksApi) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	gtasks, err := api.srv.Tasks.List(api.ListId).ShowCompleted(true).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve all tasks from list '%s': %w", api.ListId, err)
 	}
@@ -97,8 +131,8 @@ func (api *GTasksApi) GetAllTasks() (tasks.Tasks, error) {
 	return tasksList, nil
 }
 
-func (api *GTasksApi) GetAllTasksWithDeleted() (tasks.Tasks, error) {
-	gtasks, err := api.srv.Tasks.List(api.ListId).ShowDeleted(true).ShowCompleted(true).ShowHidden(true).Do()
+func (api *GTasksApi) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	gtasks, err := api.srv.Tasks.List(api.ListId).ShowDeleted(true).ShowCompleted(true).ShowHidden(true).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve all tasks with deleted from list '%s': %w", api.ListId, err)
 	}
@@ -111,23 +145,23 @@ func (api *GTasksApi) GetAllTasksWithDeleted() (tasks.Tasks, error) {
 	return tasksList, nil
 }
 
-func (api *GTasksApi) DeleteTaskByID(id string) error {
-	err := api.srv.Tasks.Delete(api.ListId, id).Do()
+func (api *GTasksApi) DeleteTaskByID(ctx context.Context, id string) error {
+	err := api.srv.Tasks.Delete(api.ListId, id).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("failed to delete task '%s' from list '%s': %w", id, api.ListId, err)
 	}
 	return nil
 }
 
-func (api *GTasksApi) PatchTask(task *tasks.Task) (*tasks.Task, error) {
-	g, err := api.srv.Tasks.Patch(api.ListId, task.GoogleID, task.GTask()).Do()
+func (api *GTasksApi) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	g, err := api.srv.Tasks.Patch(api.ListId, task.GoogleID, task.GTask()).Context(ctx).Do()
 	if err != nil {
 		return nil, fmt.Errorf("failed to patch task '%s' from list '%s': %w", task.GoogleID, api.ListId, err)
 	}
 	return ConvertGTask(g), nil
 }
 
-func (api *GTasksApi) SetTaskCompleted(id string, completed bool) error {
+func (api *GTasksApi) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
 	gtask := &gtasks.Task{}
 	if completed {
 		gtask.Status = "completed"
@@ -135,7 +169,7 @@ func (api *GTasksApi) SetTaskCompleted(id string, completed bool) error {
 		gtask.Status = "needsAction"
 	}
 
-	_, err := api.srv.Tasks.Patch(api.ListId, id, gtask).Do()
+	_, err := api.srv.Tasks.Patch(api.ListId, id, gtask).Context(ctx).Do()
 	if err != nil {
 		return fmt.Errorf("failed to delete task '%s' from list '%s': %v", id, api.ListId, err)
 	}
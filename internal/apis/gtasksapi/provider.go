@@ -0,0 +1,25 @@
+package gtasksapi
+
+import (
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// Settings are read from the "google" config section.
+type Settings struct {
+	ListId string `mapstructure:"list-id"`
+}
+
+func init() {
+	apis.Register(apis.Provider{
+		Name:        "gtasks",
+		ConfigKey:   "google",
+		NewSettings: func() any { return &Settings{ListId: "@default"} },
+		New: func(settings any, opts apis.Options) (apis.API, error) {
+			return NewGTasksApi(settings.(*Settings).ListId, opts.Limiter, opts.Logger)
+		},
+		RateLimit: DefaultRateLimit,
+		ID:        func(t *tasks.Task) string { return t.GoogleID },
+		SetID:     func(t *tasks.Task, id string) { t.GoogleID = id },
+	})
+}
@@ -0,0 +1,212 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+	"github.com/zeerodex/goot/internal/ratelimit"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const (
+	// GitHubName of the provider, under which task IDs are stored.
+	GitHubName    = "github"
+	GitHubBaseURL = "https://api.github.com"
+)
+
+// GitHubRateLimit follows the quota of 5000 requests per hour.
+var GitHubRateLimit = config.RateLimit{Rate: 5000.0 / 3600, Burst: 20}
+
+// GitHubAPI imports issues from GitHub or GitHub Enterprise. Task IDs have
+// the form "owner/repo#number".
+type GitHubAPI struct {
+	c     *restClient
+	query string
+}
+
+func NewGitHubAPI(s *Settings, limiter *ratelimit.Limiter, logger *slog.Logger) (*GitHubAPI, error) {
+	if s.Token == "" {
+		return nil, fmt.Errorf("github token is not set")
+	}
+	logger = logger.With("api", GitHubName)
+	client := metrics.WrapClient(logging.WrapClient(http.DefaultClient, logger), GitHubName)
+	return newGitHubAPI(ratelimit.WrapClient(client, limiter, GitHubName, logger), s), nil
+}
+
+func newGitHubAPI(client *http.Client, s *Settings) *GitHubAPI {
+	baseURL := s.BaseURL
+	if baseURL == "" {
+		baseURL = GitHubBaseURL
+	}
+	return &GitHubAPI{
+		c: &restClient{
+			client:  client,
+			baseURL: baseURL,
+			header: http.Header{
+				"Accept":               {"application/vnd.github+json"},
+				"Authorization":        {"Bearer " + s.Token},
+				"X-Github-Api-Version": {"2022-11-28"},
+			},
+		},
+		query: s.Query,
+	}
+}
+
+type ghIssue struct {
+	Number        int    `json:"number"`
+	Title         string `json:"title"`
+	Body          string `json:"body"`
+	State         string `json:"state"`
+	UpdatedAt     string `json:"updated_at"`
+	RepositoryURL string `json:"repository_url"`
+	PullRequest   any    `json:"pull_request"`
+	Labels        []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		DueOn string `json:"due_on"`
+	} `json:"milestone"`
+}
+
+// repo returns the "owner/repo" the issue belongs to.
+func (i *ghIssue) repo() string {
+	_, repo, _ := strings.Cut(i.RepositoryURL, "/repos/")
+	return repo
+}
+
+func (i *ghIssue) Task() *tasks.Task {
+	t := &tasks.Task{
+		Title:       i.Title,
+		Description: i.Body,
+		Completed:   i.State == "closed",
+	}
+	t.SetAPIID(GitHubName, fmt.Sprintf("%s#%d", i.repo(), i.Number))
+	if i.Milestone != nil {
+		t.Due = dueDate(i.Milestone.DueOn)
+	}
+	for _, l := range i.Labels {
+		t.Tags = append(t.Tags, l.Name)
+	}
+	t.LastModified, _ = time.Parse(time.RFC3339, i.UpdatedAt)
+	return t
+}
+
+// parseGitHubID splits an "owner/repo#number" ID.
+func parseGitHubID(id string) (repo string, number int, err error) {
+	repo, n, ok := strings.Cut(id, "#")
+	if ok {
+		number, err = strconv.Atoi(n)
+	}
+	if !ok || err != nil || strings.Count(repo, "/") != 1 {
+		return "", 0, fmt.Errorf("invalid github issue ID '%s'", id)
+	}
+	return repo, number, nil
+}
+
+func (api *GitHubAPI) Capabilities() apis.Capabilities {
+	return capabilities
+}
+
+// GetAllTasks returns the open issues assigned to the user, those closed
+// recently, and the issues matching the configured query.
+func (api *GitHubAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	since := time.Now().Add(-closedWindow).UTC().Format(time.RFC3339)
+	var issues []ghIssue
+	for _, endpoint := range []string{
+		"/issues?filter=assigned&state=open&per_page=100",
+		"/issues?filter=assigned&state=closed&per_page=100&since=" + url.QueryEscape(since),
+	} {
+		if err := getAll(ctx, api.c, endpoint, &issues); err != nil {
+			return nil, fmt.Errorf("failed to retrieve assigned issues: %w", err)
+		}
+	}
+
+	if api.query != "" {
+		for next := "/search/issues?per_page=100&q=" + url.QueryEscape(api.query); next != ""; {
+			var result struct {
+				Items []ghIssue `json:"items"`
+			}
+			var err error
+			next, err = api.c.do(ctx, "GET", next, nil, &result)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search issues: %w", err)
+			}
+			issues = append(issues, result.Items...)
+		}
+	}
+
+	var tasksList tasks.Tasks
+	for _, i := range issues {
+		if i.PullRequest != nil {
+			continue
+		}
+		tasksList = append(tasksList, *i.Task())
+	}
+	return dedup(tasksList, GitHubName), nil
+}
+
+func (api *GitHubAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	return api.GetAllTasks(ctx)
+}
+
+func (api *GitHubAPI) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	repo, number, err := parseGitHubID(id)
+	if err != nil {
+		return nil, err
+	}
+	var i ghIssue
+	if _, err := api.c.do(ctx, "GET", fmt.Sprintf("/repos/%s/issues/%d", repo, number), nil, &i); err != nil {
+		return nil, fmt.Errorf("failed to retrieve issue '%s': %w", id, err)
+	}
+	return i.Task(), nil
+}
+
+func (api *GitHubAPI) CreateTask(_ context.Context, _ *tasks.Task) (*tasks.Task, error) {
+	return nil, ErrReadOnly
+}
+
+// PatchTask only writes back the completion of the task.
+func (api *GitHubAPI) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	i, err := api.setState(ctx, task.APIID(GitHubName), task.Completed)
+	if err != nil {
+		return nil, err
+	}
+	return i.Task(), nil
+}
+
+func (api *GitHubAPI) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
+	_, err := api.setState(ctx, id, completed)
+	return err
+}
+
+func (api *GitHubAPI) setState(ctx context.Context, id string, closed bool) (*ghIssue, error) {
+	repo, number, err := parseGitHubID(id)
+	if err != nil {
+		return nil, err
+	}
+	state := "open"
+	if closed {
+		state = "closed"
+	}
+	var i ghIssue
+	if _, err := api.c.do(ctx, "PATCH", fmt.Sprintf("/repos/%s/issues/%d", repo, number), map[string]string{"state": state}, &i); err != nil {
+		return nil, fmt.Errorf("failed to set state of issue '%s': %w", id, err)
+	}
+	return &i, nil
+}
+
+// DeleteTaskByID leaves the issue untouched, deleting its task in goot
+// only stops tracking it.
+func (api *GitHubAPI) DeleteTaskByID(_ context.Context, _ string) error {
+	return nil
+}
@@ -0,0 +1,192 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+	"github.com/zeerodex/goot/internal/ratelimit"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const (
+	// GitLabName of the provider, under which task IDs are stored.
+	GitLabName    = "gitlab"
+	GitLabBaseURL = "https://gitlab.com/api/v4"
+)
+
+// GitLabRateLimit stays well below the GitLab.com quota of 2000 requests
+// per minute.
+var GitLabRateLimit = config.RateLimit{Rate: 10, Burst: 20}
+
+// GitLabAPI imports issues from GitLab.com or a self-managed instance.
+// Task IDs have the form "projectID#iid".
+type GitLabAPI struct {
+	c     *restClient
+	query string
+}
+
+func NewGitLabAPI(s *Settings, limiter *ratelimit.Limiter, logger *slog.Logger) (*GitLabAPI, error) {
+	if s.Token == "" {
+		return nil, fmt.Errorf("gitlab token is not set")
+	}
+	logger = logger.With("api", GitLabName)
+	client := metrics.WrapClient(logging.WrapClient(http.DefaultClient, logger), GitLabName)
+	return newGitLabAPI(ratelimit.WrapClient(client, limiter, GitLabName, logger), s), nil
+}
+
+func newGitLabAPI(client *http.Client, s *Settings) *GitLabAPI {
+	baseURL := s.BaseURL
+	if baseURL == "" {
+		baseURL = GitLabBaseURL
+	}
+	return &GitLabAPI{
+		c: &restClient{
+			client:  client,
+			baseURL: baseURL,
+			header:  http.Header{"Private-Token": {s.Token}},
+		},
+		query: s.Query,
+	}
+}
+
+type glIssue struct {
+	IID         int      `json:"iid"`
+	ProjectID   int      `json:"project_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	State       string   `json:"state"`
+	Labels      []string `json:"labels"`
+	DueDate     string   `json:"due_date"`
+	UpdatedAt   string   `json:"updated_at"`
+	Milestone   *struct {
+		DueDate string `json:"due_date"`
+	} `json:"milestone"`
+}
+
+func (i *glIssue) Task() *tasks.Task {
+	t := &tasks.Task{
+		Title:       i.Title,
+		Description: i.Description,
+		Completed:   i.State == "closed",
+		Tags:        i.Labels,
+	}
+	t.SetAPIID(GitLabName, fmt.Sprintf("%d#%d", i.ProjectID, i.IID))
+	// The due date of the issue takes precedence over its milestone's.
+	t.Due = dueDate(i.DueDate)
+	if t.Due.IsZero() && i.Milestone != nil {
+		t.Due = dueDate(i.Milestone.DueDate)
+	}
+	t.LastModified, _ = time.Parse(time.RFC3339, i.UpdatedAt)
+	return t
+}
+
+// parseGitLabID splits a "projectID#iid" ID.
+func parseGitLabID(id string) (project, iid int, err error) {
+	p, n, ok := strings.Cut(id, "#")
+	if ok {
+		project, err = strconv.Atoi(p)
+		if err == nil {
+			iid, err = strconv.Atoi(n)
+		}
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("invalid gitlab issue ID '%s'", id)
+	}
+	return project, iid, nil
+}
+
+func (api *GitLabAPI) Capabilities() apis.Capabilities {
+	return capabilities
+}
+
+// GetAllTasks returns the open issues assigned to the user, those closed
+// recently, and the issues matching the configured query.
+func (api *GitLabAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	since := time.Now().Add(-closedWindow).UTC().Format(time.RFC3339)
+	endpoints := []string{
+		"/issues?scope=assigned_to_me&state=opened&per_page=100",
+		"/issues?scope=assigned_to_me&state=closed&per_page=100&updated_after=" + url.QueryEscape(since),
+	}
+	if api.query != "" {
+		endpoints = append(endpoints, "/issues?per_page=100&"+api.query)
+	}
+
+	var issues []glIssue
+	for _, endpoint := range endpoints {
+		if err := getAll(ctx, api.c, endpoint, &issues); err != nil {
+			return nil, fmt.Errorf("failed to retrieve issues: %w", err)
+		}
+	}
+
+	tasksList := make(tasks.Tasks, len(issues))
+	for i, issue := range issues {
+		tasksList[i] = *issue.Task()
+	}
+	return dedup(tasksList, GitLabName), nil
+}
+
+func (api *GitLabAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	return api.GetAllTasks(ctx)
+}
+
+func (api *GitLabAPI) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	project, iid, err := parseGitLabID(id)
+	if err != nil {
+		return nil, err
+	}
+	var i glIssue
+	if _, err := api.c.do(ctx, "GET", fmt.Sprintf("/projects/%d/issues/%d", project, iid), nil, &i); err != nil {
+		return nil, fmt.Errorf("failed to retrieve issue '%s': %w", id, err)
+	}
+	return i.Task(), nil
+}
+
+func (api *GitLabAPI) CreateTask(_ context.Context, _ *tasks.Task) (*tasks.Task, error) {
+	return nil, ErrReadOnly
+}
+
+// PatchTask only writes back the completion of the task.
+func (api *GitLabAPI) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	i, err := api.setState(ctx, task.APIID(GitLabName), task.Completed)
+	if err != nil {
+		return nil, err
+	}
+	return i.Task(), nil
+}
+
+func (api *GitLabAPI) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
+	_, err := api.setState(ctx, id, completed)
+	return err
+}
+
+func (api *GitLabAPI) setState(ctx context.Context, id string, closed bool) (*glIssue, error) {
+	project, iid, err := parseGitLabID(id)
+	if err != nil {
+		return nil, err
+	}
+	event := "reopen"
+	if closed {
+		event = "close"
+	}
+	var i glIssue
+	if _, err := api.c.do(ctx, "PUT", fmt.Sprintf("/projects/%d/issues/%d", project, iid), map[string]string{"state_event": event}, &i); err != nil {
+		return nil, fmt.Errorf("failed to set state of issue '%s': %w", id, err)
+	}
+	return &i, nil
+}
+
+// DeleteTaskByID leaves the issue untouched, deleting its task in goot
+// only stops tracking it.
+func (api *GitLabAPI) DeleteTaskByID(_ context.Context, _ string) error {
+	return nil
+}
@@ -0,0 +1,144 @@
+// Package issues imports the issues assigned to the user on GitHub or
+// GitLab as tasks. Issues cannot be created or edited from goot, only
+// closed and reopened by completing their task.
+package issues
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// closedWindow is how far back closed issues are imported, so that tasks
+// completed from the tracker are completed in goot too.
+const closedWindow = 30 * 24 * time.Hour
+
+// ErrReadOnly is returned when creating a task in an issue tracker.
+var ErrReadOnly = errors.New("issues cannot be created from goot")
+
+// Settings are read from the "github" and "gitlab" config sections. Token
+// defaults to the GITHUB_TOKEN or GITLAB_TOKEN environment variable.
+type Settings struct {
+	// BaseURL of the REST API, e.g. https://github.example.com/api/v3 for
+	// GitHub Enterprise.
+	BaseURL string `mapstructure:"base-url"`
+	Token   string `mapstructure:"token"`
+	// Query selects more issues: a search query on GitHub, such as
+	// "repo:org/app is:issue label:bug", and the parameters of the issues
+	// endpoint on GitLab, such as "scope=all&labels=bug".
+	Query string `mapstructure:"query"`
+}
+
+// restClient sends JSON requests to a REST API paginated with Link headers.
+type restClient struct {
+	client  *http.Client
+	baseURL string
+	header  http.Header
+}
+
+// do sends a request to endpoint, or to the absolute URL endpoint, and
+// decodes the response body into v unless v is nil. It returns the URL of
+// the next page, if any.
+func (c *restClient) do(ctx context.Context, method, endpoint string, data any, v any) (string, error) {
+	var reqBody io.Reader
+	if data != nil {
+		jsonBody, err := json.Marshal(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+	u := endpoint
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		u = strings.TrimSuffix(c.baseURL, "/") + endpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := apis.HandleResponseStatusCode(resp.StatusCode); err != nil {
+		return "", err
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return "", fmt.Errorf("error decoding json: %w", err)
+		}
+	}
+	return nextLink(resp.Header.Get("Link")), nil
+}
+
+var linkRe = regexp.MustCompile(`<([^>]+)>;\s*rel="([^"]+)"`)
+
+// nextLink returns the rel="next" URL of a Link header.
+func nextLink(header string) string {
+	for _, m := range linkRe.FindAllStringSubmatch(header, -1) {
+		if m[2] == "next" {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// getAll fetches every page of endpoint, appending the decoded items.
+func getAll[T any](ctx context.Context, c *restClient, endpoint string, items *[]T) error {
+	for next := endpoint; next != ""; {
+		var page []T
+		var err error
+		next, err = c.do(ctx, "GET", next, nil, &page)
+		if err != nil {
+			return err
+		}
+		*items = append(*items, page...)
+	}
+	return nil
+}
+
+// dueDate returns the date of an RFC 3339 or YYYY-MM-DD timestamp as a
+// local date, trackers only keeping the day.
+func dueDate(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	if len(s) > len("2006-01-02") {
+		s = s[:len("2006-01-02")]
+	}
+	due, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return due
+}
+
+// capabilities of issue trackers. Due dates come from milestones.
+var capabilities = apis.Capabilities{Description: true, Labels: true, ReadMostly: true}
+
+// dedup drops the tasks whose ID was already seen, keeping the first.
+func dedup(ts tasks.Tasks, name string) tasks.Tasks {
+	seen := make(map[string]bool)
+	var out tasks.Tasks
+	for _, t := range ts {
+		if id := t.APIID(name); !seen[id] {
+			seen[id] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
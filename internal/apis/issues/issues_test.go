@@ -0,0 +1,212 @@
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func TestNextLink(t *testing.T) {
+	header := `<https://api.github.com/issues?page=1>; rel="prev", <https://api.github.com/issues?page=3>; rel="next", <https://api.github.com/issues?page=5>; rel="last"`
+	if got := nextLink(header); got != "https://api.github.com/issues?page=3" {
+		t.Errorf("nextLink() = %q", got)
+	}
+	if got := nextLink(""); got != "" {
+		t.Errorf("nextLink(\"\") = %q, want empty", got)
+	}
+}
+
+// newGitHubServer serves a GitHub Enterprise API under /api/v3 with two
+// pages of assigned issues, one of them a pull request.
+func newGitHubServer(t *testing.T) (*httptest.Server, *string) {
+	var closedState string
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		repoURL := srv.URL + "/api/v3/repos/org/app"
+		switch {
+		case r.URL.Query().Get("state") == "closed":
+			if r.URL.Query().Get("since") == "" {
+				t.Errorf("closed issues requested without since")
+			}
+			fmt.Fprint(w, `[]`)
+		case r.URL.Query().Get("page") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/issues?filter=assigned&state=open&page=2>; rel="next"`, srv.URL))
+			fmt.Fprintf(w, `[
+				{"number": 1, "title": "Crash on start", "body": "stack trace", "state": "open",
+				 "updated_at": "2025-05-01T10:00:00Z", "repository_url": %q,
+				 "labels": [{"name": "bug"}, {"name": "p1"}],
+				 "milestone": {"due_on": "2025-06-01T07:00:00Z"}},
+				{"number": 2, "title": "Fix crash", "state": "open", "repository_url": %q,
+				 "pull_request": {"url": "x"}}
+			]`, repoURL, repoURL)
+		default:
+			fmt.Fprintf(w, `[{"number": 3, "title": "Docs", "state": "open", "repository_url": %q}]`, repoURL)
+		}
+	})
+	mux.HandleFunc("GET /api/v3/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "repo:org/app label:bug" {
+			t.Errorf("search query = %q", q)
+		}
+		fmt.Fprintf(w, `{"items": [
+			{"number": 1, "title": "Crash on start", "state": "open", "repository_url": %q},
+			{"number": 7, "title": "Leak", "state": "closed", "repository_url": %q}
+		]}`, srv.URL+"/api/v3/repos/org/app", srv.URL+"/api/v3/repos/org/lib")
+	})
+	mux.HandleFunc("PATCH /api/v3/repos/{owner}/{repo}/issues/{n}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			State string `json:"state"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		closedState = body.State
+		fmt.Fprintf(w, `{"number": %s, "title": "Crash on start", "state": %q, "repository_url": %q}`,
+			r.PathValue("n"), body.State, srv.URL+"/api/v3/repos/"+r.PathValue("owner")+"/"+r.PathValue("repo"))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &closedState
+}
+
+func TestGitHubAPI(t *testing.T) {
+	srv, state := newGitHubServer(t)
+	api := newGitHubAPI(srv.Client(), &Settings{
+		BaseURL: srv.URL + "/api/v3",
+		Token:   "secret",
+		Query:   "repo:org/app label:bug",
+	})
+	ctx := context.Background()
+
+	ts, err := api.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+	var ids []string
+	for _, task := range ts {
+		ids = append(ids, task.APIID(GitHubName))
+	}
+	if want := []string{"org/app#1", "org/app#3", "org/lib#7"}; !slices.Equal(ids, want) {
+		t.Fatalf("imported IDs = %v, want %v", ids, want)
+	}
+
+	crash := ts[0]
+	if crash.Title != "Crash on start" || crash.Description != "stack trace" {
+		t.Errorf("crash = %+v", crash)
+	}
+	if want := time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local); !crash.Due.Equal(want) {
+		t.Errorf("due = %v, want %v", crash.Due, want)
+	}
+	if !slices.Equal(crash.Tags, []string{"bug", "p1"}) {
+		t.Errorf("tags = %v", crash.Tags)
+	}
+	if !ts[2].Completed {
+		t.Errorf("closed issue imported as not completed")
+	}
+
+	if err := api.SetTaskCompleted(ctx, "org/app#1", true); err != nil {
+		t.Fatalf("SetTaskCompleted: %v", err)
+	}
+	if *state != "closed" {
+		t.Errorf("state = %q, want closed", *state)
+	}
+	crash.Completed = false
+	patched, err := api.PatchTask(ctx, &crash)
+	if err != nil {
+		t.Fatalf("PatchTask: %v", err)
+	}
+	if *state != "open" || patched.Completed {
+		t.Errorf("reopen: state = %q, completed = %v", *state, patched.Completed)
+	}
+
+	if _, err := api.CreateTask(ctx, &tasks.Task{Title: "new"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("CreateTask error = %v, want ErrReadOnly", err)
+	}
+	if err := api.SetTaskCompleted(ctx, "not-an-id", true); err == nil {
+		t.Errorf("SetTaskCompleted with an invalid ID succeeded")
+	}
+}
+
+func TestGitLabAPI(t *testing.T) {
+	var event string
+	var srv *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Private-Token") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		switch {
+		case q.Get("labels") == "ops":
+			fmt.Fprint(w, `[{"iid": 5, "project_id": 42, "title": "Rotate keys", "state": "opened"}]`)
+		case q.Get("state") == "closed":
+			fmt.Fprint(w, `[{"iid": 2, "project_id": 42, "title": "Old", "state": "closed", "updated_at": "2025-05-02T08:00:00Z"}]`)
+		case q.Get("page") == "":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v4/issues?scope=assigned_to_me&state=opened&page=2>; rel="next"`, srv.URL))
+			fmt.Fprint(w, `[{"iid": 1, "project_id": 42, "title": "Upgrade", "description": "to v2", "state": "opened",
+				"labels": ["infra"], "due_date": "2025-06-03", "milestone": {"due_date": "2025-07-01"}}]`)
+		default:
+			fmt.Fprint(w, `[{"iid": 3, "project_id": 7, "title": "Release", "state": "opened",
+				"milestone": {"due_date": "2025-07-01"}}]`)
+		}
+	})
+	mux.HandleFunc("PUT /api/v4/projects/{project}/issues/{iid}", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			StateEvent string `json:"state_event"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		event = body.StateEvent
+		state := "opened"
+		if event == "close" {
+			state = "closed"
+		}
+		fmt.Fprintf(w, `{"iid": %s, "project_id": %s, "state": %q}`, r.PathValue("iid"), r.PathValue("project"), state)
+	})
+	srv = httptest.NewServer(mux)
+	defer srv.Close()
+
+	api := newGitLabAPI(srv.Client(), &Settings{BaseURL: srv.URL + "/api/v4", Token: "secret", Query: "scope=all&labels=ops"})
+	ctx := context.Background()
+
+	ts, err := api.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+	var ids []string
+	for _, task := range ts {
+		ids = append(ids, task.APIID(GitLabName))
+	}
+	if want := []string{"42#1", "7#3", "42#2", "42#5"}; !slices.Equal(ids, want) {
+		t.Fatalf("imported IDs = %v, want %v", ids, want)
+	}
+	if want := time.Date(2025, 6, 3, 0, 0, 0, 0, time.Local); !ts[0].Due.Equal(want) {
+		t.Errorf("issue due = %v, want %v", ts[0].Due, want)
+	}
+	if want := time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local); !ts[1].Due.Equal(want) {
+		t.Errorf("milestone due = %v, want %v", ts[1].Due, want)
+	}
+	if !slices.Equal(ts[0].Tags, []string{"infra"}) || ts[0].Description != "to v2" {
+		t.Errorf("upgrade = %+v", ts[0])
+	}
+	if !ts[2].Completed {
+		t.Errorf("closed issue imported as not completed")
+	}
+
+	if err := api.SetTaskCompleted(ctx, "42#1", true); err != nil || event != "close" {
+		t.Errorf("close: err = %v, event = %q", err, event)
+	}
+	if err := api.SetTaskCompleted(ctx, "42#1", false); err != nil || event != "reopen" {
+		t.Errorf("reopen: err = %v, event = %q", err, event)
+	}
+}
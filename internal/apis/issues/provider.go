@@ -0,0 +1,34 @@
+package issues
+
+import (
+	"os"
+
+	"github.com/zeerodex/goot/internal/apis"
+)
+
+func init() {
+	apis.Register(apis.Provider{
+		Name:        GitHubName,
+		NewSettings: func() any { return &Settings{BaseURL: GitHubBaseURL} },
+		New: func(settings any, opts apis.Options) (apis.API, error) {
+			s := settings.(*Settings)
+			if s.Token == "" {
+				s.Token = os.Getenv("GITHUB_TOKEN")
+			}
+			return NewGitHubAPI(s, opts.Limiter, opts.Logger)
+		},
+		RateLimit: GitHubRateLimit,
+	})
+	apis.Register(apis.Provider{
+		Name:        GitLabName,
+		NewSettings: func() any { return &Settings{BaseURL: GitLabBaseURL} },
+		New: func(settings any, opts apis.Options) (apis.API, error) {
+			s := settings.(*Settings)
+			if s.Token == "" {
+				s.Token = os.Getenv("GITLAB_TOKEN")
+			}
+			return NewGitLabAPI(s, opts.Limiter, opts.Logger)
+		},
+		RateLimit: GitLabRateLimit,
+	})
+}
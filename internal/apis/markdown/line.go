@@ -0,0 +1,268 @@
+package markdown
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const dateLayout = "2006-01-02"
+
+// Field kinds of the Obsidian Tasks format.
+const (
+	fieldDue = iota
+	fieldDone
+	fieldPriority
+	fieldRecurrence
+	fieldID
+	fieldOther
+)
+
+// fieldRes match the fields Obsidian Tasks reads from the end of a task
+// line. Fields of kind fieldOther, such as start or scheduled dates, are
+// kept as written.
+var fieldRes = []struct {
+	kind int
+	re   *regexp.Regexp
+}{
+	{fieldDue, regexp.MustCompile(`(?:📅|📆|🗓)\x{FE0F}? *(\d{4}-\d{2}-\d{2})$`)},
+	{fieldDone, regexp.MustCompile(`✅\x{FE0F}? *(\d{4}-\d{2}-\d{2})$`)},
+	{fieldPriority, regexp.MustCompile(`(🔺|⏫|🔼|🔽|⏬)\x{FE0F}?$`)},
+	{fieldRecurrence, regexp.MustCompile(`🔁\x{FE0F}? *([a-zA-Z0-9, !]+)$`)},
+	{fieldID, regexp.MustCompile(`🆔\x{FE0F}? *([a-zA-Z0-9_-]+)$`)},
+	{fieldOther, regexp.MustCompile(`(?:🛫|⏳|⌛|➕|❌)\x{FE0F}? *\d{4}-\d{2}-\d{2}$`)},
+	{fieldOther, regexp.MustCompile(`⛔\x{FE0F}? *[a-zA-Z0-9_, -]+$`)},
+	{fieldOther, regexp.MustCompile(`\^[a-zA-Z0-9-]+$`)},
+}
+
+var (
+	taskLineRe = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+\[)(.)\]\s+(.*?)\s*$`)
+	listItemRe = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+	tagRe      = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+)
+
+var priorities = map[string]tasks.Priority{
+	"🔺": tasks.PriorityHigh,
+	"⏫": tasks.PriorityHigh,
+	"🔼": tasks.PriorityMedium,
+	"🔽": tasks.PriorityLow,
+	"⏬": tasks.PriorityLow,
+}
+
+var prioritySigns = map[tasks.Priority]string{
+	tasks.PriorityHigh:   "⏫",
+	tasks.PriorityMedium: "🔼",
+	tasks.PriorityLow:    "🔽",
+}
+
+type field struct {
+	kind  int
+	raw   string
+	value string
+}
+
+// taskLine is a checkbox list item. Rendering an unmodified taskLine gives
+// back the line it was parsed from, except for trailing spaces.
+type taskLine struct {
+	prefix string
+	status string
+	desc   string
+	fields []field
+	// notes are the indented lines following the task which are not list
+	// items, read as its description. They are kept as written.
+	notes []string
+}
+
+// parseTaskLine parses a checkbox list item, reading its fields from the
+// end of the line like Obsidian Tasks.
+func parseTaskLine(s string) (*taskLine, bool) {
+	m := taskLineRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	l := &taskLine{prefix: m[1], status: m[2]}
+	body := m[3]
+	for {
+		matched := false
+		for _, fr := range fieldRes {
+			fm := fr.re.FindStringSubmatchIndex(body)
+			if fm == nil {
+				continue
+			}
+			f := field{kind: fr.kind, raw: body[fm[0]:fm[1]]}
+			if len(fm) > 2 {
+				f.value = body[fm[2]:fm[3]]
+			}
+			l.fields = append([]field{f}, l.fields...)
+			body = strings.TrimRight(body[:fm[0]], " \t")
+			matched = true
+			break
+		}
+		if !matched {
+			break
+		}
+	}
+	l.desc = body
+	return l, true
+}
+
+func (l *taskLine) field(kind int) *field {
+	for i := range l.fields {
+		if l.fields[i].kind == kind {
+			return &l.fields[i]
+		}
+	}
+	return nil
+}
+
+// setField replaces the field of the given kind with raw, removing it if
+// raw is empty and appending it if missing.
+func (l *taskLine) setField(kind int, raw, value string) {
+	for i := range l.fields {
+		if l.fields[i].kind != kind {
+			continue
+		}
+		if raw == "" {
+			l.fields = append(l.fields[:i], l.fields[i+1:]...)
+		} else {
+			l.fields[i] = field{kind: kind, raw: raw, value: value}
+		}
+		return
+	}
+	if raw != "" {
+		l.fields = append(l.fields, field{kind: kind, raw: raw, value: value})
+	}
+}
+
+func (l *taskLine) completed() bool {
+	return l.status == "x" || l.status == "X" || l.status == "-"
+}
+
+// title returns the description without its tags.
+func (l *taskLine) title() string {
+	return strings.Join(strings.Fields(tagRe.ReplaceAllString(l.desc, " ")), " ")
+}
+
+func (l *taskLine) tags() []string {
+	var tags []string
+	for _, m := range tagRe.FindAllStringSubmatch(l.desc, -1) {
+		tags = append(tags, m[1])
+	}
+	return tags
+}
+
+func (l *taskLine) description() string {
+	notes := make([]string, len(l.notes))
+	for i, n := range l.notes {
+		notes[i] = strings.TrimSpace(n)
+	}
+	return strings.Join(notes, "\n")
+}
+
+func (l *taskLine) indent() string {
+	return l.prefix[:len(l.prefix)-len(strings.TrimLeft(l.prefix, " \t"))]
+}
+
+func (l *taskLine) String() string {
+	var sb strings.Builder
+	sb.WriteString(l.prefix)
+	sb.WriteString(l.status)
+	sb.WriteString("] ")
+	sb.WriteString(l.desc)
+	for _, f := range l.fields {
+		sb.WriteByte(' ')
+		sb.WriteString(f.raw)
+	}
+	return sb.String()
+}
+
+// lines returns the task line followed by its notes.
+func (l *taskLine) lines() []string {
+	return append([]string{l.String()}, l.notes...)
+}
+
+// Task returns the task described by the line, without ID.
+func (l *taskLine) Task() *tasks.Task {
+	t := &tasks.Task{
+		Title:       l.title(),
+		Description: l.description(),
+		Completed:   l.completed(),
+		Tags:        l.tags(),
+	}
+	if f := l.field(fieldDue); f != nil {
+		t.Due, _ = time.ParseInLocation(dateLayout, f.value, time.Local)
+	}
+	if f := l.field(fieldPriority); f != nil {
+		t.Priority = priorities[f.value]
+	}
+	if f := l.field(fieldRecurrence); f != nil {
+		t.Recurrence, _ = parseRecurrence(f.value)
+	}
+	return t
+}
+
+// update changes the line to describe task, keeping the parts the task
+// does not change as written. It reports whether the line changed.
+func (l *taskLine) update(task *tasks.Task, now time.Time) bool {
+	before := strings.Join(l.lines(), "\n")
+	current := l.Task()
+
+	if task.Title != current.Title || !slices.Equal(task.Tags, current.Tags) {
+		desc := task.Title
+		for _, tag := range task.Tags {
+			desc += " #" + tag
+		}
+		l.desc = desc
+	}
+	if task.Description != current.Description {
+		l.notes = nil
+		for _, n := range strings.Split(task.Description, "\n") {
+			if strings.TrimSpace(n) != "" {
+				l.notes = append(l.notes, l.indent()+"    "+strings.TrimSpace(n))
+			}
+		}
+	}
+	if task.Completed != current.Completed {
+		l.setCompleted(task.Completed, now)
+	}
+	if task.Priority != current.Priority {
+		l.setField(fieldPriority, prioritySigns[task.Priority], prioritySigns[task.Priority])
+	}
+	if task.Recurrence != current.Recurrence {
+		raw, rec := "", ""
+		if rec = formatRecurrence(task.Recurrence); rec != "" {
+			raw = "🔁 " + rec
+		}
+		l.setField(fieldRecurrence, raw, rec)
+	}
+	if !sameDay(task.Due, current.Due) {
+		raw, date := "", ""
+		if !task.Due.IsZero() {
+			date = task.Due.Local().Format(dateLayout)
+			raw = "📅 " + date
+		}
+		l.setField(fieldDue, raw, date)
+	}
+	return strings.Join(l.lines(), "\n") != before
+}
+
+func (l *taskLine) setCompleted(completed bool, now time.Time) {
+	if completed {
+		l.status = "x"
+		date := now.Format(dateLayout)
+		l.setField(fieldDone, "✅ "+date, date)
+	} else {
+		l.status = " "
+		l.setField(fieldDone, "", "")
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return a.IsZero() == b.IsZero()
+	}
+	return a.Local().Format(dateLayout) == b.Local().Format(dateLayout)
+}
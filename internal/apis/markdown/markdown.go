@@ -0,0 +1,540 @@
+// Package markdown syncs the checkbox list items of a directory of
+// Markdown notes, such as an Obsidian vault, in the Obsidian Tasks format:
+//
+//   - [ ] Renew passport #admin ⏫ 🔁 every year 📅 2026-10-20
+//
+// Lines are only rewritten when their task changes, the rest of the files
+// is left untouched.
+package markdown
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// Name of the provider, under which task IDs are stored.
+const Name = "markdown"
+
+// DefaultInbox is the note new tasks are appended to.
+const DefaultInbox = "Tasks.md"
+
+// ErrNotFound is returned when the line of a task is not found, because
+// its note was moved or the line edited outside goot.
+var ErrNotFound = errors.New("task not found in notes")
+
+var fenceRe = regexp.MustCompile("^\\s*(```|~~~)")
+
+// MarkdownAPI reads and writes tasks in the notes of a directory.
+//
+// A task ID is the path of its note relative to the directory followed by
+// "#" and the 🆔 field of the task, or a hash of its description if it has
+// none. Editing the description outside goot thus changes the ID of tasks
+// without a 🆔 field, goot adds one when it edits them.
+type MarkdownAPI struct {
+	dir   string
+	inbox string
+
+	mu sync.Mutex
+	// seen holds the tasks found by the last scan of the notes.
+	seen map[string]seenTask
+	// removed holds the tasks removed from the notes since the last call
+	// to GetAllTasksWithDeleted.
+	removed map[string]*tasks.Task
+}
+
+type seenTask struct {
+	text string
+	// modified is when the task was first seen with this text.
+	modified time.Time
+}
+
+// note is a parsed Markdown file.
+type note struct {
+	path            string
+	lines           []string
+	crlf            bool
+	trailingNewline bool
+	mode            fs.FileMode
+	mtime           time.Time
+	tasks           []*noteTask
+}
+
+// noteTask is a task of a note, on lines [start, end) with its notes.
+type noteTask struct {
+	id         string
+	start, end int
+	line       *taskLine
+}
+
+func (t *noteTask) text() string {
+	return strings.Join(t.line.lines(), "\n")
+}
+
+func NewMarkdownAPI(dir, inbox string) (*MarkdownAPI, error) {
+	if dir == "" {
+		return nil, errors.New("markdown notes directory is not set")
+	}
+	if rest, ok := strings.CutPrefix(dir, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dir = filepath.Join(home, rest)
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("invalid markdown notes directory: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("markdown notes directory '%s' is not a directory", dir)
+	}
+	if inbox == "" {
+		inbox = DefaultInbox
+	}
+	inbox = filepath.ToSlash(filepath.Clean(inbox))
+	if !filepath.IsLocal(inbox) {
+		return nil, fmt.Errorf("markdown inbox '%s' is not inside the notes directory", inbox)
+	}
+	return &MarkdownAPI{
+		dir:     dir,
+		inbox:   inbox,
+		seen:    make(map[string]seenTask),
+		removed: make(map[string]*tasks.Task),
+	}, nil
+}
+
+func (m *MarkdownAPI) Capabilities() apis.Capabilities {
+	return apis.Capabilities{Description: true, Priority: true, Labels: true, Recurrence: true, Tombstones: true}
+}
+
+// splitID returns the note path and key of a task ID.
+func splitID(id string) (string, string, error) {
+	i := strings.LastIndex(id, "#")
+	if i <= 0 || !filepath.IsLocal(filepath.FromSlash(id[:i])) {
+		return "", "", fmt.Errorf("invalid markdown task ID '%s'", id)
+	}
+	return id[:i], id[i+1:], nil
+}
+
+func (m *MarkdownAPI) readNote(rel string) (*note, error) {
+	p := filepath.Join(m.dir, filepath.FromSlash(rel))
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note: %w", err)
+	}
+	n := &note{path: rel, mode: info.Mode().Perm(), mtime: info.ModTime()}
+	text := string(data)
+	n.crlf = strings.Contains(text, "\r\n")
+	if n.crlf {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	if text != "" {
+		n.lines = strings.Split(text, "\n")
+		if n.lines[len(n.lines)-1] == "" {
+			n.lines = n.lines[:len(n.lines)-1]
+			n.trailingNewline = true
+		}
+	}
+	n.parse()
+	return n, nil
+}
+
+// parse finds the tasks of the note, outside code blocks, and their IDs.
+func (n *note) parse() {
+	n.tasks = nil
+	inFence := false
+	for i := 0; i < len(n.lines); i++ {
+		if fenceRe.MatchString(n.lines[i]) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		l, ok := parseTaskLine(n.lines[i])
+		if !ok {
+			continue
+		}
+		t := &noteTask{start: i, line: l}
+		indent := indentWidth(n.lines[i])
+		for i+1 < len(n.lines) {
+			next := n.lines[i+1]
+			if strings.TrimSpace(next) == "" || indentWidth(next) <= indent || listItemRe.MatchString(next) {
+				break
+			}
+			l.notes = append(l.notes, next)
+			i++
+		}
+		t.end = i + 1
+		n.tasks = append(n.tasks, t)
+	}
+
+	used := make(map[string]bool)
+	for _, t := range n.tasks {
+		if f := t.line.field(fieldID); f != nil {
+			t.id = uniqueKey(used, f.value)
+		}
+	}
+	for _, t := range n.tasks {
+		if t.id == "" {
+			sum := sha1.Sum([]byte(strings.TrimSpace(t.line.desc)))
+			t.id = uniqueKey(used, hex.EncodeToString(sum[:4]))
+		}
+	}
+	for _, t := range n.tasks {
+		t.id = n.path + "#" + t.id
+	}
+}
+
+func uniqueKey(used map[string]bool, key string) string {
+	k := key
+	for i := 2; used[k]; i++ {
+		k = fmt.Sprintf("%s-%d", key, i)
+	}
+	used[k] = true
+	return k
+}
+
+func indentWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		switch r {
+		case ' ':
+			w++
+		case '\t':
+			w += 4
+		default:
+			return w
+		}
+	}
+	return w
+}
+
+func (n *note) find(id string) (*noteTask, bool) {
+	for _, t := range n.tasks {
+		if t.id == id {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// replace replaces the lines of t with lines.
+func (n *note) replace(t *noteTask, lines []string) {
+	n.lines = append(n.lines[:t.start], append(lines, n.lines[t.end:]...)...)
+	n.parse()
+}
+
+// write replaces the note atomically, keeping its line endings and mode.
+func (m *MarkdownAPI) write(n *note) error {
+	sep := "\n"
+	if n.crlf {
+		sep = "\r\n"
+	}
+	text := strings.Join(n.lines, sep)
+	if n.trailingNewline && len(n.lines) > 0 {
+		text += sep
+	}
+
+	p := filepath.Join(m.dir, filepath.FromSlash(n.path))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create note directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write note: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write note: %w", err)
+	}
+	if err := f.Chmod(n.mode); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write note: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write note: %w", err)
+	}
+	if err := os.Rename(f.Name(), p); err != nil {
+		return fmt.Errorf("failed to write note: %w", err)
+	}
+	return nil
+}
+
+// notes returns the paths of the Markdown files of the directory, skipping
+// hidden ones such as .obsidian and .trash.
+func (m *MarkdownAPI) notes(ctx context.Context) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(m.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p != m.dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".md") {
+			rel, err := filepath.Rel(m.dir, p)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	return paths, nil
+}
+
+// task returns the task t of n, noting it as seen.
+func (m *MarkdownAPI) task(n *note, t *noteTask) *tasks.Task {
+	s, ok := m.seen[t.id]
+	if !ok || s.text != t.text() {
+		s = seenTask{text: t.text(), modified: n.mtime.UTC().Truncate(time.Second)}
+		m.seen[t.id] = s
+	}
+	task := t.line.Task()
+	task.LastModified = s.modified
+	task.SetAPIID(Name, t.id)
+	return task
+}
+
+func (m *MarkdownAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	paths, err := m.notes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tasksList tasks.Tasks
+	found := make(map[string]bool)
+	for _, p := range paths {
+		n, err := m.readNote(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, t := range n.tasks {
+			found[t.id] = true
+			tasksList = append(tasksList, *m.task(n, t))
+		}
+	}
+
+	for id := range m.seen {
+		if !found[id] {
+			task := &tasks.Task{Deleted: true, LastModified: time.Now().UTC()}
+			task.SetAPIID(Name, id)
+			m.removed[id] = task
+			delete(m.seen, id)
+		}
+	}
+	return tasksList, nil
+}
+
+func (m *MarkdownAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	tasksList, err := m.GetAllTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, task := range m.removed {
+		tasksList = append(tasksList, *task)
+		delete(m.removed, id)
+	}
+	return tasksList, nil
+}
+
+// load returns the note and task with the given ID.
+func (m *MarkdownAPI) load(id string) (*note, *noteTask, error) {
+	rel, _, err := splitID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	n, err := m.readNote(rel)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	t, ok := n.find(id)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	}
+	return n, t, nil
+}
+
+func (m *MarkdownAPI) GetTaskByID(_ context.Context, id string) (*tasks.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, t, err := m.load(id)
+	if err != nil {
+		return nil, err
+	}
+	return m.task(n, t), nil
+}
+
+// CreateTask appends task to the inbox note with a new 🆔 field.
+func (m *MarkdownAPI) CreateTask(_ context.Context, task *tasks.Task) (*tasks.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, err := m.readNote(m.inbox)
+	if errors.Is(err, fs.ErrNotExist) {
+		n = &note{path: m.inbox, mode: 0o644, trailingNewline: true}
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	l := &taskLine{prefix: "- [", status: " "}
+	l.update(task, time.Now())
+	l.setField(fieldID, "🆔 "+key, key)
+
+	n.lines = append(n.lines, l.lines()...)
+	n.trailingNewline = true
+	if err := m.write(n); err != nil {
+		return nil, err
+	}
+	n.parse()
+
+	id := n.path + "#" + key
+	t, ok := n.find(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	}
+	m.seen[id] = seenTask{text: t.text(), modified: modifiedAt(task)}
+	task.SetAPIID(Name, id)
+	return task, nil
+}
+
+func newKey() (string, error) {
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate task ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// modifiedAt returns when task was modified, for the tasks written by goot
+// not to look newer than their local copy.
+func modifiedAt(task *tasks.Task) time.Time {
+	if task.LastModified.IsZero() {
+		return time.Now().UTC().Truncate(time.Second)
+	}
+	return task.LastModified
+}
+
+// PatchTask rewrites the line of task if it changed, adding a 🆔 field
+// to keep its ID when its description changes.
+func (m *MarkdownAPI) PatchTask(_ context.Context, task *tasks.Task) (*tasks.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := task.APIID(Name)
+	n, t, err := m.load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	l := *t.line
+	l.fields = append([]field(nil), t.line.fields...)
+	if !l.update(task, time.Now()) {
+		return m.task(n, t), nil
+	}
+	if l.field(fieldID) == nil && l.desc != t.line.desc {
+		_, key, _ := splitID(id)
+		l.setField(fieldID, "🆔 "+key, key)
+	}
+
+	n.replace(t, l.lines())
+	if err := m.write(n); err != nil {
+		return nil, err
+	}
+	t, ok := n.find(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	}
+	m.seen[id] = seenTask{text: t.text(), modified: modifiedAt(task)}
+	return m.task(n, t), nil
+}
+
+func (m *MarkdownAPI) SetTaskCompleted(_ context.Context, id string, completed bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, t, err := m.load(id)
+	if err != nil {
+		return err
+	}
+	if t.line.completed() == completed {
+		return nil
+	}
+	t.line.setCompleted(completed, time.Now())
+	n.replace(t, t.line.lines())
+	if err := m.write(n); err != nil {
+		return err
+	}
+	if t, ok := n.find(id); ok {
+		m.seen[id] = seenTask{text: t.text(), modified: time.Now().UTC().Truncate(time.Second)}
+	}
+	return nil
+}
+
+// DeleteTaskByID removes the line of the task and its notes.
+func (m *MarkdownAPI) DeleteTaskByID(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n, t, err := m.load(id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	n.replace(t, nil)
+	if err := m.write(n); err != nil {
+		return err
+	}
+	delete(m.seen, id)
+	return nil
+}
+
+// isNote reports whether p is the path of a note that is not hidden.
+func isNote(p string) bool {
+	base := path.Base(filepath.ToSlash(p))
+	return !strings.HasPrefix(base, ".") && strings.EqualFold(path.Ext(base), ".md")
+}
@@ -0,0 +1,284 @@
+package markdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const vaultNote = `# Week
+
+Some text with a [link](https://example.com).
+
+- [ ] Renew passport #admin ⏫ 🔁 every year 📅 2026-10-20
+    Bring two photos
+- [x] Call plumber ✅ 2026-10-01
+- [ ] Water plants 🔁 every week on Monday, Thursday 🛫 2026-10-01 ^plants
+  - [ ] Buy fertilizer 🔽
+
+` + "```" + `
+- [ ] not a task in a code block
+` + "```" + `
+`
+
+func writeVault(t *testing.T) (string, *MarkdownAPI) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "Journal", ".obsidian"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Journal/Week.md":             vaultNote,
+		"Journal/.obsidian/hidden.md": "- [ ] hidden\n",
+		"readme.txt":                  "- [ ] not a note\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	api, err := NewMarkdownAPI(dir, "")
+	if err != nil {
+		t.Fatalf("NewMarkdownAPI: %v", err)
+	}
+	return dir, api
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseTaskLine(t *testing.T) {
+	line := "  - [ ] Renew #admin passport ⏫ 🔁 every 2 weeks when done ➕ 2026-09-01 📅 2026-10-20 🆔 abc123 ^blk"
+	l, ok := parseTaskLine(line)
+	if !ok {
+		t.Fatal("task line not parsed")
+	}
+	if l.String() != line {
+		t.Errorf("String() = %q, want the parsed line", l.String())
+	}
+	task := l.Task()
+	if task.Title != "Renew passport" || !slices.Equal(task.Tags, []string{"admin"}) {
+		t.Errorf("title = %q, tags = %v", task.Title, task.Tags)
+	}
+	if task.Priority != tasks.PriorityHigh || task.Recurrence != "FREQ=WEEKLY;INTERVAL=2" {
+		t.Errorf("priority = %v, recurrence = %q", task.Priority, task.Recurrence)
+	}
+	if want := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local); !task.Due.Equal(want) {
+		t.Errorf("due = %v, want %v", task.Due, want)
+	}
+	if f := l.field(fieldID); f == nil || f.value != "abc123" {
+		t.Errorf("id field = %+v", f)
+	}
+
+	for _, s := range []string{"- [] no status", "[ ] no bullet", "- plain item"} {
+		if _, ok := parseTaskLine(s); ok {
+			t.Errorf("parseTaskLine(%q) succeeded", s)
+		}
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	tests := []struct {
+		obsidian, rrule string
+	}{
+		{"every day", "FREQ=DAILY"},
+		{"every 3 months", "FREQ=MONTHLY;INTERVAL=3"},
+		{"every weekday", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{"every week on Monday, Thursday", "FREQ=WEEKLY;BYDAY=MO,TH"},
+		{"every year", "FREQ=YEARLY"},
+	}
+	for _, tt := range tests {
+		if got, ok := parseRecurrence(tt.obsidian); !ok || got != tt.rrule {
+			t.Errorf("parseRecurrence(%q) = %q, %v, want %q", tt.obsidian, got, ok, tt.rrule)
+		}
+		if got := formatRecurrence(tt.rrule); got != tt.obsidian {
+			t.Errorf("formatRecurrence(%q) = %q, want %q", tt.rrule, got, tt.obsidian)
+		}
+	}
+	if _, ok := parseRecurrence("every month on the last Friday"); ok {
+		t.Error("unsupported rule parsed")
+	}
+}
+
+func TestGetAllTasks(t *testing.T) {
+	_, api := writeVault(t)
+	ts, err := api.GetAllTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+
+	var titles []string
+	for _, task := range ts {
+		titles = append(titles, task.Title)
+		if !strings.HasPrefix(task.APIID(Name), "Journal/Week.md#") {
+			t.Errorf("ID = %q", task.APIID(Name))
+		}
+	}
+	if want := []string{"Renew passport", "Call plumber", "Water plants", "Buy fertilizer"}; !slices.Equal(titles, want) {
+		t.Fatalf("titles = %v, want %v", titles, want)
+	}
+	if ts[0].Description != "Bring two photos" {
+		t.Errorf("description = %q", ts[0].Description)
+	}
+	if !ts[1].Completed || ts[3].Priority != tasks.PriorityLow {
+		t.Errorf("completed = %v, priority = %v", ts[1].Completed, ts[3].Priority)
+	}
+
+	again, err := api.GetAllTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+	for i := range ts {
+		if again[i].APIID(Name) != ts[i].APIID(Name) {
+			t.Errorf("ID of %q changed between scans", ts[i].Title)
+		}
+	}
+}
+
+func TestWriteBack(t *testing.T) {
+	dir, api := writeVault(t)
+	ctx := context.Background()
+	path := filepath.Join(dir, "Journal", "Week.md")
+
+	ts, err := api.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks: %v", err)
+	}
+	passport, plants := ts[0], ts[2]
+
+	if err := api.SetTaskCompleted(ctx, passport.APIID(Name), true); err != nil {
+		t.Fatalf("SetTaskCompleted: %v", err)
+	}
+	done := "- [x] Renew passport #admin ⏫ 🔁 every year 📅 2026-10-20 ✅ " + time.Now().Format(dateLayout)
+	want := strings.Replace(vaultNote, "- [ ] Renew passport #admin ⏫ 🔁 every year 📅 2026-10-20", done, 1)
+	if got := readFile(t, path); got != want {
+		t.Fatalf("after completion:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Editing the title keeps the ID by adding it to the line, and leaves
+	// the fields goot does not know about in place.
+	plants.Title = "Water the plants"
+	plants.Due = time.Date(2026, 10, 22, 9, 0, 0, 0, time.Local)
+	if _, err := api.PatchTask(ctx, &plants); err != nil {
+		t.Fatalf("PatchTask: %v", err)
+	}
+	_, key, _ := splitID(plants.APIID(Name))
+	edited := "- [ ] Water the plants 🔁 every week on Monday, Thursday 🛫 2026-10-01 ^plants 📅 2026-10-22 🆔 " + key
+	want = strings.Replace(want, "- [ ] Water plants 🔁 every week on Monday, Thursday 🛫 2026-10-01 ^plants", edited, 1)
+	if got := readFile(t, path); got != want {
+		t.Fatalf("after patch:\n%s\nwant:\n%s", got, want)
+	}
+	got, err := api.GetTaskByID(ctx, plants.APIID(Name))
+	if err != nil || got.Title != "Water the plants" {
+		t.Fatalf("GetTaskByID after patch = %+v, %v", got, err)
+	}
+
+	// Patching a task unchanged does not touch the file.
+	info, _ := os.Stat(path)
+	if _, err := api.PatchTask(ctx, got); err != nil {
+		t.Fatalf("PatchTask: %v", err)
+	}
+	if after, _ := os.Stat(path); !after.ModTime().Equal(info.ModTime()) {
+		t.Error("unchanged task rewritten")
+	}
+
+	if err := api.DeleteTaskByID(ctx, passport.APIID(Name)); err != nil {
+		t.Fatalf("DeleteTaskByID: %v", err)
+	}
+	want = strings.Replace(want, done+"\n    Bring two photos\n", "", 1)
+	if got := readFile(t, path); got != want {
+		t.Fatalf("after delete:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCreateTaskAndTombstones(t *testing.T) {
+	dir, api := writeVault(t)
+	ctx := context.Background()
+
+	task := &tasks.Task{
+		Title:      "Book flights",
+		Tags:       []string{"travel"},
+		Priority:   tasks.PriorityMedium,
+		Recurrence: "FREQ=DAILY",
+		Due:        time.Date(2026, 11, 2, 0, 0, 0, 0, time.Local),
+	}
+	if _, err := api.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	_, key, err := splitID(task.APIID(Name))
+	if err != nil {
+		t.Fatalf("created ID: %v", err)
+	}
+	want := "- [ ] Book flights #travel 🔼 🔁 every day 📅 2026-11-02 🆔 " + key + "\n"
+	if got := readFile(t, filepath.Join(dir, DefaultInbox)); got != want {
+		t.Fatalf("inbox = %q, want %q", got, want)
+	}
+
+	if _, err := api.GetAllTasksWithDeleted(ctx); err != nil {
+		t.Fatalf("GetAllTasksWithDeleted: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, DefaultInbox)); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted: %v", err)
+	}
+	i := slices.IndexFunc(ts, func(t tasks.Task) bool { return t.APIID(Name) == task.APIID(Name) })
+	if i < 0 || !ts[i].Deleted {
+		t.Fatalf("no tombstone for the removed task in %+v", ts)
+	}
+	ts, _ = api.GetAllTasksWithDeleted(ctx)
+	if slices.ContainsFunc(ts, func(t tasks.Task) bool { return t.Deleted }) {
+		t.Error("tombstone returned twice")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, api := writeVault(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changed := make(chan struct{}, 1)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- api.Watch(ctx, func() {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		})
+	}()
+	// Let the watcher add the directories.
+	time.Sleep(100 * time.Millisecond)
+
+	if err := os.MkdirAll(filepath.Join(dir, "Projects"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "Projects", "New.md"), []byte("- [ ] new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change reported")
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("Watch: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package markdown
+
+import (
+	"github.com/zeerodex/goot/internal/apis"
+)
+
+// Settings are read from the "markdown" config section.
+type Settings struct {
+	// Dir is the directory of the notes, such as an Obsidian vault.
+	Dir string `mapstructure:"dir"`
+	// Inbox is the note, relative to Dir, new tasks are appended to.
+	Inbox string `mapstructure:"inbox"`
+}
+
+func init() {
+	apis.Register(apis.Provider{
+		Name:        Name,
+		NewSettings: func() any { return &Settings{Inbox: DefaultInbox} },
+		New: func(settings any, _ apis.Options) (apis.API, error) {
+			s := settings.(*Settings)
+			return NewMarkdownAPI(s.Dir, s.Inbox)
+		},
+	})
+}
@@ -0,0 +1,132 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	everyRe = regexp.MustCompile(`^every (?:(\d+) )?(day|week|month|year)s?(?: on ([a-z, ]+))?$`)
+
+	weekdays = []struct{ name, rrule string }{
+		{"monday", "MO"}, {"tuesday", "TU"}, {"wednesday", "WE"}, {"thursday", "TH"},
+		{"friday", "FR"}, {"saturday", "SA"}, {"sunday", "SU"},
+	}
+	frequencies = map[string]string{
+		"day": "DAILY", "week": "WEEKLY", "month": "MONTHLY", "year": "YEARLY",
+	}
+)
+
+const workweek = "MO,TU,WE,TH,FR"
+
+// parseRecurrence converts the simple Obsidian Tasks recurrence rules, such
+// as "every 2 weeks" or "every week on Monday, Friday", to an RRULE value.
+// It reports false for the rules it does not understand.
+func parseRecurrence(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimSuffix(s, " when done")
+
+	switch {
+	case s == "every weekday":
+		return "FREQ=WEEKLY;BYDAY=" + workweek, true
+	case strings.HasPrefix(s, "every ") && !everyRe.MatchString(s):
+		days, ok := parseWeekdays(strings.TrimPrefix(s, "every "))
+		if !ok {
+			return "", false
+		}
+		return "FREQ=WEEKLY;BYDAY=" + days, true
+	}
+
+	m := everyRe.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	rrule := "FREQ=" + frequencies[m[2]]
+	if m[1] != "" && m[1] != "1" {
+		rrule += ";INTERVAL=" + m[1]
+	}
+	if m[3] != "" {
+		if m[2] != "week" {
+			return "", false
+		}
+		days, ok := parseWeekdays(m[3])
+		if !ok {
+			return "", false
+		}
+		rrule += ";BYDAY=" + days
+	}
+	return rrule, true
+}
+
+func parseWeekdays(s string) (string, bool) {
+	var days []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, d := range weekdays {
+			if d.name == name {
+				days = append(days, d.rrule)
+				found = true
+			}
+		}
+		if !found {
+			return "", false
+		}
+	}
+	return strings.Join(days, ","), true
+}
+
+// formatRecurrence converts an RRULE value to an Obsidian Tasks rule. The
+// parts other than FREQ, INTERVAL and weekly BYDAY are dropped. It returns
+// an empty string if rrule has no known frequency.
+func formatRecurrence(rrule string) string {
+	var freq, byday string
+	interval := 1
+	for _, part := range strings.Split(rrule, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			freq = strings.ToUpper(v)
+		case "INTERVAL":
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				interval = n
+			}
+		case "BYDAY":
+			byday = strings.ToUpper(v)
+		}
+	}
+
+	var unit string
+	for u, f := range frequencies {
+		if f == freq {
+			unit = u
+		}
+	}
+	if unit == "" {
+		return ""
+	}
+	if unit == "week" && interval == 1 && byday == workweek {
+		return "every weekday"
+	}
+
+	s := "every " + unit
+	if interval > 1 {
+		s = fmt.Sprintf("every %d %ss", interval, unit)
+	}
+	if unit == "week" && byday != "" {
+		var names []string
+		for _, day := range strings.Split(byday, ",") {
+			for _, d := range weekdays {
+				if d.rrule == day {
+					names = append(names, strings.ToUpper(d.name[:1])+d.name[1:])
+				}
+			}
+		}
+		if len(names) > 0 {
+			s += " on " + strings.Join(names, ", ")
+		}
+	}
+	return s
+}
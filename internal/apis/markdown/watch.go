@@ -0,0 +1,77 @@
+package markdown
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce groups the events of an editor saving a note, or of a
+// vault being synced, into a single change.
+const watchDebounce = 500 * time.Millisecond
+
+// Watch calls changed once notes stop changing for watchDebounce, until
+// ctx is done. Directories created while watching are watched too.
+func (m *MarkdownAPI) Watch(ctx context.Context, changed func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer w.Close()
+
+	if err := addDirs(w, m.dir); err != nil {
+		return err
+	}
+
+	timer := time.AfterFunc(watchDebounce, changed)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				// Errors are ignored, the path may be a file or already gone.
+				addDirs(w, ev.Name)
+			}
+			// A removed or renamed directory may hold notes.
+			if isNote(ev.Name) || ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("failed to watch notes: %w", err)
+		}
+	}
+}
+
+// addDirs watches dir and its subdirectories, skipping hidden ones.
+func addDirs(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := w.Add(p); err != nil {
+			return fmt.Errorf("failed to watch '%s': %w", p, err)
+		}
+		return nil
+	})
+}
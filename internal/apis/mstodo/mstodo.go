@@ -0,0 +1,535 @@
+package mstodo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+	"github.com/zeerodex/goot/internal/ratelimit"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const (
+	apiURL   = "https://graph.microsoft.com/v1.0"
+	authURL  = "https://login.microsoftonline.com/common/oauth2/v2.0/authorize"
+	tokenURL = "https://login.microsoftonline.com/common/oauth2/v2.0/token"
+	tokFile  = "mstodo_token.json"
+
+	// Name of the provider, under which task IDs are stored.
+	Name = "mstodo"
+
+	// DefaultStateFile is where the cache of the list is kept between runs,
+	// relative to the working directory like the database.
+	DefaultStateFile = "mstodo_state.json"
+
+	// dateTimeLayout of Graph dateTimeTimeZone values.
+	dateTimeLayout = "2006-01-02T15:04:05"
+)
+
+var Scopes = []string{"Tasks.ReadWrite", "offline_access"}
+
+// DefaultRateLimit follows the Outlook service limit of 10000 requests per
+// 10 minutes per mailbox.
+var DefaultRateLimit = config.RateLimit{Rate: 10000.0 / (10 * 60), Burst: 20}
+
+// Graph importances, mapped to tasks.Priority by position. Graph has no
+// "none" nor medium importance, both are sent as normal, which reads back
+// as no priority.
+var importances = []string{"normal", "low", "normal", "high"}
+
+// MSTodoAPI syncs a Microsoft To Do list through the Graph API. The list
+// is cached and kept up to date with delta queries. The cache is persisted
+// to stateFile, if set, so that tasks removed while goot was not running
+// are still reported.
+type MSTodoAPI struct {
+	client *http.Client
+	// baseURL overrides apiURL in tests.
+	baseURL   string
+	stateFile string
+
+	// list is the configured list name or ID, resolved to listID on first
+	// use.
+	list   string
+	listMu sync.Mutex
+	listID string
+
+	// syncMu serializes delta queries.
+	syncMu sync.Mutex
+	// saveMu serializes writes of the state file.
+	saveMu sync.Mutex
+
+	mu        sync.Mutex
+	deltaLink string
+	cache     map[string]*Task
+	// removed holds the tasks removed from the list since the last call to
+	// GetAllTasksWithDeleted.
+	removed map[string]*Task
+}
+
+// state is the cache as stored in the state file.
+type state struct {
+	// List is the configured list the state belongs to, the state of
+	// another list is ignored.
+	List      string           `json:"list"`
+	DeltaLink string           `json:"delta_link"`
+	Tasks     map[string]*Task `json:"tasks"`
+	Removed   map[string]*Task `json:"removed,omitempty"`
+}
+
+func NewMSTodoAPI(s *Settings, limiter *ratelimit.Limiter, logger *slog.Logger) (*MSTodoAPI, error) {
+	clientID, clientSecret := os.Getenv("MSTODO_CLIENT_ID"), os.Getenv("MSTODO_CLIENT_SECRET")
+	client, err := apis.NewOAuthHandler(
+		clientID,
+		clientSecret,
+		authURL,
+		tokenURL,
+		tokFile,
+		Scopes).WithPKCE().GetClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init oauth handler: %w", err)
+	}
+
+	logger = logger.With("api", Name)
+	client = metrics.WrapClient(logging.WrapClient(client, logger), Name)
+	return newMSTodoAPI(ratelimit.WrapClient(client, limiter, Name, logger), s.List, s.StateFile)
+}
+
+func newMSTodoAPI(client *http.Client, list, stateFile string) (*MSTodoAPI, error) {
+	c := &MSTodoAPI{
+		client:    client,
+		list:      list,
+		stateFile: stateFile,
+		cache:     make(map[string]*Task),
+		removed:   make(map[string]*Task),
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load restores the cache from the state file.
+func (c *MSTodoAPI) load() error {
+	if c.stateFile == "" {
+		return nil
+	}
+	var s state
+	if err := apis.LoadState(c.stateFile, &s); err != nil {
+		return err
+	}
+	if s.List != c.list || s.DeltaLink == "" {
+		return nil
+	}
+	c.deltaLink = s.DeltaLink
+	if s.Tasks != nil {
+		c.cache = s.Tasks
+	}
+	if s.Removed != nil {
+		c.removed = s.Removed
+	}
+	return nil
+}
+
+// save writes the cache to the state file.
+func (c *MSTodoAPI) save() error {
+	if c.stateFile == "" {
+		return nil
+	}
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	// Cached tasks are replaced rather than modified, copying the maps is
+	// enough.
+	c.mu.Lock()
+	s := state{List: c.list, DeltaLink: c.deltaLink, Tasks: maps.Clone(c.cache), Removed: maps.Clone(c.removed)}
+	c.mu.Unlock()
+	return apis.SaveState(c.stateFile, s)
+}
+
+func (c *MSTodoAPI) url(endpoint string) string {
+	if c.baseURL != "" {
+		return c.baseURL + endpoint
+	}
+	return apiURL + endpoint
+}
+
+type List struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	WellknownListName string `json:"wellknownListName"`
+}
+
+type itemBody struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+}
+
+type dateTimeTimeZone struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type Task struct {
+	ID                   string            `json:"id,omitempty"`
+	Title                string            `json:"title"`
+	Body                 *itemBody         `json:"body,omitempty"`
+	DueDateTime          *dateTimeTimeZone `json:"dueDateTime"`
+	Status               string            `json:"status,omitempty"`
+	Importance           string            `json:"importance,omitempty"`
+	Categories           []string          `json:"categories"`
+	LastModifiedDateTime string            `json:"lastModifiedDateTime,omitempty"`
+	// Removed is set on the tasks deleted since the previous delta query.
+	Removed *struct {
+		Reason string `json:"reason"`
+	} `json:"@removed,omitempty"`
+}
+
+func (mt *Task) Task() *tasks.Task {
+	var t tasks.Task
+	t.SetAPIID(Name, mt.ID)
+	t.Title = mt.Title
+	if mt.Body != nil {
+		t.Description = mt.Body.Content
+	}
+	// To Do only keeps the date of due dates.
+	if mt.DueDateTime != nil {
+		date, _, _ := strings.Cut(mt.DueDateTime.DateTime, "T")
+		t.Due, _ = time.ParseInLocation("2006-01-02", date, time.Local)
+	}
+	for i, imp := range importances {
+		if mt.Importance == imp {
+			t.Priority = tasks.Priority(i)
+			break
+		}
+	}
+	t.Tags = mt.Categories
+	t.Completed = mt.Status == "completed"
+	t.LastModified, _ = time.Parse(time.RFC3339, mt.LastModifiedDateTime)
+	return &t
+}
+
+func MSTodoTask(t *tasks.Task) *Task {
+	mt := &Task{
+		ID:         t.APIID(Name),
+		Title:      t.Title,
+		Body:       &itemBody{Content: t.Description, ContentType: "text"},
+		Status:     "notStarted",
+		Importance: "normal",
+		// Empty rather than nil so that updates clear them.
+		Categories: []string{},
+	}
+	if t.Tags != nil {
+		mt.Categories = t.Tags
+	}
+	if !t.Due.IsZero() {
+		due := time.Date(t.Due.Year(), t.Due.Month(), t.Due.Day(), 0, 0, 0, 0, time.UTC)
+		mt.DueDateTime = &dateTimeTimeZone{DateTime: due.Format(dateTimeLayout), TimeZone: "UTC"}
+	}
+	if int(t.Priority) >= 0 && int(t.Priority) < len(importances) {
+		mt.Importance = importances[t.Priority]
+	}
+	if t.Completed {
+		mt.Status = "completed"
+	}
+	return mt
+}
+
+// makeRequest sends a request to endpoint, or to the absolute URL
+// endpoint as found in Graph next and delta links.
+func (c *MSTodoAPI) makeRequest(ctx context.Context, method string, endpoint string, data any) (*http.Response, error) {
+	var reqBody io.Reader
+	if data != nil {
+		jsonBody, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+	u := endpoint
+	if !strings.HasPrefix(endpoint, "https://") && !strings.HasPrefix(endpoint, "http://") {
+		u = c.url(endpoint)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.client.Do(req)
+}
+
+// do sends a request and decodes the response body into v, unless v is nil.
+func (c *MSTodoAPI) do(ctx context.Context, method string, endpoint string, data any, v any) error {
+	resp, err := c.makeRequest(ctx, method, endpoint, data)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		if err := apis.HandleResponseStatusCode(resp.StatusCode); err != nil {
+			return err
+		}
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding json: %w", err)
+	}
+	return nil
+}
+
+type page[T any] struct {
+	Value     []T    `json:"value"`
+	NextLink  string `json:"@odata.nextLink"`
+	DeltaLink string `json:"@odata.deltaLink"`
+}
+
+// GetAllLists returns the To Do lists of the user.
+func (c *MSTodoAPI) GetAllLists(ctx context.Context) ([]List, error) {
+	var lists []List
+	for next := "/me/todo/lists"; next != ""; {
+		var p page[List]
+		if err := c.do(ctx, "GET", next, nil, &p); err != nil {
+			return nil, fmt.Errorf("failed to retrieve lists: %w", err)
+		}
+		lists = append(lists, p.Value...)
+		next = p.NextLink
+	}
+	return lists, nil
+}
+
+// listOf returns the ID of the configured list, looking it up by name or
+// ID the first time. The default list is used if none is configured.
+func (c *MSTodoAPI) listOf(ctx context.Context) (string, error) {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+	if c.listID != "" {
+		return c.listID, nil
+	}
+
+	lists, err := c.GetAllLists(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, l := range lists {
+		if c.list == "" && l.WellknownListName == "defaultList" ||
+			c.list != "" && (l.ID == c.list || strings.EqualFold(l.DisplayName, c.list)) {
+			c.listID = l.ID
+			return c.listID, nil
+		}
+	}
+	if c.list == "" {
+		return "", fmt.Errorf("default list not found")
+	}
+	return "", fmt.Errorf("list '%s' not found", c.list)
+}
+
+func (c *MSTodoAPI) tasksEndpoint(ctx context.Context) (string, error) {
+	listID, err := c.listOf(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/me/todo/lists/%s/tasks", listID), nil
+}
+
+// refresh brings the cache up to date with a delta query, starting over
+// if the previous delta link expired.
+func (c *MSTodoAPI) refresh(ctx context.Context) error {
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+
+	endpoint, err := c.tasksEndpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	next := c.deltaLink
+	c.mu.Unlock()
+	full := next == ""
+	if full {
+		next = endpoint + "/delta"
+	}
+
+	var changes []Task
+	var deltaLink string
+	for next != "" {
+		var p page[Task]
+		err := c.do(ctx, "GET", next, nil, &p)
+		if err != nil && !full {
+			// The delta link expired, list the whole list again.
+			full, changes, next = true, nil, endpoint+"/delta"
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query task changes: %w", err)
+		}
+		changes = append(changes, p.Value...)
+		next, deltaLink = p.NextLink, p.DeltaLink
+	}
+
+	c.mu.Lock()
+	seen := make(map[string]bool)
+	for _, mt := range changes {
+		if mt.Removed != nil {
+			c.remove(mt.ID)
+			continue
+		}
+		seen[mt.ID] = true
+		c.cache[mt.ID] = &mt
+	}
+	if full {
+		// A full listing does not report removals.
+		for id := range c.cache {
+			if !seen[id] {
+				c.remove(id)
+			}
+		}
+	}
+	c.deltaLink = deltaLink
+	c.mu.Unlock()
+	return c.save()
+}
+
+// remove drops id from the cache, keeping it as a tombstone. c.mu must be
+// held.
+func (c *MSTodoAPI) remove(id string) {
+	if mt, ok := c.cache[id]; ok {
+		c.removed[id] = mt
+		delete(c.cache, id)
+	}
+}
+
+// Capabilities of Microsoft To Do, which keeps the date of due dates only.
+// Its three importances cannot hold every priority, which is kept locally.
+// Recurrence patterns are not mapped yet. Removals are known for the tasks
+// seen since the state file was created.
+func (c *MSTodoAPI) Capabilities() apis.Capabilities {
+	return apis.Capabilities{Description: true, Labels: true, Subtasks: true, Tombstones: true}
+}
+
+func (c *MSTodoAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tasksList := make(tasks.Tasks, 0, len(c.cache))
+	for _, mt := range c.cache {
+		tasksList = append(tasksList, *mt.Task())
+	}
+	return tasksList, nil
+}
+
+func (c *MSTodoAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	tasksList, err := c.GetAllTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	for id, mt := range c.removed {
+		t := mt.Task()
+		t.Deleted = true
+		tasksList = append(tasksList, *t)
+		delete(c.removed, id)
+	}
+	c.mu.Unlock()
+
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return tasksList, nil
+}
+
+func (c *MSTodoAPI) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	endpoint, err := c.tasksEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mt Task
+	if err := c.do(ctx, "GET", endpoint+"/"+id, nil, &mt); err != nil {
+		return nil, fmt.Errorf("failed to retrieve task '%s': %w", id, err)
+	}
+	return mt.Task(), nil
+}
+
+func (c *MSTodoAPI) CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	endpoint, err := c.tasksEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mt Task
+	if err := c.do(ctx, "POST", endpoint, MSTodoTask(task), &mt); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	task.SetAPIID(Name, mt.ID)
+	return mt.Task(), nil
+}
+
+func (c *MSTodoAPI) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	endpoint, err := c.tasksEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := task.APIID(Name)
+	mt := MSTodoTask(task)
+	mt.ID = ""
+	var updated Task
+	if err := c.do(ctx, "PATCH", endpoint+"/"+id, mt, &updated); err != nil {
+		return nil, fmt.Errorf("failed to update task '%s': %w", id, err)
+	}
+	return updated.Task(), nil
+}
+
+func (c *MSTodoAPI) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
+	endpoint, err := c.tasksEndpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := "notStarted"
+	if completed {
+		status = "completed"
+	}
+	if err := c.do(ctx, "PATCH", endpoint+"/"+id, map[string]string{"status": status}, nil); err != nil {
+		return fmt.Errorf("failed to set status of task '%s': %w", id, err)
+	}
+	return nil
+}
+
+func (c *MSTodoAPI) DeleteTaskByID(ctx context.Context, id string) error {
+	endpoint, err := c.tasksEndpoint(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(ctx, "DELETE", endpoint+"/"+id, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete task '%s': %w", id, err)
+	}
+
+	c.mu.Lock()
+	delete(c.cache, id)
+	c.mu.Unlock()
+	return nil
+}
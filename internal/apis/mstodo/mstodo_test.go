@@ -0,0 +1,179 @@
+package mstodo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// interaction is a recorded Graph request and its response. Request, if
+// set, lists fields the request body must contain.
+type interaction struct {
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Request  map[string]any  `json:"request"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// newReplayServer serves the interactions recorded in file, in order, with
+// links to Graph rewritten to the server itself.
+func newReplayServer(t *testing.T, file string) *httptest.Server {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixtures []interaction
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("invalid fixtures %s: %v", file, err)
+	}
+
+	var mu sync.Mutex
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(fixtures) == 0 {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.Error(w, "no more fixtures", http.StatusInternalServerError)
+			return
+		}
+		f := fixtures[0]
+		fixtures = fixtures[1:]
+
+		if got := r.URL.RequestURI(); r.Method != f.Method || got != f.URL && got != strings.ReplaceAll(f.URL, "$", "%24") {
+			t.Errorf("request = %s %s, want %s %s", r.Method, got, f.Method, f.URL)
+		}
+		if f.Request != nil {
+			var body map[string]any
+			b, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(b, &body); err != nil {
+				t.Errorf("%s %s: invalid body %s", r.Method, r.URL, b)
+			}
+			for k, want := range f.Request {
+				if body[k] != want {
+					t.Errorf("%s %s: body %s = %v, want %v", r.Method, r.URL, k, body[k], want)
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.Status)
+		w.Write([]byte(strings.ReplaceAll(string(f.Response), apiURL, srv.URL)))
+	}))
+	t.Cleanup(func() {
+		srv.Close()
+		if len(fixtures) > 0 {
+			t.Errorf("%d recorded requests were not replayed, next %s %s", len(fixtures), fixtures[0].Method, fixtures[0].URL)
+		}
+	})
+	return srv
+}
+
+func TestReplay(t *testing.T) {
+	srv := newReplayServer(t, "testdata/graph.json")
+	api, err := newMSTodoAPI(srv.Client(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.baseURL = srv.URL
+	ctx := context.Background()
+
+	all, err := api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted() error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(all))
+	}
+	rent := find(all, "AAMkTask1")
+	if rent == nil || rent.Title != "Pay rent" || rent.Description != "before the 5th" || rent.Priority != tasks.PriorityHigh ||
+		!slices.Equal(rent.Tags, []string{"home"}) || !rent.Due.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("AAMkTask1 = %+v", rent)
+	}
+	if mom := find(all, "AAMkTask2"); mom == nil || !mom.Completed || mom.Priority != tasks.PriorityNone {
+		t.Errorf("AAMkTask2 = %+v", mom)
+	}
+
+	task := &tasks.Task{Title: "Book flights", Priority: tasks.PriorityLow}
+	if _, err := api.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if id := task.APIID(Name); id != "AAMkTask3" {
+		t.Errorf("created task ID = %q, want AAMkTask3", id)
+	}
+	if err := api.SetTaskCompleted(ctx, "AAMkTask3", true); err != nil {
+		t.Fatalf("SetTaskCompleted() error = %v", err)
+	}
+
+	// The delta query only returns changes, including the removal.
+	all, err = api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted() error = %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d tasks, want 3", len(all))
+	}
+	if rent := find(all, "AAMkTask1"); rent.Title != "Pay rent and bills" {
+		t.Errorf("AAMkTask1 title = %q, want the updated one", rent.Title)
+	}
+	if mom := find(all, "AAMkTask2"); mom == nil || !mom.Deleted {
+		t.Errorf("AAMkTask2 = %+v, want a tombstone", mom)
+	}
+
+	if err := api.DeleteTaskByID(ctx, "AAMkTask3"); err != nil {
+		t.Fatalf("DeleteTaskByID() error = %v", err)
+	}
+
+	// An expired delta link starts over with a full listing.
+	all, err = api.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if len(all) != 1 || all[0].APIID(Name) != "AAMkTask1" {
+		t.Errorf("got %+v after resync, want AAMkTask1 only", all)
+	}
+}
+
+// TestStateFile checks that a task removed while goot was not running is
+// reported as deleted from the persisted delta link and cache.
+func TestStateFile(t *testing.T) {
+	srv := newReplayServer(t, "testdata/restart.json")
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	ctx := context.Background()
+
+	for _, want := range []bool{false, true} {
+		api, err := newMSTodoAPI(srv.Client(), "", stateFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		api.baseURL = srv.URL
+		all, err := api.GetAllTasksWithDeleted(ctx)
+		if err != nil {
+			t.Fatalf("GetAllTasksWithDeleted() error = %v", err)
+		}
+		if len(all) != 1 || all[0].APIID(Name) != "AAMkTask1" || all[0].Deleted != want {
+			t.Errorf("got %+v, want AAMkTask1 with deleted %v", all, want)
+		}
+	}
+}
+
+func find(ts tasks.Tasks, id string) *tasks.Task {
+	for i := range ts {
+		if ts[i].APIID(Name) == id {
+			return &ts[i]
+		}
+	}
+	return nil
+}
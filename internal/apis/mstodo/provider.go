@@ -0,0 +1,24 @@
+package mstodo
+
+import (
+	"github.com/zeerodex/goot/internal/apis"
+)
+
+// Settings are read from the "mstodo" config section.
+type Settings struct {
+	// List is the name or ID of the synced list, the default list if empty.
+	List string `mapstructure:"list"`
+	// StateFile keeps the delta link and cache of the list between runs.
+	StateFile string `mapstructure:"state-file"`
+}
+
+func init() {
+	apis.Register(apis.Provider{
+		Name:        Name,
+		NewSettings: func() any { return &Settings{StateFile: DefaultStateFile} },
+		New: func(settings any, opts apis.Options) (apis.API, error) {
+			return NewMSTodoAPI(settings.(*Settings), opts.Limiter, opts.Logger)
+		},
+		RateLimit: DefaultRateLimit,
+	})
+}
@@ -26,6 +26,8 @@ type OAuthHandler struct {
 	errChan        chan error
 	callbackServer *http.Server
 	mu             sync.Mutex
+	// verifier is the PKCE code verifier, empty if PKCE is not used.
+	verifier string
 }
 
 func NewOAuthHandler(clientID, clientSecret, authURL, tokenURL, tokFile string, scopes []string) *OAuthHandler {
@@ -47,6 +49,13 @@ func NewOAuthHandler(clientID, clientSecret, authURL, tokenURL, tokFile string,
 	}
 }
 
+// WithPKCE makes the handler use Proof Key for Code Exchange, required by
+// public clients that have no client secret.
+func (h *OAuthHandler) WithPKCE() *OAuthHandler {
+	h.verifier = oauth2.GenerateVerifier()
+	return h
+}
+
 func generateRandomState() string {
 	b := make([]byte, 32)
 	rand.Read(b)
@@ -88,7 +97,11 @@ func (h *OAuthHandler) Login() error {
 }
 
 func (h *OAuthHandler) getTokenFromWeb(config *oauth2.Config) (*oauth2.Token, error) {
-	authURL := config.AuthCodeURL(h.state, oauth2.AccessTypeOffline)
+	opts := []oauth2.AuthCodeOption{oauth2.AccessTypeOffline}
+	if h.verifier != "" {
+		opts = append(opts, oauth2.S256ChallengeOption(h.verifier))
+	}
+	authURL := config.AuthCodeURL(h.state, opts...)
 	fmt.Printf("Go to the following link in your browser to log in:\n%s\n", authURL)
 
 	mux := http.NewServeMux()
@@ -137,7 +150,11 @@ func (h *OAuthHandler) oauthCallbackHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	tok, err := h.config.Exchange(context.Background(), code)
+	var opts []oauth2.AuthCodeOption
+	if h.verifier != "" {
+		opts = append(opts, oauth2.VerifierOption(h.verifier))
+	}
+	tok, err := h.config.Exchange(context.Background(), code, opts...)
 	if err != nil {
 		err = fmt.Errorf("unable to retrieve token from web: %w", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,14 @@
+// Package providers registers the built-in API providers. Importing it
+// makes them available to services.NewTaskService.
+package providers
+
+import (
+	_ "github.com/zeerodex/goot/internal/apis/caldav"
+	_ "github.com/zeerodex/goot/internal/apis/gtasksapi"
+	_ "github.com/zeerodex/goot/internal/apis/issues"
+	_ "github.com/zeerodex/goot/internal/apis/markdown"
+	_ "github.com/zeerodex/goot/internal/apis/mstodo"
+	_ "github.com/zeerodex/goot/internal/apis/ticktick"
+	_ "github.com/zeerodex/goot/internal/apis/todoist"
+	_ "github.com/zeerodex/goot/internal/apis/todotxtapi"
+)
@@ -0,0 +1,104 @@
+package apis
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/ratelimit"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// Provider describes a task API that can be enabled in the config. Provider
+// packages register themselves with Register from an init function.
+type Provider struct {
+	// Name identifies the API in jobs, logs, metrics and the database.
+	Name string
+	// ConfigKey is the key enabling the provider in the "apis" config map,
+	// and of its settings, timeout and rate limit. Name if empty.
+	ConfigKey string
+
+	// NewSettings returns the provider settings filled with their defaults,
+	// into which its config section is decoded. Nil if it has none.
+	NewSettings func() any
+	// New creates the API from the decoded settings.
+	New func(settings any, opts Options) (API, error)
+
+	// RateLimit is the default rate limit, following the provider quotas.
+	RateLimit config.RateLimit
+
+	// ID returns the ID of a task in the provider and SetID sets it. They
+	// default to Task.APIID and Task.SetAPIID.
+	ID    func(*tasks.Task) string
+	SetID func(*tasks.Task, string)
+}
+
+// Options are passed by the service to every provider it creates.
+type Options struct {
+	Limiter *ratelimit.Limiter
+	Logger  *slog.Logger
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Provider)
+)
+
+// Register makes a provider available under p.Name. It panics if the name
+// is already taken.
+func Register(p Provider) {
+	if p.Name == "" || p.New == nil {
+		panic("apis: Register of provider without name or constructor")
+	}
+	if p.ConfigKey == "" {
+		p.ConfigKey = p.Name
+	}
+	if p.ID == nil {
+		p.ID = func(t *tasks.Task) string { return t.APIID(p.Name) }
+	}
+	if p.SetID == nil {
+		p.SetID = func(t *tasks.Task, id string) { t.SetAPIID(p.Name, id) }
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[p.Name]; dup {
+		panic(fmt.Sprintf("apis: Register called twice for provider %s", p.Name))
+	}
+	registry[p.Name] = p
+}
+
+// Lookup returns the provider registered under name.
+func Lookup(name string) (Provider, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[name]
+	return p, ok
+}
+
+// IDs returns the IDs of t in every registered provider it has one in,
+// keyed by provider name.
+func IDs(t *tasks.Task) map[string]string {
+	ids := make(map[string]string)
+	for _, p := range Providers() {
+		if id := p.ID(t); id != "" {
+			ids[p.Name] = id
+		}
+	}
+	return ids
+}
+
+// Providers returns the registered providers sorted by name.
+func Providers() []Provider {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	ps := make([]Provider, 0, len(registry))
+	for _, p := range registry {
+		ps = append(ps, p)
+	}
+	slices.SortFunc(ps, func(a, b Provider) int { return strings.Compare(a.Name, b.Name) })
+	return ps
+}
@@ -0,0 +1,22 @@
+package ticktick
+
+import (
+	"github.com/zeerodex/goot/internal/apis"
+)
+
+// Settings are read from the "ticktick" config section.
+type Settings struct {
+	// Project is the name or ID of the synced project, the inbox if empty.
+	Project string `mapstructure:"project"`
+}
+
+func init() {
+	apis.Register(apis.Provider{
+		Name:        Name,
+		NewSettings: func() any { return &Settings{Project: InboxProject} },
+		New: func(settings any, opts apis.Options) (apis.API, error) {
+			return NewTickTickAPI(settings.(*Settings).Project, opts.Limiter, opts.Logger)
+		},
+		RateLimit: DefaultRateLimit,
+	})
+}
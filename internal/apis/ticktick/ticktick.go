@@ -0,0 +1,366 @@
+package ticktick
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+	"github.com/zeerodex/goot/internal/ratelimit"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+const (
+	apiURL   = "https://api.ticktick.com/open/v1"
+	authURL  = "https://ticktick.com/oauth/authorize"
+	tokenURL = "https://ticktick.com/oauth/token"
+	tokFile  = "ticktick_token.json"
+
+	// Name of the provider, under which task IDs are stored.
+	Name = "ticktick"
+	// InboxProject is the project used when none is configured.
+	InboxProject = "inbox"
+
+	dateLayout = "2006-01-02T15:04:05-0700"
+)
+
+var Scopes = []string{"tasks:read", "tasks:write"}
+
+// DefaultRateLimit is conservative, TickTick does not publish its quotas.
+var DefaultRateLimit = config.RateLimit{Rate: 2, Burst: 10}
+
+// TickTick priorities, mapped to tasks.Priority by position.
+var priorities = []int{0, 1, 3, 5}
+
+const (
+	statusNormal    = 0
+	statusCompleted = 2
+)
+
+type TickTickAPI struct {
+	client *http.Client
+	// baseURL overrides apiURL in tests.
+	baseURL string
+
+	// project is the configured project name or ID, resolved to projectID
+	// on first use.
+	project   string
+	mu        sync.Mutex
+	projectID string
+}
+
+func NewTickTickAPI(project string, limiter *ratelimit.Limiter, logger *slog.Logger) (*TickTickAPI, error) {
+	clientID, clientSecret := os.Getenv("TICKTICK_CLIENT_ID"), os.Getenv("TICKTICK_CLIENT_SECRET")
+	client, err := apis.NewOAuthHandler(
+		clientID,
+		clientSecret,
+		authURL,
+		tokenURL,
+		tokFile,
+		Scopes).GetClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init oauth handler: %w", err)
+	}
+
+	logger = logger.With("api", Name)
+	client = metrics.WrapClient(logging.WrapClient(client, logger), Name)
+	return &TickTickAPI{
+		client:  ratelimit.WrapClient(client, limiter, Name, logger),
+		project: project,
+	}, nil
+}
+
+func (c *TickTickAPI) url(endpoint string) string {
+	if c.baseURL != "" {
+		return c.baseURL + endpoint
+	}
+	return apiURL + endpoint
+}
+
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Task struct {
+	ID           string   `json:"id,omitempty"`
+	ProjectID    string   `json:"projectId"`
+	Title        string   `json:"title"`
+	Content      string   `json:"content,omitempty"`
+	IsAllDay     bool     `json:"isAllDay"`
+	DueDate      string   `json:"dueDate,omitempty"`
+	TimeZone     string   `json:"timeZone,omitempty"`
+	Priority     int      `json:"priority"`
+	Tags         []string `json:"tags"`
+	RepeatFlag   string   `json:"repeatFlag,omitempty"`
+	Status       int      `json:"status"`
+	CompletedAt  string   `json:"completedTime,omitempty"`
+	ModifiedTime string   `json:"modifiedTime,omitempty"`
+}
+
+func (tt *Task) Task() *tasks.Task {
+	var t tasks.Task
+	t.SetAPIID(Name, tt.ID)
+	t.Title = tt.Title
+	t.Description = tt.Content
+	if tt.DueDate != "" {
+		due, err := time.Parse(dateLayout, tt.DueDate)
+		if err == nil {
+			due = due.In(time.Local)
+			if tt.IsAllDay {
+				due = time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.Local)
+			}
+			t.Due = due
+		}
+	}
+	for i, p := range priorities {
+		if tt.Priority == p {
+			t.Priority = tasks.Priority(i)
+		}
+	}
+	t.Tags = tt.Tags
+	t.Recurrence = strings.TrimPrefix(tt.RepeatFlag, "RRULE:")
+	t.Completed = tt.Status == statusCompleted
+	// The modification time is not always returned, the completion time is
+	// the best approximation then.
+	for _, s := range []string{tt.ModifiedTime, tt.CompletedAt} {
+		if modified, err := time.Parse(dateLayout, s); err == nil {
+			t.LastModified = modified
+			break
+		}
+	}
+	return &t
+}
+
+func TickTickTask(t *tasks.Task, projectID string) *Task {
+	tt := &Task{
+		ID:        t.APIID(Name),
+		ProjectID: projectID,
+		Title:     t.Title,
+		Content:   t.Description,
+		// Empty rather than nil so that updates clear them.
+		Tags: []string{},
+	}
+	if t.Tags != nil {
+		tt.Tags = t.Tags
+	}
+	if !t.Due.IsZero() {
+		tt.IsAllDay = timeutil.IsOnlyDate(t.Due)
+		tt.DueDate = t.Due.Format(dateLayout)
+		tt.TimeZone = t.Due.Location().String()
+		if tt.TimeZone == "Local" {
+			tt.TimeZone = ""
+		}
+	}
+	if t.Priority >= tasks.PriorityNone && int(t.Priority) < len(priorities) {
+		tt.Priority = priorities[t.Priority]
+	}
+	if t.Recurrence != "" {
+		tt.RepeatFlag = "RRULE:" + t.Recurrence
+	}
+	if t.Completed {
+		tt.Status = statusCompleted
+	}
+	return tt
+}
+
+func (c *TickTickAPI) makeRequest(ctx context.Context, method string, endpoint string, data any) (*http.Response, error) {
+	var reqBody io.Reader
+	if data != nil {
+		jsonBody, err := json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url(endpoint), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.client.Do(req)
+}
+
+// do sends a request and decodes the response body into v, unless v is nil.
+func (c *TickTickAPI) do(ctx context.Context, method string, endpoint string, data any, v any) error {
+	resp, err := c.makeRequest(ctx, method, endpoint, data)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := apis.HandleResponseStatusCode(resp.StatusCode); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("error decoding json: %w", err)
+	}
+	return nil
+}
+
+// GetAllProjects returns the projects of the user, without the inbox.
+func (c *TickTickAPI) GetAllProjects(ctx context.Context) ([]Project, error) {
+	var projects []Project
+	if err := c.do(ctx, "GET", "/project", nil, &projects); err != nil {
+		return nil, fmt.Errorf("failed to retrieve projects: %w", err)
+	}
+	return projects, nil
+}
+
+// projectOf returns the ID of the configured project, looking it up by
+// name or ID the first time.
+func (c *TickTickAPI) projectOf(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.projectID != "" {
+		return c.projectID, nil
+	}
+	if c.project == "" || c.project == InboxProject {
+		c.projectID = InboxProject
+		return c.projectID, nil
+	}
+
+	projects, err := c.GetAllProjects(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range projects {
+		if p.ID == c.project || strings.EqualFold(p.Name, c.project) {
+			c.projectID = p.ID
+			return c.projectID, nil
+		}
+	}
+	return "", fmt.Errorf("project '%s' not found", c.project)
+}
+
+// Capabilities of TickTick. The open API returns neither deleted nor
+// completed tasks when listing a project, so sync completes the known
+// tasks missing from it.
+func (c *TickTickAPI) Capabilities() apis.Capabilities {
+	return apis.Capabilities{Description: true, DueTime: true, Priority: true, Labels: true, Recurrence: true, Subtasks: true}
+}
+
+func (c *TickTickAPI) CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	projectID, err := c.projectOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tt Task
+	if err := c.do(ctx, "POST", "/task", TickTickTask(task, projectID), &tt); err != nil {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	task.SetAPIID(Name, tt.ID)
+	return tt.Task(), nil
+}
+
+type projectData struct {
+	Tasks []Task `json:"tasks"`
+}
+
+func (c *TickTickAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	projectID, err := c.projectOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var data projectData
+	if err := c.do(ctx, "GET", fmt.Sprintf("/project/%s/data", projectID), nil, &data); err != nil {
+		return nil, fmt.Errorf("failed to retrieve tasks of project '%s': %w", projectID, err)
+	}
+
+	tasksList := make(tasks.Tasks, len(data.Tasks))
+	for i, tt := range data.Tasks {
+		tasksList[i] = *tt.Task()
+	}
+	return tasksList, nil
+}
+
+// GetAllTasksWithDeleted returns the same tasks as GetAllTasks, deleted
+// tasks are not available.
+func (c *TickTickAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	return c.GetAllTasks(ctx)
+}
+
+func (c *TickTickAPI) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	projectID, err := c.projectOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tt Task
+	if err := c.do(ctx, "GET", fmt.Sprintf("/project/%s/task/%s", projectID, id), nil, &tt); err != nil {
+		return nil, fmt.Errorf("failed to retrieve task '%s': %w", id, err)
+	}
+	return tt.Task(), nil
+}
+
+func (c *TickTickAPI) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	projectID, err := c.projectOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := task.APIID(Name)
+	var tt Task
+	if err := c.do(ctx, "POST", fmt.Sprintf("/task/%s", id), TickTickTask(task, projectID), &tt); err != nil {
+		return nil, fmt.Errorf("failed to update task '%s': %w", id, err)
+	}
+	return tt.Task(), nil
+}
+
+// SetTaskCompleted completes the task through its dedicated endpoint, and
+// reopens it by resetting its status since there is none for that.
+func (c *TickTickAPI) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
+	projectID, err := c.projectOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	if completed {
+		if err := c.do(ctx, "POST", fmt.Sprintf("/project/%s/task/%s/complete", projectID, id), nil, nil); err != nil {
+			return fmt.Errorf("failed to complete task '%s': %w", id, err)
+		}
+		return nil
+	}
+
+	var tt Task
+	if err := c.do(ctx, "GET", fmt.Sprintf("/project/%s/task/%s", projectID, id), nil, &tt); err != nil {
+		return fmt.Errorf("failed to retrieve task '%s': %w", id, err)
+	}
+	tt.Status = statusNormal
+	tt.CompletedAt = ""
+	if err := c.do(ctx, "POST", fmt.Sprintf("/task/%s", id), &tt, nil); err != nil {
+		return fmt.Errorf("failed to reopen task '%s': %w", id, err)
+	}
+	return nil
+}
+
+func (c *TickTickAPI) DeleteTaskByID(ctx context.Context, id string) error {
+	projectID, err := c.projectOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.do(ctx, "DELETE", fmt.Sprintf("/project/%s/task/%s", projectID, id), nil, nil); err != nil {
+		return fmt.Errorf("failed to delete task '%s': %w", id, err)
+	}
+	return nil
+}
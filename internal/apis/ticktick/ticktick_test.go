@@ -0,0 +1,218 @@
+package ticktick
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// fakeServer implements the parts of the TickTick open API used by the
+// provider, keeping tasks in memory.
+type fakeServer struct {
+	mu       sync.Mutex
+	projects []Project
+	tasks    map[string]*Task
+	nextID   int
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *httptest.Server) {
+	f := &fakeServer{
+		projects: []Project{{ID: "p1", Name: "Work"}, {ID: "p2", Name: "Home"}},
+		tasks:    make(map[string]*Task),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /project", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(f.projects)
+	})
+	mux.HandleFunc("GET /project/{project}/data", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		data := projectData{Tasks: []Task{}}
+		for _, tt := range f.tasks {
+			if tt.ProjectID == r.PathValue("project") && tt.Status != statusCompleted {
+				data.Tasks = append(data.Tasks, *tt)
+			}
+		}
+		json.NewEncoder(w).Encode(data)
+	})
+	mux.HandleFunc("POST /task", func(w http.ResponseWriter, r *http.Request) {
+		var tt Task
+		if err := json.NewDecoder(r.Body).Decode(&tt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.nextID++
+		tt.ID = fmt.Sprintf("t%d", f.nextID)
+		f.tasks[tt.ID] = &tt
+		json.NewEncoder(w).Encode(tt)
+	})
+	mux.HandleFunc("POST /task/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var tt Task
+		if err := json.NewDecoder(r.Body).Decode(&tt); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.tasks[r.PathValue("id")]; !ok || tt.ID != r.PathValue("id") {
+			http.NotFound(w, r)
+			return
+		}
+		f.tasks[tt.ID] = &tt
+		json.NewEncoder(w).Encode(tt)
+	})
+	mux.HandleFunc("GET /project/{project}/task/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		tt, ok := f.task(r)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(tt)
+	})
+	mux.HandleFunc("POST /project/{project}/task/{id}/complete", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		tt, ok := f.task(r)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		tt.Status = statusCompleted
+	})
+	mux.HandleFunc("DELETE /project/{project}/task/{id}", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.task(r); !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.tasks, r.PathValue("id"))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeServer) task(r *http.Request) (*Task, bool) {
+	tt, ok := f.tasks[r.PathValue("id")]
+	if !ok || tt.ProjectID != r.PathValue("project") {
+		return nil, false
+	}
+	return tt, true
+}
+
+func newTestAPI(srv *httptest.Server, project string) *TickTickAPI {
+	return &TickTickAPI{client: srv.Client(), baseURL: srv.URL, project: project}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	f, srv := newFakeServer(t)
+	api := newTestAPI(srv, "work")
+	ctx := context.Background()
+
+	due := time.Date(2025, 6, 1, 14, 30, 0, 0, time.Local)
+	task := &tasks.Task{Title: "report", Description: "quarterly", Due: due, Priority: tasks.PriorityMedium, Tags: []string{"q2"}}
+	if _, err := api.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	id := task.APIID(Name)
+	if id == "" {
+		t.Fatal("CreateTask() did not set the task ID")
+	}
+	if got := f.tasks[id]; got.ProjectID != "p1" || got.Priority != 3 {
+		t.Errorf("stored task project = %q, priority = %d, want p1, 3", got.ProjectID, got.Priority)
+	}
+
+	all, err := api.GetAllTasks(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("GetAllTasks() returned %d tasks, want 1", len(all))
+	}
+	got := all[0]
+	if got.APIID(Name) != id || got.Title != "report" || got.Description != "quarterly" || !got.Due.Equal(due) || got.Priority != tasks.PriorityMedium ||
+		!slices.Equal(got.Tags, task.Tags) {
+		t.Errorf("GetAllTasks()[0] = %+v, want the created task", got)
+	}
+
+	if err := api.SetTaskCompleted(ctx, id, true); err != nil {
+		t.Fatalf("SetTaskCompleted(true) error = %v", err)
+	}
+	if f.tasks[id].Status != statusCompleted {
+		t.Error("task not completed")
+	}
+	if err := api.SetTaskCompleted(ctx, id, false); err != nil {
+		t.Fatalf("SetTaskCompleted(false) error = %v", err)
+	}
+	if f.tasks[id].Status != statusNormal {
+		t.Error("task not reopened")
+	}
+
+	task.Priority, task.Tags = tasks.PriorityHigh, nil
+	if _, err := api.PatchTask(ctx, task); err != nil {
+		t.Fatalf("PatchTask() error = %v", err)
+	}
+	if f.tasks[id].Priority != 5 || f.tasks[id].Tags == nil || len(f.tasks[id].Tags) != 0 {
+		t.Errorf("patched priority, tags = %d, %v, want 5 and cleared tags", f.tasks[id].Priority, f.tasks[id].Tags)
+	}
+
+	if err := api.DeleteTaskByID(ctx, id); err != nil {
+		t.Fatalf("DeleteTaskByID() error = %v", err)
+	}
+	if _, err := api.GetTaskByID(ctx, id); err == nil {
+		t.Error("GetTaskByID() of deleted task succeeded")
+	}
+}
+
+func TestProject(t *testing.T) {
+	_, srv := newFakeServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		project string
+		want    string
+		wantErr bool
+	}{
+		{project: "", want: InboxProject},
+		{project: "p2", want: "p2"},
+		{project: "Home", want: "p2"},
+		{project: "missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			got, err := newTestAPI(srv, tt.project).projectOf(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("projectOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("projectOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllDayDue(t *testing.T) {
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)
+	tt := TickTickTask(&tasks.Task{Title: "a", Due: due}, InboxProject)
+	if !tt.IsAllDay {
+		t.Error("IsAllDay = false for a date without time")
+	}
+	if got := tt.Task().Due; !got.Equal(due) {
+		t.Errorf("round-tripped due = %v, want %v", got, due)
+	}
+}
@@ -0,0 +1,92 @@
+package apis
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// DefaultTimeout bounds a single API call when no timeout is configured.
+const DefaultTimeout = 30 * time.Second
+
+type timeoutAPI struct {
+	api     API
+	timeout time.Duration
+}
+
+type timeoutBatchAPI struct {
+	*timeoutAPI
+	batch BatchAPI
+}
+
+// WithTimeout returns an API that cancels every call to api after timeout.
+// A timeout of 0 or less leaves api unchanged. The result implements
+// BatchAPI if api does.
+func WithTimeout(api API, timeout time.Duration) API {
+	if timeout <= 0 {
+		return api
+	}
+	t := &timeoutAPI{api: api, timeout: timeout}
+	if batch, ok := api.(BatchAPI); ok {
+		return &timeoutBatchAPI{timeoutAPI: t, batch: batch}
+	}
+	return t
+}
+
+func (t *timeoutBatchAPI) CreateTasks(ctx context.Context, ts []*tasks.Task) ([]*tasks.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.batch.CreateTasks(ctx, ts)
+}
+
+// Unwrap returns the API whose calls are bounded.
+func (t *timeoutAPI) Unwrap() API {
+	return t.api
+}
+
+func (t *timeoutAPI) Capabilities() Capabilities {
+	return t.api.Capabilities()
+}
+
+func (t *timeoutAPI) CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.api.CreateTask(ctx, task)
+}
+
+func (t *timeoutAPI) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.api.GetTaskByID(ctx, id)
+}
+
+func (t *timeoutAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.api.GetAllTasks(ctx)
+}
+
+func (t *timeoutAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.api.GetAllTasksWithDeleted(ctx)
+}
+
+func (t *timeoutAPI) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.api.PatchTask(ctx, task)
+}
+
+func (t *timeoutAPI) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.api.SetTaskCompleted(ctx, id, completed)
+}
+
+func (t *timeoutAPI) DeleteTaskByID(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.api.DeleteTaskByID(ctx, id)
+}
@@ -0,0 +1,18 @@
+package todoist
+
+import (
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func init() {
+	apis.Register(apis.Provider{
+		Name: "todoist",
+		New: func(_ any, opts apis.Options) (apis.API, error) {
+			return NewTodoistAPI(opts.Limiter, opts.Logger)
+		},
+		RateLimit: DefaultRateLimit,
+		ID:        func(t *tasks.Task) string { return t.TodoistID },
+		SetID:     func(t *tasks.Task, id string) { t.TodoistID = id },
+	})
+}
@@ -0,0 +1,137 @@
+package todoist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+// maxCommands is the number of commands the Sync API accepts per request.
+const maxCommands = 100
+
+type command struct {
+	Type   string `json:"type"`
+	UUID   string `json:"uuid"`
+	TempID string `json:"temp_id,omitempty"`
+	Args   any    `json:"args"`
+}
+
+type itemAddArgs struct {
+	Content     string   `json:"content"`
+	Description string   `json:"description,omitempty"`
+	Due         *itemDue `json:"due,omitempty"`
+	Priority    int      `json:"priority"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+type itemDue struct {
+	Date string `json:"date"`
+}
+
+type syncResponse struct {
+	SyncStatus    map[string]json.RawMessage `json:"sync_status"`
+	TempIDMapping map[string]string          `json:"temp_id_mapping"`
+}
+
+// CreateTasks creates tasks with Sync API item_add commands. If some
+// commands fail, the tasks created by the others still have their
+// TodoistID set.
+func (c *TodoistAPI) CreateTasks(ctx context.Context, ts []*tasks.Task) ([]*tasks.Task, error) {
+	cmds := make([]command, len(ts))
+	for i, t := range ts {
+		args := itemAddArgs{Content: t.Title, Description: t.Description, Priority: todoistPriority(t.Priority), Labels: t.Tags}
+		if !t.Due.IsZero() {
+			if timeutil.IsOnlyDate(t.Due) {
+				args.Due = &itemDue{Date: t.Due.Format("2006-01-02")}
+			} else {
+				args.Due = &itemDue{Date: t.Due.Format("2006-01-02T15:04:05")}
+			}
+		}
+		cmds[i] = command{Type: "item_add", UUID: newUUID(), TempID: newUUID(), Args: args}
+	}
+
+	mapping, err := c.sync(ctx, cmds)
+	if err != nil {
+		err = fmt.Errorf("failed to create tasks: %w", err)
+	}
+	for i, cmd := range cmds {
+		if id, ok := mapping[cmd.TempID]; ok {
+			ts[i].TodoistID = id
+		}
+	}
+	return ts, err
+}
+
+// sync sends cmds in as few requests as possible and returns the mapping
+// of their temp IDs to the created IDs. If commands fail, the mapping of
+// the others is returned with the error, and no more requests are sent.
+func (c *TodoistAPI) sync(ctx context.Context, cmds []command) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for chunk := range slices.Chunk(cmds, maxCommands) {
+		if err := c.syncChunk(ctx, chunk, mapping); err != nil {
+			return mapping, err
+		}
+	}
+	return mapping, nil
+}
+
+// syncChunk sends cmds in a single request, adding the created IDs to
+// mapping.
+func (c *TodoistAPI) syncChunk(ctx context.Context, cmds []command, mapping map[string]string) error {
+	data, err := json.Marshal(cmds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal commands: %w", err)
+	}
+	form := url.Values{"commands": {string(data)}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url("/sync"), strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	var sr syncResponse
+	err = apis.HandleResponseStatusCode(resp.StatusCode)
+	if err == nil {
+		err = json.NewDecoder(resp.Body).Decode(&sr)
+	}
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	// Commands are applied one by one, the ones before and after a failed
+	// command may have succeeded.
+	maps.Copy(mapping, sr.TempIDMapping)
+	var errs []error
+	for _, cmd := range cmds {
+		if status := sr.SyncStatus[cmd.UUID]; string(status) != `"ok"` {
+			errs = append(errs, fmt.Errorf("command %s failed: %s", cmd.Type, status))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,108 @@
+package todoist
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func TestCreateTasks(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/sync" {
+			t.Errorf("path = %s, want /sync", r.URL.Path)
+		}
+		var cmds []command
+		if err := json.Unmarshal([]byte(r.FormValue("commands")), &cmds); err != nil {
+			t.Fatalf("invalid commands: %v", err)
+		}
+
+		resp := syncResponse{SyncStatus: map[string]json.RawMessage{}, TempIDMapping: map[string]string{}}
+		for _, cmd := range cmds {
+			if cmd.Type != "item_add" {
+				t.Errorf("command type = %s, want item_add", cmd.Type)
+			}
+			args := cmd.Args.(map[string]any)
+			resp.SyncStatus[cmd.UUID] = json.RawMessage(`"ok"`)
+			resp.TempIDMapping[cmd.TempID] = "id-" + args["content"].(string)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	api := &TodoistAPI{client: srv.Client(), baseURL: srv.URL}
+
+	due := time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local)
+	var ts []*tasks.Task
+	for _, title := range []string{"a", "b", "c"} {
+		ts = append(ts, &tasks.Task{Title: title, Due: due})
+	}
+	created, err := api.CreateTasks(context.Background(), ts)
+	if err != nil {
+		t.Fatalf("CreateTasks() error = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("sent %d requests, want 1", requests)
+	}
+	for _, task := range created {
+		if want := "id-" + task.Title; task.TodoistID != want {
+			t.Errorf("task %q TodoistID = %q, want %q", task.Title, task.TodoistID, want)
+		}
+	}
+}
+
+// TestCreateTasksPartialFailure checks that the tasks created before a
+// command failed have their ID set.
+func TestCreateTasksPartialFailure(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var cmds []command
+		if err := json.Unmarshal([]byte(r.FormValue("commands")), &cmds); err != nil {
+			t.Fatalf("invalid commands: %v", err)
+		}
+		resp := syncResponse{SyncStatus: map[string]json.RawMessage{}, TempIDMapping: map[string]string{}}
+		for _, cmd := range cmds {
+			content := cmd.Args.(map[string]any)["content"].(string)
+			if content == "fail" {
+				resp.SyncStatus[cmd.UUID] = json.RawMessage(`{"error": "invalid argument"}`)
+				continue
+			}
+			resp.SyncStatus[cmd.UUID] = json.RawMessage(`"ok"`)
+			resp.TempIDMapping[cmd.TempID] = "id-" + content
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	api := &TodoistAPI{client: srv.Client(), baseURL: srv.URL}
+
+	var ts []*tasks.Task
+	for i := range maxCommands + 2 {
+		ts = append(ts, &tasks.Task{Title: strconv.Itoa(i)})
+	}
+	ts[1].Title = "fail"
+	created, err := api.CreateTasks(context.Background(), ts)
+	if err == nil {
+		t.Fatal("CreateTasks() succeeded, want the error of the failed command")
+	}
+	if requests != 1 {
+		t.Errorf("sent %d requests, want 1", requests)
+	}
+	for i, task := range created {
+		want := "id-" + task.Title
+		if i == 1 || i >= maxCommands {
+			want = ""
+		}
+		if task.TodoistID != want {
+			t.Errorf("task %d TodoistID = %q, want %q", i, task.TodoistID, want)
+		}
+	}
+}
@@ -2,14 +2,22 @@ package todoist
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+	"github.com/zeerodex/goot/internal/ratelimit"
 	"github.com/zeerodex/goot/internal/tasks"
 	"github.com/zeerodex/goot/pkg/timeutil"
 )
@@ -23,11 +31,17 @@ const (
 
 var Scopes = []string{"data:read_write"}
 
+// DefaultRateLimit follows the Todoist quota of 1000 requests per user per
+// 15 minutes.
+var DefaultRateLimit = config.RateLimit{Rate: 1000.0 / (15 * 60), Burst: 50}
+
 type TodoistAPI struct {
 	client *http.Client
+	// baseURL overrides apiURL in tests.
+	baseURL string
 }
 
-func NewTodoistAPI() (apis.API, error) {
+func NewTodoistAPI(limiter *ratelimit.Limiter, logger *slog.Logger) (apis.BatchAPI, error) {
 	clientID, clientSecret := os.Getenv("TODOIST_CLIENT_ID"), os.Getenv("TODOIST_CLIENT_SECRET")
 	client, err := apis.NewOAuthHandler(
 		clientID,
@@ -41,11 +55,20 @@ func NewTodoistAPI() (apis.API, error) {
 		return nil, fmt.Errorf("failed to init oauth handler: %w", err)
 	}
 
+	logger = logger.With("api", "todoist")
+	client = metrics.WrapClient(logging.WrapClient(client, logger), "todoist")
 	return &TodoistAPI{
-		client: client,
+		client: ratelimit.WrapClient(client, limiter, "todoist", logger),
 	}, nil
 }
 
+func (c *TodoistAPI) url(endpoint string) string {
+	if c.baseURL != "" {
+		return c.baseURL + endpoint
+	}
+	return apiURL + endpoint
+}
+
 type Task struct {
 	ID          string `json:"id"`
 	Content     string `json:"content"`
@@ -53,9 +76,31 @@ type Task struct {
 	Due         struct {
 		Date string `json:"date,omitempty"`
 	} `json:"due"`
-	CompletedAt string `json:"completed_at,omitempty"`
-	IsDeleted   bool   `json:"is_deleted"`
-	UpdatedAt   string `json:"updated_at"`
+	Priority    int      `json:"priority,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	CompletedAt string   `json:"completed_at,omitempty"`
+	IsDeleted   bool     `json:"is_deleted"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// todoistPriority converts p to a Todoist priority, from 1 (normal) to 4
+// (urgent).
+func todoistPriority(p tasks.Priority) int {
+	return int(min(max(p, tasks.PriorityNone), tasks.PriorityHigh)) + 1
+}
+
+// taskPriority converts a Todoist priority to a task priority.
+func taskPriority(p int) tasks.Priority {
+	return min(max(tasks.Priority(p-1), tasks.PriorityNone), tasks.PriorityHigh)
+}
+
+// labels returns tags as Todoist labels, empty rather than nil so that
+// updates clear them.
+func labels(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
 }
 
 func (tt *Task) Task() *tasks.Task {
@@ -64,6 +109,8 @@ func (tt *Task) Task() *tasks.Task {
 	t.Title = tt.Content
 	t.Description = tt.Description
 	t.Due, _ = timeutil.Parse(tt.Due.Date)
+	t.Priority = taskPriority(tt.Priority)
+	t.Tags = tt.Labels
 	if tt.CompletedAt != "" {
 		t.Completed = true
 	} else {
@@ -80,6 +127,8 @@ func TodoistTask(t *tasks.Task) *Task {
 	tt.Content = t.Title
 	tt.Description = t.Description
 	tt.Due.Date = t.Due.Format(time.RFC3339)
+	tt.Priority = todoistPriority(t.Priority)
+	tt.Labels = t.Tags
 	if t.Completed {
 		tt.CompletedAt = time.Now().Format(time.RFC3339)
 	} else {
@@ -90,7 +139,7 @@ func TodoistTask(t *tasks.Task) *Task {
 	return &tt
 }
 
-func (c *TodoistAPI) makeRequest(method string, endpoint string, data any) (*http.Response, error) {
+func (c *TodoistAPI) makeRequest(ctx context.Context, method string, endpoint string, data any) (*http.Response, error) {
 	var reqBody io.Reader
 	if data != nil {
 		jsonBody, err := json.Marshal(data)
@@ -99,7 +148,7 @@ func (c *TodoistAPI) makeRequest(method string, endpoint string, data any) (*htt
 		}
 		reqBody = bytes.NewBuffer(jsonBody)
 	}
-	req, err := http.NewRequest(method, apiURL+endpoint, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, c.url(endpoint), reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -110,16 +159,20 @@ func (c *TodoistAPI) makeRequest(method string, endpoint string, data any) (*htt
 }
 
 type taskCU struct {
-	Content     string `json:"content"`
-	Description string `json:"description,omitempty"`
-	DueDate     string `json:"due_date,omitempty"`
-	DueDateTime string `json:"due_datetime,omitempty"`
+	Content     string   `json:"content"`
+	Description string   `json:"description,omitempty"`
+	DueDate     string   `json:"due_date,omitempty"`
+	DueDateTime string   `json:"due_datetime,omitempty"`
+	Priority    int      `json:"priority"`
+	Labels      []string `json:"labels"`
 }
 
 func newTaskCU(task *tasks.Task) *taskCU {
 	ct := &taskCU{
 		Content:     task.Title,
 		Description: task.Description,
+		Priority:    todoistPriority(task.Priority),
+		Labels:      labels(task.Tags),
 	}
 	if !task.Due.IsZero() {
 		if timeutil.IsOnlyDate(task.Due) {
@@ -131,8 +184,15 @@ func newTaskCU(task *tasks.Task) *taskCU {
 	return ct
 }
 
-func (c TodoistAPI) CreateTask(task *tasks.Task) (*tasks.Task, error) {
-	resp, err := c.makeRequest("POST", "/tasks", newTaskCU(task))
+// Capabilities of Todoist. Deleted tasks are not returned by the REST API.
+// Todoist stores recurrence as natural language, such as "every 2 weeks",
+// which does not round-trip RRULE values.
+func (c *TodoistAPI) Capabilities() apis.Capabilities {
+	return apis.Capabilities{Description: true, DueTime: true, Priority: true, Labels: true, Subtasks: true}
+}
+
+func (c *TodoistAPI) CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	resp, err := c.makeRequest(ctx, "POST", "/tasks", newTaskCU(task))
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -153,13 +213,38 @@ func (c TodoistAPI) CreateTask(task *tasks.Task) (*tasks.Task, error) {
 	return tt.Task(), nil
 }
 
+// pageSize is the number of tasks listed per request, the maximum allowed.
+const pageSize = 200
+
 type paginatedResponse struct {
-	Tasks       []Task `json:"results,omitempty"`
-	Next_cursor string `json:"next_cursor,omitempty"`
+	Tasks      []Task `json:"results,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// GetAllTasks returns the active tasks, following the cursor of every page.
+func (c *TodoistAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	var tasksList tasks.Tasks
+	for cursor := ""; ; {
+		page, err := c.getTasksPage(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range page.Tasks {
+			tasksList = append(tasksList, *t.Task())
+		}
+		if page.NextCursor == "" {
+			return tasksList, nil
+		}
+		cursor = page.NextCursor
+	}
 }
 
-func (c *TodoistAPI) GetAllTasks() (tasks.Tasks, error) {
-	resp, err := c.makeRequest("GET", "/tasks", nil)
+func (c *TodoistAPI) getTasksPage(ctx context.Context, cursor string) (*paginatedResponse, error) {
+	q := url.Values{"limit": {strconv.Itoa(pageSize)}}
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	resp, err := c.makeRequest(ctx, "GET", "/tasks?"+q.Encode(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -169,23 +254,15 @@ func (c *TodoistAPI) GetAllTasks() (tasks.Tasks, error) {
 		return nil, err
 	}
 
-	var paginatedResp paginatedResponse
-	err = json.NewDecoder(resp.Body).Decode(&paginatedResp)
-	if err != nil {
+	var page paginatedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
 		return nil, fmt.Errorf("error encoding json: %w", err)
 	}
-
-	// HACK:
-	tasks := make(tasks.Tasks, len(paginatedResp.Tasks))
-	for i, t := range paginatedResp.Tasks {
-		tasks[i] = *t.Task()
-	}
-
-	return tasks, nil
+	return &page, nil
 }
 
-func (c *TodoistAPI) GetTaskByID(id string) (*tasks.Task, error) {
-	resp, err := c.makeRequest("GET", fmt.Sprintf("/tasks/%s", id), nil)
+func (c *TodoistAPI) GetTaskByID(ctx context.Context, id string) (*tasks.Task, error) {
+	resp, err := c.makeRequest(ctx, "GET", fmt.Sprintf("/tasks/%s", id), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -204,12 +281,12 @@ func (c *TodoistAPI) GetTaskByID(id string) (*tasks.Task, error) {
 	return task.Task(), nil
 }
 
-func (TodoistAPI) GetAllTasksWithDeleted() (_ tasks.Tasks, _ error) {
+func (*TodoistAPI) GetAllTasksWithDeleted(_ context.Context) (_ tasks.Tasks, _ error) {
 	panic("not implemented") // TODO: Implement
 }
 
-func (c *TodoistAPI) PatchTask(task *tasks.Task) (*tasks.Task, error) {
-	resp, err := c.makeRequest("POST", fmt.Sprintf("/tasks/%s", task.TodoistID), newTaskCU(task))
+func (c *TodoistAPI) PatchTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
+	resp, err := c.makeRequest(ctx, "POST", fmt.Sprintf("/tasks/%s", task.TodoistID), newTaskCU(task))
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
@@ -227,14 +304,14 @@ func (c *TodoistAPI) PatchTask(task *tasks.Task) (*tasks.Task, error) {
 	return t.Task(), nil
 }
 
-func (c *TodoistAPI) SetTaskCompleted(id string, completed bool) error {
+func (c *TodoistAPI) SetTaskCompleted(ctx context.Context, id string, completed bool) error {
 	var method string
 	if completed {
 		method = "close"
 	} else {
 		method = "reopen"
 	}
-	resp, err := c.makeRequest("POST", fmt.Sprintf("/tasks/%s/%s", id, method), nil)
+	resp, err := c.makeRequest(ctx, "POST", fmt.Sprintf("/tasks/%s/%s", id, method), nil)
 	if err != nil {
 		return fmt.Errorf("failed to make request: %w", err)
 	}
@@ -247,8 +324,8 @@ func (c *TodoistAPI) SetTaskCompleted(id string, completed bool) error {
 	return nil
 }
 
-func (c *TodoistAPI) DeleteTaskByID(id string) error {
-	resp, err := c.makeRequest("DELETE", fmt.Sprintf("/tasks/%s", id), nil)
+func (c *TodoistAPI) DeleteTaskByID(ctx context.Context, id string) error {
+	resp, err := c.makeRequest(ctx, "DELETE", fmt.Sprintf("/tasks/%s", id), nil)
 	if err != nil {
 		return fmt.Errorf("failed to make request: %w", err)
 	}
@@ -0,0 +1,69 @@
+package todoist
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func TestTaskRoundTrip(t *testing.T) {
+	for _, p := range []tasks.Priority{tasks.PriorityNone, tasks.PriorityLow, tasks.PriorityMedium, tasks.PriorityHigh} {
+		task := &tasks.Task{Title: "rent", Priority: p, Tags: []string{"home", "bills"}}
+		got := TodoistTask(task).Task()
+		if got.Priority != p {
+			t.Errorf("priority %v round-tripped to %v", p, got.Priority)
+		}
+		if !slices.Equal(got.Tags, task.Tags) {
+			t.Errorf("tags %v round-tripped to %v", task.Tags, got.Tags)
+		}
+	}
+
+	// Updates clear the labels of tasks without tags.
+	b, err := json.Marshal(newTaskCU(&tasks.Task{Title: "rent"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"labels":[]`) || !strings.Contains(string(b), `"priority":1`) {
+		t.Errorf("newTaskCU() = %s, want empty labels and priority 1", b)
+	}
+}
+
+func TestGetAllTasksPaginated(t *testing.T) {
+	pages := map[string]paginatedResponse{
+		"":      {Tasks: []Task{{ID: "1", Content: "a"}, {ID: "2", Content: "b"}}, NextCursor: "page2"},
+		"page2": {Tasks: []Task{{ID: "3", Content: "c"}}, NextCursor: "page3"},
+		"page3": {Tasks: []Task{{ID: "4", Content: "d"}}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tasks" || r.URL.Query().Get("limit") != strconv.Itoa(pageSize) {
+			t.Errorf("request = %s, want /tasks with limit %d", r.URL, pageSize)
+		}
+		page, ok := pages[r.URL.Query().Get("cursor")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer srv.Close()
+
+	api := &TodoistAPI{client: srv.Client(), baseURL: srv.URL}
+	all, err := api.GetAllTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllTasks() error = %v", err)
+	}
+	var ids []string
+	for _, task := range all {
+		ids = append(ids, task.TodoistID)
+	}
+	if want := []string{"1", "2", "3", "4"}; !slices.Equal(ids, want) {
+		t.Errorf("GetAllTasks() IDs = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,23 @@
+package todotxtapi
+
+import (
+	"github.com/zeerodex/goot/internal/apis"
+)
+
+// Settings are read from the "todotxt" config section.
+type Settings struct {
+	File string `mapstructure:"file"`
+	// DoneFile is done.txt next to File if empty.
+	DoneFile string `mapstructure:"done-file"`
+}
+
+func init() {
+	apis.Register(apis.Provider{
+		Name:        Name,
+		NewSettings: func() any { return &Settings{File: "~/todo.txt"} },
+		New: func(settings any, _ apis.Options) (apis.API, error) {
+			s := settings.(*Settings)
+			return NewTodoTxtAPI(s.File, s.DoneFile)
+		},
+	})
+}
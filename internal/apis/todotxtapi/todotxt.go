@@ -0,0 +1,501 @@
+// Package todotxtapi syncs a todo.txt file and the done.txt file its
+// completed tasks are archived to.
+package todotxtapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/internal/todotxt"
+)
+
+// Name of the provider, under which task IDs are stored.
+const Name = "todotxt"
+
+// watchDebounce groups the events of an editor saving the files into a
+// single change.
+const watchDebounce = 500 * time.Millisecond
+
+// ErrNotFound is returned when the line of a task is not found, because
+// it was edited outside goot.
+var ErrNotFound = errors.New("task not found in todo.txt")
+
+// TodoTxtAPI reads and writes the tasks of a todo.txt file. Completed tasks
+// may be moved to done.txt by other clients, they are still synced there.
+//
+// A task ID is the value of its id extension, or a hash of its title and
+// tags if it has none. Editing the title outside goot thus changes the ID
+// of tasks without an id extension, goot adds one when it edits them.
+type TodoTxtAPI struct {
+	todoFile string
+	doneFile string
+
+	mu sync.Mutex
+	// seen holds the tasks found by the last read of the files.
+	seen map[string]seenTask
+	// removed holds the tasks removed from the files since the last call
+	// to GetAllTasksWithDeleted.
+	removed map[string]*tasks.Task
+}
+
+type seenTask struct {
+	line string
+	// modified is when the task was first seen with this line.
+	modified time.Time
+}
+
+// list is a parsed todo.txt or done.txt file.
+type list struct {
+	path            string
+	lines           []string
+	crlf            bool
+	trailingNewline bool
+	mode            fs.FileMode
+	mtime           time.Time
+	items           []*listItem
+}
+
+type listItem struct {
+	id    string
+	index int
+	item  todotxt.Item
+}
+
+func NewTodoTxtAPI(todoFile, doneFile string) (*TodoTxtAPI, error) {
+	if todoFile == "" {
+		return nil, errors.New("todo.txt file is not set")
+	}
+	var err error
+	if todoFile, err = expandHome(todoFile); err != nil {
+		return nil, err
+	}
+	if doneFile == "" {
+		doneFile = filepath.Join(filepath.Dir(todoFile), "done.txt")
+	} else if doneFile, err = expandHome(doneFile); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(filepath.Dir(todoFile)); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("directory of todo.txt '%s' does not exist", todoFile)
+	}
+	return &TodoTxtAPI{
+		todoFile: todoFile,
+		doneFile: doneFile,
+		seen:     make(map[string]seenTask),
+		removed:  make(map[string]*tasks.Task),
+	}, nil
+}
+
+func expandHome(p string) (string, error) {
+	rest, ok := strings.CutPrefix(p, "~/")
+	if !ok {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, rest), nil
+}
+
+func (a *TodoTxtAPI) Capabilities() apis.Capabilities {
+	return apis.Capabilities{Priority: true, Labels: true, Recurrence: true, Tombstones: true}
+}
+
+// readList reads the file at p, which is empty if missing.
+func readList(p string) (*list, error) {
+	l := &list{path: p, mode: 0o644, trailingNewline: true}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", p, err)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", p, err)
+	}
+	l.mode, l.mtime = info.Mode().Perm(), info.ModTime()
+	text := string(data)
+	l.crlf = strings.Contains(text, "\r\n")
+	if l.crlf {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+	l.trailingNewline = text == "" || strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text != "" {
+		l.lines = strings.Split(text, "\n")
+	}
+	l.parse()
+	return l, nil
+}
+
+func (l *list) parse() {
+	l.items = nil
+	for i, line := range l.lines {
+		if it, ok := todotxt.Parse(line); ok {
+			l.items = append(l.items, &listItem{index: i, item: it})
+		}
+	}
+}
+
+// write replaces the file atomically, keeping its line endings and mode.
+func (l *list) write() error {
+	sep := "\n"
+	if l.crlf {
+		sep = "\r\n"
+	}
+	text := strings.Join(l.lines, sep)
+	if l.trailingNewline && len(l.lines) > 0 {
+		text += sep
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(l.path), "."+filepath.Base(l.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write '%s': %w", l.path, err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write '%s': %w", l.path, err)
+	}
+	if err := f.Chmod(l.mode); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write '%s': %w", l.path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", l.path, err)
+	}
+	if err := os.Rename(f.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to write '%s': %w", l.path, err)
+	}
+	return nil
+}
+
+// append appends line, ending the file with a newline.
+func (l *list) append(line string) {
+	l.lines = append(l.lines, line)
+	l.trailingNewline = true
+}
+
+// remove removes the line of li.
+func (l *list) remove(li *listItem) {
+	l.lines = append(l.lines[:li.index], l.lines[li.index+1:]...)
+	l.parse()
+}
+
+// load reads both files and sets the IDs of their tasks.
+func (a *TodoTxtAPI) load() (todo, done *list, err error) {
+	if todo, err = readList(a.todoFile); err != nil {
+		return nil, nil, err
+	}
+	if done, err = readList(a.doneFile); err != nil {
+		return nil, nil, err
+	}
+	setIDs(todo, done)
+	return todo, done, nil
+}
+
+func setIDs(lists ...*list) {
+	used := make(map[string]bool)
+	for _, l := range lists {
+		for _, li := range l.items {
+			li.id = ""
+			if id, ok := li.item.Extension("id"); ok {
+				li.id = uniqueKey(used, id)
+			}
+		}
+	}
+	for _, l := range lists {
+		for _, li := range l.items {
+			if li.id == "" {
+				li.id = uniqueKey(used, hashKey(li.item))
+			}
+		}
+	}
+}
+
+// hashKey identifies a task by its title and tags, which stay the same
+// when it is completed, archived or rescheduled.
+func hashKey(it todotxt.Item) string {
+	sum := sha1.Sum([]byte(it.Title() + "\x00" + strings.Join(it.Tags(), " ")))
+	return hex.EncodeToString(sum[:4])
+}
+
+func uniqueKey(used map[string]bool, key string) string {
+	k := key
+	for i := 2; used[k]; i++ {
+		k = fmt.Sprintf("%s-%d", key, i)
+	}
+	used[k] = true
+	return k
+}
+
+func find(id string, lists ...*list) (*list, *listItem, bool) {
+	for _, l := range lists {
+		for _, li := range l.items {
+			if li.id == id {
+				return l, li, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// task returns the task of li, noting it as seen.
+func (a *TodoTxtAPI) task(l *list, li *listItem) *tasks.Task {
+	line := li.item.String()
+	s, ok := a.seen[li.id]
+	if !ok || s.line != line {
+		s = seenTask{line: line, modified: l.mtime.UTC().Truncate(time.Second)}
+		a.seen[li.id] = s
+	}
+	task := li.item.Task()
+	task.LastModified = s.modified
+	task.SetAPIID(Name, li.id)
+	return task
+}
+
+// modifiedAt returns when task was modified, for the tasks written by goot
+// not to look newer than their local copy.
+func modifiedAt(task *tasks.Task) time.Time {
+	if task.LastModified.IsZero() {
+		return time.Now().UTC().Truncate(time.Second)
+	}
+	return task.LastModified
+}
+
+func (a *TodoTxtAPI) GetAllTasks(_ context.Context) (tasks.Tasks, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	todo, done, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var tasksList tasks.Tasks
+	found := make(map[string]bool)
+	for _, l := range []*list{todo, done} {
+		for _, li := range l.items {
+			found[li.id] = true
+			tasksList = append(tasksList, *a.task(l, li))
+		}
+	}
+
+	for id := range a.seen {
+		if !found[id] {
+			task := &tasks.Task{Deleted: true, LastModified: time.Now().UTC()}
+			task.SetAPIID(Name, id)
+			a.removed[id] = task
+			delete(a.seen, id)
+		}
+	}
+	return tasksList, nil
+}
+
+func (a *TodoTxtAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	tasksList, err := a.GetAllTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, task := range a.removed {
+		tasksList = append(tasksList, *task)
+		delete(a.removed, id)
+	}
+	return tasksList, nil
+}
+
+func (a *TodoTxtAPI) GetTaskByID(_ context.Context, id string) (*tasks.Task, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	todo, done, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+	l, li, ok := find(id, todo, done)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	}
+	return a.task(l, li), nil
+}
+
+// CreateTask appends task to todo.txt with a new id extension.
+func (a *TodoTxtAPI) CreateTask(_ context.Context, task *tasks.Task) (*tasks.Task, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	todo, done, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 3)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate task ID: %w", err)
+	}
+	id := hex.EncodeToString(b)
+
+	it := todotxt.FromTask(task)
+	it.SetExtension("id", id)
+	todo.append(it.String())
+	if err := todo.write(); err != nil {
+		return nil, err
+	}
+
+	todo.parse()
+	setIDs(todo, done)
+	if _, li, ok := find(id, todo); ok {
+		a.seen[id] = seenTask{line: li.item.String(), modified: modifiedAt(task)}
+	}
+	task.SetAPIID(Name, id)
+	return task, nil
+}
+
+// update rewrites the line of the task id with change applied. A task
+// uncompleted in done.txt is moved back to todo.txt.
+func (a *TodoTxtAPI) update(id string, modified time.Time, change func(*todotxt.Item) bool) (*tasks.Task, error) {
+	todo, done, err := a.load()
+	if err != nil {
+		return nil, err
+	}
+	l, li, ok := find(id, todo, done)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	}
+
+	it := li.item
+	if !change(&it) {
+		return a.task(l, li), nil
+	}
+	if _, ok := it.Extension("id"); !ok && hashKey(it) != hashKey(li.item) {
+		it.SetExtension("id", id)
+	}
+
+	if l == done && !it.Completed {
+		done.remove(li)
+		todo.append(it.String())
+		if err := todo.write(); err != nil {
+			return nil, err
+		}
+		if err := done.write(); err != nil {
+			return nil, err
+		}
+		todo.parse()
+	} else {
+		l.lines[li.index] = it.String()
+		if err := l.write(); err != nil {
+			return nil, err
+		}
+		l.parse()
+	}
+
+	setIDs(todo, done)
+	l, li, ok = find(id, todo, done)
+	if !ok {
+		return nil, fmt.Errorf("%w: '%s'", ErrNotFound, id)
+	}
+	a.seen[id] = seenTask{line: li.item.String(), modified: modified}
+	return a.task(l, li), nil
+}
+
+// PatchTask rewrites the line of task if it changed, adding an id
+// extension to keep its ID when its title or tags change.
+func (a *TodoTxtAPI) PatchTask(_ context.Context, task *tasks.Task) (*tasks.Task, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.update(task.APIID(Name), modifiedAt(task), func(it *todotxt.Item) bool {
+		return it.Update(task, time.Now())
+	})
+}
+
+func (a *TodoTxtAPI) SetTaskCompleted(_ context.Context, id string, completed bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, err := a.update(id, time.Now().UTC().Truncate(time.Second), func(it *todotxt.Item) bool {
+		if it.Completed == completed {
+			return false
+		}
+		it.SetCompleted(completed, time.Now())
+		return true
+	})
+	return err
+}
+
+func (a *TodoTxtAPI) DeleteTaskByID(_ context.Context, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	todo, done, err := a.load()
+	if err != nil {
+		return err
+	}
+	l, li, ok := find(id, todo, done)
+	if !ok {
+		return nil
+	}
+	l.remove(li)
+	if err := l.write(); err != nil {
+		return err
+	}
+	delete(a.seen, id)
+	return nil
+}
+
+// Watch calls changed once todo.txt and done.txt stop changing for
+// watchDebounce, until ctx is done. Their directories are watched, as
+// editors often replace files instead of writing them.
+func (a *TodoTxtAPI) Watch(ctx context.Context, changed func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer w.Close()
+
+	for _, dir := range []string{filepath.Dir(a.todoFile), filepath.Dir(a.doneFile)} {
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch '%s': %w", dir, err)
+		}
+	}
+
+	timer := time.AfterFunc(watchDebounce, changed)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Name == a.todoFile || ev.Name == a.doneFile {
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("failed to watch todo.txt: %w", err)
+		}
+	}
+}
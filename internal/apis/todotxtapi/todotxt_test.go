@@ -0,0 +1,134 @@
+package todotxtapi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func newTestAPI(t *testing.T, todo, done string) (*TodoTxtAPI, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "todo.txt"), []byte(todo), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "done.txt"), []byte(done), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	api, err := NewTodoTxtAPI(filepath.Join(dir, "todo.txt"), "")
+	if err != nil {
+		t.Fatalf("NewTodoTxtAPI: %v", err)
+	}
+	return api, dir
+}
+
+func readFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func byTitle(ts tasks.Tasks, title string) *tasks.Task {
+	for i := range ts {
+		if ts[i].Title == title {
+			return &ts[i]
+		}
+	}
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	today := time.Now().Format("2006-01-02")
+	api, dir := newTestAPI(t,
+		"(A) 2026-10-01 Call mom +family due:2026-10-20\r\nWater plants @home\r\n",
+		"x 2026-10-02 2026-10-01 Pay rent pri:B\n")
+	ctx := context.Background()
+
+	ts, err := api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted: %v", err)
+	}
+	var titles []string
+	for _, task := range ts {
+		titles = append(titles, task.Title)
+	}
+	if want := []string{"Call mom", "Water plants", "Pay rent"}; !slices.Equal(titles, want) {
+		t.Fatalf("titles = %v, want %v", titles, want)
+	}
+	rent := byTitle(ts, "Pay rent")
+	if !rent.Completed || rent.Priority != tasks.PriorityMedium {
+		t.Errorf("rent = %+v", rent)
+	}
+
+	// Completing keeps the line in todo.txt and its line endings.
+	call := byTitle(ts, "Call mom")
+	if err := api.SetTaskCompleted(ctx, call.APIID(Name), true); err != nil {
+		t.Fatalf("SetTaskCompleted: %v", err)
+	}
+	want := "x " + today + " 2026-10-01 Call mom +family due:2026-10-20 pri:A\r\nWater plants @home\r\n"
+	if got := readFile(t, filepath.Join(dir, "todo.txt")); got != want {
+		t.Errorf("todo.txt = %q, want %q", got, want)
+	}
+
+	// Uncompleting an archived task moves it back to todo.txt.
+	rent.Completed = false
+	rent.Title = "Pay the rent"
+	if _, err := api.PatchTask(ctx, rent); err != nil {
+		t.Fatalf("PatchTask: %v", err)
+	}
+	if got := readFile(t, filepath.Join(dir, "done.txt")); got != "" {
+		t.Errorf("done.txt = %q, want it empty", got)
+	}
+	want += "(B) 2026-10-01 Pay the rent id:" + rent.APIID(Name) + "\r\n"
+	if got := readFile(t, filepath.Join(dir, "todo.txt")); got != want {
+		t.Errorf("todo.txt = %q, want %q", got, want)
+	}
+
+	// IDs survive the edits, and lines removed by another client come back
+	// as tombstones once.
+	ts, err = api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted: %v", err)
+	}
+	if got := byTitle(ts, "Pay the rent"); got == nil || got.APIID(Name) != rent.APIID(Name) {
+		t.Fatalf("patched task lost its ID: %+v", got)
+	}
+	lines := strings.SplitAfter(readFile(t, filepath.Join(dir, "todo.txt")), "\r\n")
+	if err := os.WriteFile(filepath.Join(dir, "todo.txt"), []byte(lines[0]+lines[2]), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ts, err = api.GetAllTasksWithDeleted(ctx)
+	if err != nil {
+		t.Fatalf("GetAllTasksWithDeleted: %v", err)
+	}
+	i := slices.IndexFunc(ts, func(t tasks.Task) bool { return t.Deleted })
+	if i < 0 || len(ts) != 3 {
+		t.Fatalf("tasks after removal = %+v", ts)
+	}
+}
+
+func TestCreateTask(t *testing.T) {
+	api, dir := newTestAPI(t, "Existing", "")
+	task := &tasks.Task{Title: "Book flights", Tags: []string{"travel"}, Priority: tasks.PriorityHigh, LastModified: time.Date(2026, 10, 5, 8, 0, 0, 0, time.Local)}
+	if _, err := api.CreateTask(context.Background(), task); err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	want := "Existing\n(A) 2026-10-05 Book flights +travel id:" + task.APIID(Name) + "\n"
+	if got := readFile(t, filepath.Join(dir, "todo.txt")); got != want {
+		t.Errorf("todo.txt = %q, want %q", got, want)
+	}
+
+	got, err := api.GetTaskByID(context.Background(), task.APIID(Name))
+	if err != nil || got.Title != "Book flights" || !got.LastModified.Equal(task.LastModified) {
+		t.Errorf("GetTaskByID = %+v, %v", got, err)
+	}
+}
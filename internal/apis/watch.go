@@ -0,0 +1,26 @@
+package apis
+
+import "context"
+
+// Watcher is implemented by APIs noticing changes to their tasks by
+// themselves, such as file-based ones, so that the daemon syncs them right
+// away instead of waiting for the sync interval.
+type Watcher interface {
+	// Watch calls changed whenever the tasks may have changed, until ctx
+	// is done.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// AsWatcher returns api, or the API it wraps, as a Watcher.
+func AsWatcher(api API) (Watcher, bool) {
+	for {
+		if w, ok := api.(Watcher); ok {
+			return w, true
+		}
+		u, ok := api.(interface{ Unwrap() API })
+		if !ok {
+			return nil, false
+		}
+		api = u.Unwrap()
+	}
+}
@@ -0,0 +1,145 @@
+// Package calendar renders tasks with due dates as an iCalendar calendar
+// that calendar apps can import or subscribe to.
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/ical"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+const prodID = "-//goot//goot//EN"
+
+// eventLength is the length of the events of tasks due at a time of day.
+const eventLength = 30 * time.Minute
+
+// iCalendar priorities, mapped to tasks.Priority by position: 1 is the
+// highest and 9 the lowest, 0 is undefined.
+var priorities = []int{0, 9, 5, 1}
+
+type Options struct {
+	// Events adds a VEVENT at the due date of every task, and Todos a VTODO.
+	Events, Todos bool
+	// Name is the calendar name shown by calendar apps, if set.
+	Name string
+	// Refresh is how often subscribers should fetch the calendar again, if
+	// set.
+	Refresh time.Duration
+}
+
+// UID returns the UID of the component of task, "VEVENT" or "VTODO". It
+// only depends on the task ID, so that calendar apps update the entries
+// of a task instead of duplicating them.
+func UID(task *tasks.Task, component string) string {
+	return fmt.Sprintf("goot-task-%d-%s@goot", task.ID, strings.ToLower(strings.TrimPrefix(component, "V")))
+}
+
+// New returns a VCALENDAR with the components selected by opts for every
+// task of ts that has a due date.
+func New(ts tasks.Tasks, opts Options) *ical.Component {
+	cal := ical.NewComponent("VCALENDAR")
+	cal.Set("VERSION", "2.0", nil)
+	cal.Set("PRODID", prodID, nil)
+	cal.Set("CALSCALE", "GREGORIAN", nil)
+	if opts.Name != "" {
+		cal.SetText("X-WR-CALNAME", opts.Name)
+	}
+	if opts.Refresh > 0 {
+		refresh := fmt.Sprintf("PT%dM", max(int(opts.Refresh.Minutes()), 1))
+		cal.Set("REFRESH-INTERVAL", refresh, map[string]string{"VALUE": "DURATION"})
+		cal.Set("X-PUBLISHED-TTL", refresh, nil)
+	}
+
+	for i := range ts {
+		task := &ts[i]
+		if task.Due.IsZero() {
+			continue
+		}
+		if opts.Events {
+			cal.Children = append(cal.Children, newEvent(task))
+		}
+		if opts.Todos {
+			cal.Children = append(cal.Children, newTodo(task))
+		}
+	}
+	return cal
+}
+
+// Encoder returns a function writing the calendar of tasks selected by opts.
+func Encoder(opts Options) func(io.Writer, tasks.Tasks) error {
+	return func(w io.Writer, ts tasks.Tasks) error {
+		return New(ts, opts).Encode(w)
+	}
+}
+
+// newComponent returns a component called name with the properties shared
+// by events and to-dos.
+func newComponent(name string, task *tasks.Task) *ical.Component {
+	c := ical.NewComponent(name)
+	c.Set("UID", UID(task, name), nil)
+	// The stamps come from the task rather than the clock, so that an
+	// unchanged calendar encodes to the same bytes.
+	modified := task.LastModified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	c.SetTime("DTSTAMP", modified, false)
+	c.SetTime("LAST-MODIFIED", modified, false)
+	c.SetText("SUMMARY", task.Title)
+	if task.Description != "" {
+		c.SetText("DESCRIPTION", task.Description)
+	}
+	if len(task.Tags) > 0 {
+		categories := make([]string, len(task.Tags))
+		for i, tag := range task.Tags {
+			categories[i] = ical.EscapeText(tag)
+		}
+		c.Set("CATEGORIES", strings.Join(categories, ","), nil)
+	}
+	if task.Priority > tasks.PriorityNone && int(task.Priority) < len(priorities) {
+		c.Set("PRIORITY", strconv.Itoa(priorities[task.Priority]), nil)
+	}
+	return c
+}
+
+func newEvent(task *tasks.Task) *ical.Component {
+	event := newComponent("VEVENT", task)
+	dateOnly := timeutil.IsOnlyDate(task.Due)
+	event.SetTime("DTSTART", task.Due, dateOnly)
+	if dateOnly {
+		event.SetTime("DTEND", task.Due.AddDate(0, 0, 1), true)
+	} else {
+		event.SetTime("DTEND", task.Due.Add(eventLength), false)
+	}
+	if task.Recurrence != "" {
+		event.Set("RRULE", task.Recurrence, nil)
+	}
+	// Tasks do not make their owner busy.
+	event.Set("TRANSP", "TRANSPARENT", nil)
+	return event
+}
+
+func newTodo(task *tasks.Task) *ical.Component {
+	todo := newComponent("VTODO", task)
+	dateOnly := timeutil.IsOnlyDate(task.Due)
+	todo.SetTime("DUE", task.Due, dateOnly)
+	if task.Recurrence != "" {
+		// Recurrence is computed from DTSTART.
+		todo.SetTime("DTSTART", task.Due, dateOnly)
+		todo.Set("RRULE", task.Recurrence, nil)
+	}
+	if task.Completed {
+		todo.Set("STATUS", "COMPLETED", nil)
+		todo.Set("COMPLETED", todo.Get("LAST-MODIFIED").Value, nil)
+		todo.Set("PERCENT-COMPLETE", "100", nil)
+	} else {
+		todo.Set("STATUS", "NEEDS-ACTION", nil)
+	}
+	return todo
+}
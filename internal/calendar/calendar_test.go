@@ -0,0 +1,53 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/ical"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func TestNew(t *testing.T) {
+	modified := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	ts := tasks.Tasks{
+		{ID: 1, Title: "Pay rent", Due: time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local), Recurrence: "FREQ=MONTHLY", Tags: []string{"home", "a,b"}, LastModified: modified},
+		{ID: 2, Title: "Call mom", Due: time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC), Priority: tasks.PriorityHigh, Completed: true, LastModified: modified},
+		{ID: 3, Title: "Someday", LastModified: modified},
+	}
+
+	cal := New(ts, Options{Events: true, Todos: true, Name: "goot", Refresh: time.Hour})
+	if len(cal.Children) != 4 {
+		t.Fatalf("calendar has %d components, want 4:\n%s", len(cal.Children), cal)
+	}
+	if got := cal.Get("REFRESH-INTERVAL"); got == nil || got.Value != "PT60M" {
+		t.Errorf("REFRESH-INTERVAL = %+v", got)
+	}
+
+	rent, rentTodo := cal.Children[0], cal.Children[1]
+	if rent.Name != "VEVENT" || rent.Text("UID") != "goot-task-1-event@goot" || rentTodo.Text("UID") != "goot-task-1-todo@goot" {
+		t.Errorf("rent UIDs = %q, %q", rent.Text("UID"), rentTodo.Text("UID"))
+	}
+	for name, want := range map[string]string{"DTSTART": "20261101", "DTEND": "20261102", "RRULE": "FREQ=MONTHLY", "CATEGORIES": `home,a\,b`, "DTSTAMP": "20261001T080000Z"} {
+		if got := rent.Get(name); got == nil || got.Value != want {
+			t.Errorf("event %s = %+v, want %q", name, got, want)
+		}
+	}
+
+	call := cal.Children[3]
+	for name, want := range map[string]string{"DUE": "20261020T180000Z", "STATUS": "COMPLETED", "PRIORITY": "1", "COMPLETED": "20261001T080000Z"} {
+		if got := call.Get(name); got == nil || got.Value != want {
+			t.Errorf("todo %s = %+v, want %q", name, got, want)
+		}
+	}
+	if end, _, _ := cal.Children[2].Time("DTEND", time.UTC); !end.Equal(ts[1].Due.Add(eventLength)) {
+		t.Errorf("event DTEND = %v", end)
+	}
+
+	// Encoding is deterministic, so feeds only change with the tasks.
+	decoded, err := ical.Decode(strings.NewReader(cal.String()))
+	if err != nil || decoded.String() != New(ts, Options{Events: true, Todos: true, Name: "goot", Refresh: time.Hour}).String() {
+		t.Errorf("calendar does not round trip: %v", err)
+	}
+}
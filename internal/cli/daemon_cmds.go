@@ -1,20 +1,172 @@
 package cli
 
 import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
 	"github.com/spf13/cobra"
 
+	"github.com/zeerodex/goot/internal/config"
 	"github.com/zeerodex/goot/internal/daemon"
+	"github.com/zeerodex/goot/internal/database"
+	"github.com/zeerodex/goot/internal/output"
 	"github.com/zeerodex/goot/internal/services"
 )
 
-func NewDaemonCmd(s services.TaskService) *cobra.Command {
+func NewDaemonCmd(s services.TaskService, cfg *config.Config, logger *slog.Logger) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "daemon",
 		Short: "Start a daemon of gootodo",
 		Args:  cobra.ExactArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
-			daemon.StartDaemon(s)
+			daemon.StartDaemon(cmd.Context(), s, cfg, logger)
+		},
+	}
+
+	cmd.AddCommand(NewDaemonReloadCmd())
+	cmd.AddCommand(NewDaemonStatusCmd())
+	cmd.AddCommand(NewDaemonInstallCmd())
+	cmd.AddCommand(NewDaemonUninstallCmd())
+	return cmd
+}
+
+func NewDaemonReloadCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reload",
+		Short: "Makes the running daemon reload its config",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := daemon.Reload(); err != nil {
+				return err
+			}
+			cmd.Println("Reload signal sent to daemon.")
+			return nil
+		},
+	}
+}
+
+func NewDaemonStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Shows the state of the running daemon and its periodic sync",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := daemon.ReadStatus()
+			if err != nil {
+				return err
+			}
+
+			w, err := newOutput(cmd, false)
+			if err != nil {
+				return err
+			}
+			switch w.Format().Name {
+			case output.Table, output.Plain, output.CSV:
+			default:
+				// Other formats encode the whole status.
+				return output.WriteOne(w, nil, *status)
+			}
+
+			w.Printf("Daemon running (pid %d) since %s\n", status.PID, status.StartedAt.Format(time.DateTime))
+			w.Printf("Sync interval: %s\n\n", status.SyncInterval)
+			var providers []providerStatus
+			for _, name := range slices.Sorted(maps.Keys(status.Providers)) {
+				providers = append(providers, providerStatus{API: name, ProviderStatus: status.Providers[name]})
+			}
+			return output.Write(w, providerStatusColumns, providers)
+		},
+	}
+}
+
+// providerStatus is a row of 'goot daemon status'.
+type providerStatus struct {
+	API string
+	daemon.ProviderStatus
+}
+
+// statusTime formats t, "" if zero.
+func statusTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateTime)
+}
+
+var providerStatusColumns = []output.Column[providerStatus]{
+	{Name: "api", Value: func(p providerStatus) string { return p.API }},
+	{Name: "last_success", Value: func(p providerStatus) string { return statusTime(p.LastSuccess) }},
+	{Name: "next_sync", Value: func(p providerStatus) string { return statusTime(p.NextSync) }},
+	{Name: "failures", Value: func(p providerStatus) string { return strconv.Itoa(p.Failures) }},
+	{
+		Name: "last_error",
+		Value: func(p providerStatus) string {
+			if p.LastError == "" {
+				return ""
+			}
+			return p.LastError + " (" + statusTime(p.LastErrorAt) + ")"
+		},
+		Flex: true,
+	},
+}
+
+func NewDaemonInstallCmd() *cobra.Command {
+	var opts daemon.UnitOptions
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Installs systemd user units running the daemon",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			opts.Executable, err = os.Executable()
+			if err != nil {
+				return fmt.Errorf("failed to resolve goot executable: %w", err)
+			}
+			if opts.Executable, err = filepath.EvalSymlinks(opts.Executable); err != nil {
+				return fmt.Errorf("failed to resolve goot executable: %w", err)
+			}
+			if opts.WorkDir, err = os.Getwd(); err != nil {
+				return fmt.Errorf("failed to resolve working directory: %w", err)
+			}
+			if opts.ConfigFile, err = filepath.Abs(config.File()); err != nil {
+				return fmt.Errorf("failed to resolve config file: %w", err)
+			}
+			if opts.DBFile, err = filepath.Abs(database.File); err != nil {
+				return fmt.Errorf("failed to resolve database file: %w", err)
+			}
+
+			units, err := daemon.InstallUnits(opts)
+			for _, unit := range units {
+				cmd.Println("Written " + unit)
+			}
+			if err != nil {
+				return err
+			}
+
+			cmd.Println("Start the daemon with:\n\tsystemctl --user enable --now goot.service")
+			return nil
 		},
 	}
+	cmd.Flags().DurationVar(&opts.Watchdog, "watchdog", 5*time.Minute, "Restart the daemon if it stops responding for this long")
 	return cmd
 }
+
+func NewDaemonUninstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stops and removes the systemd user units of the daemon",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			units, err := daemon.UninstallUnits()
+			for _, unit := range units {
+				cmd.Println("Removed " + unit)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/zeerodex/goot/internal/interchange"
+	"github.com/zeerodex/goot/internal/output"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const outputFlag = "output"
+
+// addOutputFlag adds --output to cmd and its subcommands.
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP(outputFlag, "o", output.Table, "Output format: "+output.Formats)
+}
+
+// newOutput returns a writer to the output of cmd in the format of
+// --output, or in JSON if json is set by a --json flag.
+func newOutput(cmd *cobra.Command, json bool) (*output.Writer, error) {
+	name, _ := cmd.Flags().GetString(outputFlag)
+	switch {
+	case json:
+		name = output.JSON
+	case name == "":
+		name = output.Table
+	}
+	f, err := output.ParseFormat(name)
+	if err != nil {
+		return nil, err
+	}
+	return output.NewWriter(cmd.OutOrStdout(), f), nil
+}
+
+var (
+	overdueColor  = lipgloss.Color("9")
+	todayColor    = lipgloss.Color("11")
+	doneColor     = lipgloss.Color("10")
+	priorityColor = map[tasks.Priority]lipgloss.Color{
+		tasks.PriorityHigh:   lipgloss.Color("9"),
+		tasks.PriorityMedium: lipgloss.Color("11"),
+		tasks.PriorityLow:    lipgloss.Color("12"),
+	}
+)
+
+func taskStatus(t tasks.Task) string {
+	if t.Completed {
+		return "completed"
+	}
+	return "pending"
+}
+
+func taskPriority(t tasks.Task) string {
+	if t.Priority == tasks.PriorityNone {
+		return ""
+	}
+	return t.Priority.String()
+}
+
+func taskRemoteIDs(t tasks.Task) string {
+	ids := interchange.RemoteIDs(&t)
+	var pairs []string
+	for _, api := range slices.Sorted(maps.Keys(ids)) {
+		pairs = append(pairs, api+":"+ids[api])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+var (
+	idColumn = output.Column[tasks.Task]{Name: "id", Value: func(t tasks.Task) string { return strconv.Itoa(t.ID) }}
+
+	titleColumn = output.Column[tasks.Task]{
+		Name:  "title",
+		Value: func(t tasks.Task) string { return t.Title },
+		Render: func(t tasks.Task, r *lipgloss.Renderer) string {
+			if t.Completed {
+				return r.NewStyle().Faint(true).Render(t.Title)
+			}
+			return t.Title
+		},
+		Flex: true,
+	}
+
+	dueColumn = output.Column[tasks.Task]{
+		Name:  "due",
+		Value: func(t tasks.Task) string { return t.DueStr() },
+		Render: func(t tasks.Task, r *lipgloss.Renderer) string {
+			due := t.DueStr()
+			if due == "" || t.Completed {
+				return due
+			}
+			// goot stores dates without time as midnight UTC.
+			now := time.Now()
+			today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+			wall := time.Date(t.Due.Year(), t.Due.Month(), t.Due.Day(), 0, 0, 0, 0, time.UTC)
+			switch {
+			case wall.Before(today):
+				return r.NewStyle().Foreground(overdueColor).Render(due)
+			case wall.Equal(today):
+				return r.NewStyle().Foreground(todayColor).Render(due)
+			}
+			return due
+		},
+	}
+
+	priorityColumn = output.Column[tasks.Task]{
+		Name:  "priority",
+		Value: taskPriority,
+		Render: func(t tasks.Task, r *lipgloss.Renderer) string {
+			if c, ok := priorityColor[t.Priority]; ok {
+				return r.NewStyle().Foreground(c).Render(taskPriority(t))
+			}
+			return taskPriority(t)
+		},
+	}
+
+	tagsColumn = output.Column[tasks.Task]{Name: "tags", Value: func(t tasks.Task) string { return strings.Join(t.Tags, ", ") }, Flex: true}
+
+	statusColumn = output.Column[tasks.Task]{
+		Name:  "status",
+		Value: taskStatus,
+		Render: func(t tasks.Task, r *lipgloss.Renderer) string {
+			if t.Completed {
+				return r.NewStyle().Foreground(doneColor).Render(taskStatus(t))
+			}
+			return taskStatus(t)
+		},
+	}
+)
+
+// taskColumns are the columns of task lists.
+var taskColumns = []output.Column[tasks.Task]{idColumn, titleColumn, dueColumn, priorityColumn, tagsColumn, statusColumn}
+
+// taskDetailColumns are the columns of single tasks.
+var taskDetailColumns = []output.Column[tasks.Task]{
+	idColumn, titleColumn,
+	{Name: "description", Value: func(t tasks.Task) string { return t.Description }, Flex: true},
+	dueColumn,
+	{Name: "recurrence", Value: func(t tasks.Task) string { return t.Recurrence }},
+	priorityColumn, tagsColumn, statusColumn,
+	{Name: "modified", Value: func(t tasks.Task) string {
+		if t.LastModified.IsZero() {
+			return ""
+		}
+		return t.LastModified.Local().Format(time.DateTime)
+	}},
+	{Name: "remote_ids", Value: taskRemoteIDs, Flex: true},
+}
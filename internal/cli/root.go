@@ -1,23 +1,31 @@
 package cli
 
 import (
-	"log"
+	"context"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 
 	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/logging"
 	"github.com/zeerodex/goot/internal/services"
 	"github.com/zeerodex/goot/internal/tui"
 )
 
-func newRootCmd(s services.TaskService) *cobra.Command {
+func newRootCmd(s services.TaskService, cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "goot",
 		Short: "Sleek cli/tui task manager with APIs integration",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			program := tea.NewProgram(tui.InitialMainModel(s))
+			if cfg.Log.File == "" {
+				// Log lines written to stderr would corrupt the TUI.
+				logging.Mute()
+			}
+			program := tea.NewProgram(tui.InitialMainModel(cmd.Context(), s))
 
 			if _, err := program.Run(); err != nil {
 				return err
@@ -27,32 +35,43 @@ func newRootCmd(s services.TaskService) *cobra.Command {
 	}
 }
 
-func Execute(s services.TaskService, cfg *config.Config) {
-	rootCmd := newRootCmd(s)
+func Execute(s services.TaskService, cfg *config.Config, logger *slog.Logger) {
+	rootCmd := newRootCmd(s, cfg)
 
 	commands := []*cobra.Command{
 		NewCreateCmd(s),
 		NewAllTasksCmd(s),
+		NewShowTaskCmd(s),
+		NewSearchCmd(s),
 		NewDeleteTaskCmd(s),
 		NewDoneTaskCmd(s),
+		NewImportCmd(s),
+		NewExportCmd(s),
 
-		NewDaemonCmd(s),
+		NewDaemonCmd(s, cfg, logger),
+		NewServeCmd(s, cfg, logger),
 
 		NewSyncCmd(s, cfg.APIs),
 
 		NewGetAllTodoistTasks(),
 	}
 	rootCmd.AddCommand(commands...)
+	addOutputFlag(rootCmd)
+
+	// Interrupting goot cancels the API requests in flight.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	if cfg.SyncOnStartup {
-		err := s.Sync()
+		err := s.Sync(ctx)
 		if err != nil {
-			log.Printf("Failed to sync tasks on startup: %v", err)
+			logger.Error("failed to sync tasks on startup", "err", err)
 		}
 	}
 
-	err := rootCmd.Execute()
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
+		stop()
 		os.Exit(1)
 	}
 }
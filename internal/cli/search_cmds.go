@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"github.com/zeerodex/goot/internal/output"
+	"github.com/zeerodex/goot/internal/services"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// renderSnippet replaces the highlight markers of a search snippet with
+// bold colored text.
+func renderSnippet(snippet string, r *lipgloss.Renderer) string {
+	style := r.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	var b strings.Builder
+	for {
+		before, rest, ok := strings.Cut(snippet, tasks.HighlightStart)
+		b.WriteString(before)
+		if !ok {
+			return b.String()
+		}
+		match, after, _ := strings.Cut(rest, tasks.HighlightEnd)
+		b.WriteString(style.Render(match))
+		snippet = after
+	}
+}
+
+// searchMatch is a task found by 'goot search' with Match, the text that
+// matched, which is Snippet without its highlight markers.
+type searchMatch struct {
+	tasks.Task
+	Match   string `json:"match"`
+	Snippet string `json:"-"`
+}
+
+func newSearchMatch(res tasks.SearchResult) searchMatch {
+	plain := strings.NewReplacer(tasks.HighlightStart, "", tasks.HighlightEnd, "").Replace(res.Snippet)
+	return searchMatch{Task: res.Task, Match: plain, Snippet: res.Snippet}
+}
+
+// inTitle reports whether m matched its title rather than its description.
+func (m searchMatch) inTitle() bool {
+	return m.Match == m.Title
+}
+
+// searchColumns highlight the matches in titles, or show the matching part
+// of descriptions after them.
+var searchColumns = []output.Column[searchMatch]{
+	{Name: "id", Value: func(m searchMatch) string { return idColumn.Value(m.Task) }},
+	{
+		Name:  "title",
+		Value: func(m searchMatch) string { return m.Title },
+		Render: func(m searchMatch, r *lipgloss.Renderer) string {
+			if m.inTitle() {
+				return renderSnippet(m.Snippet, r)
+			}
+			return m.Title
+		},
+		Flex: true,
+	},
+	{
+		Name: "match",
+		Value: func(m searchMatch) string {
+			if m.inTitle() {
+				return ""
+			}
+			return m.Match
+		},
+		Render: func(m searchMatch, r *lipgloss.Renderer) string {
+			if m.inTitle() {
+				return ""
+			}
+			return renderSnippet(m.Snippet, r)
+		},
+		Flex: true,
+	},
+	{Name: "due", Value: func(m searchMatch) string { return dueColumn.Value(m.Task) }, Render: func(m searchMatch, r *lipgloss.Renderer) string { return dueColumn.Render(m.Task, r) }},
+	{Name: "status", Value: func(m searchMatch) string { return statusColumn.Value(m.Task) }, Render: func(m searchMatch, r *lipgloss.Renderer) string { return statusColumn.Render(m.Task, r) }},
+}
+
+func NewSearchCmd(s services.TaskService) *cobra.Command {
+	var limit int
+	var q string
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Searches the titles and descriptions of tasks",
+		Long: `Searches the titles and descriptions of tasks, best matches first.
+
+Tasks match when they contain every word of the query. Quote phrases to match
+words in sequence ("call mom"), end words with '*' to match words they start
+(plan*), and combine terms with OR and NOT (rent OR bills NOT paid).
+
+--query keeps the tasks matching a query, as in 'goot list --query'.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w, err := newOutput(cmd, false)
+			if err != nil {
+				return err
+			}
+			searchLimit := limit
+			if q != "" {
+				// Filter all results first, so that the limit applies to the
+				// filtered ones.
+				searchLimit = 0
+			}
+			results, err := s.SearchTasks(strings.Join(args, " "), searchLimit)
+			if err != nil {
+				return err
+			}
+			if q != "" {
+				matching, err := s.QueryTasks(q)
+				if err != nil {
+					return err
+				}
+				results = slices.DeleteFunc(results, func(res tasks.SearchResult) bool {
+					_, ok := matching.FindByID(res.Task.ID)
+					return !ok
+				})
+				if limit > 0 && len(results) > limit {
+					results = results[:limit]
+				}
+			}
+			if len(results) == 0 && w.Format().Name == output.Table {
+				w.Printf("No tasks found\n")
+				return nil
+			}
+			matches := make([]searchMatch, len(results))
+			for i, res := range results {
+				matches[i] = newSearchMatch(res)
+			}
+			return output.Write(w, searchColumns, matches)
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Maximum number of tasks shown, 0 for all")
+	cmd.Flags().StringVarP(&q, "query", "q", "", "Only show tasks matching a query, such as 'not completed' or @today")
+	return cmd
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"log/slog"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/server"
+	"github.com/zeerodex/goot/internal/services"
+)
+
+func NewServeCmd(s services.TaskService, cfg *config.Config, logger *slog.Logger) *cobra.Command {
+	var listen, token string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serves tasks over a local JSON REST API",
+		Long: `Serves tasks over a local JSON REST API on a loopback address or a unix socket.
+
+Requests on TCP must carry the token as 'Authorization: Bearer <token>'.
+The OpenAPI document is served at /v1/openapi.json.
+
+Calendar apps can subscribe to the tasks with due dates at /v1/calendar.ics,
+passing the token as ?token=<token>. Filter with ?tag=<tag> or ?api=<name>.`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return server.New(s, token, logger).ListenAndServe(cmd.Context(), listen)
+		},
+	}
+	cmd.Flags().StringVarP(&listen, "listen", "l", cfg.Server.Listen, "Loopback host:port or unix:<socket path> to listen on")
+	cmd.Flags().StringVar(&token, "token", cfg.Server.Token, "Bearer token required from clients (default from GOOT_SERVER_TOKEN)")
+	return cmd
+}
@@ -1,30 +1,75 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/output"
 	"github.com/zeerodex/goot/internal/services"
 	"github.com/zeerodex/goot/internal/tui/components"
+	"github.com/zeerodex/goot/internal/workers"
 )
 
+// syncedAPI is an API in the output of 'goot sync'.
+type syncedAPI struct {
+	API    string `json:"api"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+var syncedAPIColumns = []output.Column[syncedAPI]{
+	{Name: "api", Value: func(a syncedAPI) string { return a.API }},
+	{Name: "status", Value: func(a syncedAPI) string { return a.Status }},
+	{Name: "error", Value: func(a syncedAPI) string { return a.Error }, Flex: true},
+}
+
 func NewSyncCmd(s services.TaskService, apis map[string]bool) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Enables sync with google tasks api",
 		Args:  cobra.ExactArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			err := s.Sync()
+			w, err := newOutput(cmd, false)
+			if err != nil {
+				return err
+			}
+			// Every API is synced by its own job, so that its result is
+			// reported separately.
+			wp := s.WP()
+			jobs := make(map[int]string)
+			for _, api := range wp.APINames() {
+				job := workers.APIJob{ID: wp.NewJobID(), Operation: workers.SyncTasksOp, API: api}
+				if err := wp.Submit(cmd.Context(), job); err != nil {
+					return err
+				}
+				jobs[job.ID] = api
+			}
+			// Wait for the jobs, otherwise the worker pool is stopped on exit
+			// before the sync is done.
+			results, err := waitForSync(cmd.Context(), wp.Results(), jobs)
 			if err != nil {
 				return err
 			}
-			cmd.Println("Successfully synced APIs:")
-			for api, enabled := range apis {
-				if enabled {
-					cmd.Println(" - " + api)
+
+			var synced []syncedAPI
+			var errs []error
+			for _, res := range results {
+				a := syncedAPI{API: res.API, Status: "synced"}
+				if err := res.ParseErr(); err != nil {
+					a.Status, a.Error = "failed", res.Err.Error()
+					errs = append(errs, err)
 				}
+				synced = append(synced, a)
 			}
-			return nil
+			if err := output.Write(w, syncedAPIColumns, synced); err != nil {
+				return err
+			}
+			return errors.Join(errs...)
 		},
 	}
 
@@ -33,6 +78,28 @@ func NewSyncCmd(s services.TaskService, apis map[string]bool) *cobra.Command {
 	return cmd
 }
 
+// waitForSync returns the results of jobs, which maps job IDs to their API,
+// sorted by API. The results of other jobs, such as the sync on startup,
+// are dropped.
+func waitForSync(ctx context.Context, results <-chan workers.APIJobResult, jobs map[int]string) ([]workers.APIJobResult, error) {
+	var got []workers.APIJobResult
+	for len(got) < len(jobs) {
+		select {
+		case res, ok := <-results:
+			if !ok {
+				return nil, errors.New("worker pool stopped before the sync was done")
+			}
+			if _, ok := jobs[res.JobID]; ok {
+				got = append(got, res)
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	slices.SortFunc(got, func(a, b workers.APIJobResult) int { return strings.Compare(a.API, b.API) })
+	return got, nil
+}
+
 func NewChooseSyncAPIs(apis map[string]bool) *cobra.Command {
 	return &cobra.Command{
 		Use:   "choose",
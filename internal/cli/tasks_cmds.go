@@ -1,13 +1,16 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"maps"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/zeerodex/goot/internal/output"
+	"github.com/zeerodex/goot/internal/query"
 	"github.com/zeerodex/goot/internal/services"
 	"github.com/zeerodex/goot/internal/tasks"
 	"github.com/zeerodex/goot/internal/tui"
@@ -15,38 +18,211 @@ import (
 )
 
 func NewAllTasksCmd(s services.TaskService) *cobra.Command {
-	var jsonFormat bool
+	var jsonFormat, listViews bool
+	var q, sortKeys, groupBy string
+	var opts query.Options
 	cmd := &cobra.Command{
-		Use:   "list",
+		Use:   "list [@view | query...]",
 		Short: "List all tasks",
-		Args:  cobra.ExactArgs(0),
-		Run: func(cmd *cobra.Command, args []string) {
-			tasks, err := s.GetAllTasks()
+		Long: `Lists all tasks, or the tasks matching a query given as arguments or with
+--query. Conditions of a query are combined with and, or, not and parentheses:
+
+  goot list 'due<=tomorrow and not completed and tag:work or priority>=medium'
+
+Fields are due (compared to today, tomorrow, friday, 2026-11-01, +3d, now or
+none), priority (none, low, medium, high), tag:name, api:name, title:text,
+description:text, completed and recurring. Other words match the title or the
+description. Named views, such as @today, @overdue, @upcoming, @someday and
+@completed, are defined in the "views" section of the config file.
+
+--sort orders tasks by due, priority, created, modified or title, descending
+with a '-' prefix (--sort -priority,due). --group-by groups them by list (the
+APIs they are synced with), due (day), tag or completed, and --limit and
+--offset page every group.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w, err := newOutput(cmd, jsonFormat)
 			if err != nil {
-				cmd.Println(err)
-				return
+				return err
 			}
-			if jsonFormat {
-				b, err := json.MarshalIndent(&tasks, "", " ")
-				if err != nil {
-					cmd.Println(err)
+			if listViews {
+				views := s.Views()
+				var vs []view
+				for _, name := range query.ViewNames(views) {
+					vs = append(vs, view{Name: name, Query: views[name[1:]]})
 				}
-				os.Stdout.Write(b)
-				cmd.Println()
-			} else {
-				for _, task := range tasks {
-					cmd.Println(task.Task())
+				return output.Write(w, viewColumns, vs)
+			}
+
+			if opts.Sort, err = query.ParseSort(sortKeys); err != nil {
+				return err
+			}
+			if opts.GroupBy, err = query.ParseGroup(groupBy); err != nil {
+				return err
+			}
+			if opts.Limit < 0 || opts.Offset < 0 {
+				return fmt.Errorf("limit and offset cannot be negative")
+			}
+			groups, err := s.ListTasks(joinQuery(q, args), opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.GroupBy == "" {
+				// Ungrouped tasks are a plain list, as before grouping.
+				var g tasks.Group
+				if len(groups) > 0 {
+					g = groups[0]
+				}
+				if err := output.Write(w, taskColumns, g.Tasks); err != nil {
+					return err
+				}
+				if len(g.Tasks) < g.Count {
+					w.Printf("Showing %s tasks\n", pageCount(g, opts))
+				}
+				return nil
+			}
+
+			switch w.Format().Name {
+			case output.Table:
+				title := w.Renderer().NewStyle().Bold(true)
+				for i, g := range groups {
+					if i > 0 {
+						w.Printf("\n")
+					}
+					w.Printf("%s (%s)\n", title.Render(groupTitle(opts.GroupBy, g.Key)), pageCount(g, opts))
+					if err := output.Write(w, taskColumns, g.Tasks); err != nil {
+						return err
+					}
+				}
+				return nil
+			case output.JSON, output.YAML:
+				return output.Write(w, nil, groupsJSON(groups))
+			}
+			// Other formats are a row per task, led by its group.
+			var rows []groupedTask
+			for _, g := range groups {
+				for _, task := range g.Tasks {
+					rows = append(rows, groupedTask{Group: g.Key, Task: task})
 				}
 			}
+			return output.Write(w, groupedTaskColumns, rows)
 		},
 	}
 	cmd.Flags().BoolVarP(&jsonFormat, "json", "j", false, "Output in json format")
+	cmd.Flags().StringVarP(&q, "query", "q", "", "Only list tasks matching a query, such as 'due<=today and not completed'")
+	cmd.Flags().BoolVar(&listViews, "views", false, "List the named views instead of tasks")
+	cmd.Flags().StringVarP(&sortKeys, "sort", "s", "", "Sort keys separated by commas: due, priority, created, modified, title, '-' prefixed for descending order")
+	cmd.Flags().StringVarP(&groupBy, "group-by", "g", "", "Group tasks by list, due, tag or completed")
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", 0, "Maximum number of tasks listed, per group if grouped, 0 for all")
+	cmd.Flags().IntVar(&opts.Offset, "offset", 0, "Number of tasks skipped, per group if grouped")
 	return cmd
 }
 
+// groupTitle names the group of tasks with key, grouped by field.
+func groupTitle(field, key string) string {
+	switch field {
+	case "completed":
+		if key == "completed" {
+			return "Completed"
+		}
+		return "Pending"
+	case "due":
+		if key == "" {
+			return "No due date"
+		}
+		return key
+	case "tag":
+		if key == "" {
+			return "Untagged"
+		}
+		return "#" + key
+	case "list":
+		if key == "" {
+			return "Local only"
+		}
+		return key
+	}
+	return key
+}
+
+// pageCount describes which tasks of g the page of opts holds, such as
+// "3" or "1-20 of 57".
+func pageCount(g tasks.Group, opts query.Options) string {
+	if len(g.Tasks) == g.Count {
+		return strconv.Itoa(g.Count)
+	}
+	if len(g.Tasks) == 0 {
+		return fmt.Sprintf("none of %d", g.Count)
+	}
+	return fmt.Sprintf("%d-%d of %d", opts.Offset+1, opts.Offset+len(g.Tasks), g.Count)
+}
+
+// groupJSON is a group of tasks in 'goot list --group-by --json' output.
+type groupJSON struct {
+	Group string      `json:"group"`
+	Count int         `json:"count"`
+	Tasks tasks.Tasks `json:"tasks"`
+}
+
+// groupedTask is a task of a group in the output of 'goot list --group-by'
+// as rows of tasks, such as CSV.
+type groupedTask struct {
+	Group string `json:"group"`
+	tasks.Task
+}
+
+var groupedTaskColumns = func() []output.Column[groupedTask] {
+	columns := []output.Column[groupedTask]{{Name: "group", Value: func(t groupedTask) string { return t.Group }}}
+	for _, c := range taskColumns {
+		columns = append(columns, output.Column[groupedTask]{
+			Name:  c.Name,
+			Value: func(t groupedTask) string { return c.Value(t.Task) },
+			Flex:  c.Flex,
+		})
+	}
+	return columns
+}()
+
+// view is a named view in the output of 'goot list --views'.
+type view struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+var viewColumns = []output.Column[view]{
+	{Name: "name", Value: func(v view) string { return v.Name }},
+	{Name: "query", Value: func(v view) string { return v.Query }, Flex: true},
+}
+
+func groupsJSON(groups []tasks.Group) []groupJSON {
+	out := make([]groupJSON, len(groups))
+	for i, g := range groups {
+		out[i] = groupJSON{Group: g.Key, Count: g.Count, Tasks: g.Tasks}
+		if out[i].Tasks == nil {
+			out[i].Tasks = tasks.Tasks{}
+		}
+	}
+	return out
+}
+
+// joinQuery combines the query of a flag with the one given as arguments.
+func joinQuery(q string, args []string) string {
+	var parts []string
+	if strings.TrimSpace(q) != "" {
+		parts = append(parts, "("+q+")")
+	}
+	if len(args) > 0 {
+		parts = append(parts, "("+strings.Join(args, " ")+")")
+	}
+	return strings.Join(parts, " and ")
+}
+
 func NewCreateCmd(s services.TaskService) *cobra.Command {
 	var description string
 	var dueTimeStr string
+	var priorityStr string
+	var tags []string
 	cmd := &cobra.Command{
 		Use:   "add [title] [date (Today if none)]",
 		Short: "Creates a task",
@@ -55,6 +231,7 @@ func NewCreateCmd(s services.TaskService) *cobra.Command {
 			var task tasks.Task
 			task.Title = args[0]
 			task.Description = description
+			task.Tags = tags
 
 			var dueStr string
 			switch len(args) {
@@ -79,19 +256,68 @@ func NewCreateCmd(s services.TaskService) *cobra.Command {
 			}
 			task.Due = due
 
-			_, err = s.CreateTask(&task)
+			task.Priority, err = tasks.ParsePriority(priorityStr)
+			if err != nil {
+				cmd.Println(err)
+				return
+			}
+
+			w, err := newOutput(cmd, false)
+			if err != nil {
+				cmd.Println(err)
+				return
+			}
+			_, err = s.CreateTask(cmd.Context(), &task)
 			if err != nil {
 				cmd.Printf("Error creating task: %v", err)
 				return
 			}
-			cmd.Println(task.Task())
+			if err := output.WriteOne(w, taskDetailColumns, task); err != nil {
+				cmd.Println(err)
+				return
+			}
+			warnLossy(cmd, s, &task)
 		},
 	}
 	cmd.Flags().StringVarP(&dueTimeStr, "time", "t", "", "Due time (HH:MM)")
 	cmd.Flags().StringVarP(&description, "description", "d", "", "Description of the task")
+	cmd.Flags().StringVarP(&priorityStr, "priority", "p", "none", "Priority of the task (none, low, medium, high)")
+	cmd.Flags().StringSliceVar(&tags, "tag", nil, "Tags of the task, repeatable or comma-separated")
 	return cmd
 }
 
+// warnLossy tells the user which fields of task the enabled APIs cannot
+// store and will not round-trip.
+func warnLossy(cmd *cobra.Command, s services.TaskService, task *tasks.Task) {
+	lossy := s.LossyFields(task)
+	for _, api := range slices.Sorted(maps.Keys(lossy)) {
+		cmd.PrintErrf("Warning: %s cannot store the %s of this task\n", api, strings.Join(lossy[api], ", "))
+	}
+}
+
+func NewShowTaskCmd(s services.TaskService) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <task id>",
+		Short: "Shows a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("incorrect task id: %w", err)
+			}
+			w, err := newOutput(cmd, false)
+			if err != nil {
+				return err
+			}
+			task, err := s.GetTaskByID(id)
+			if err != nil {
+				return err
+			}
+			return output.WriteOne(w, taskDetailColumns, *task)
+		},
+	}
+}
+
 func NewDeleteTaskCmd(s services.TaskService) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rm [task id]",
@@ -119,7 +345,7 @@ func NewDeleteTaskCmd(s services.TaskService) *cobra.Command {
 					return
 				}
 			}
-			err := s.DeleteTaskByID(id)
+			err := s.DeleteTaskByID(cmd.Context(), id)
 			if err != nil {
 				cmd.Println(err)
 				return
@@ -154,7 +380,7 @@ func NewDoneTaskCmd(s services.TaskService) *cobra.Command {
 					return fmt.Errorf("incorrect task id: %w", err)
 				}
 			}
-			err := s.SetTaskCompleted(id, true)
+			err := s.SetTaskCompleted(cmd.Context(), id, true)
 			if err != nil {
 				return fmt.Errorf("failed to mark task completed: %w", err)
 			}
@@ -0,0 +1,353 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zeerodex/goot/internal/calendar"
+	"github.com/zeerodex/goot/internal/interchange"
+	"github.com/zeerodex/goot/internal/services"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/internal/taskwarrior"
+	"github.com/zeerodex/goot/internal/todotxt"
+)
+
+// taskFormat is a file format tasks can be imported from and exported to.
+type taskFormat struct {
+	// decode is nil for formats that can only be exported, or that are
+	// decoded by decodeMapped.
+	decode func(io.Reader) (tasks.Tasks, error)
+	// decodeMapped decodes formats whose fields can be renamed by --map.
+	decodeMapped func(r io.Reader, fields map[string]string) (tasks.Tasks, error)
+	encode       func(io.Writer, tasks.Tasks) error
+	// exts are the file extensions the format is guessed from.
+	exts []string
+	// id returns the ID a task has in the format. Imported tasks with the ID
+	// of a stored task update it instead of being created again. Nil if the
+	// format has no IDs.
+	id func(tasks.Task) string
+}
+
+var taskFormats = map[string]taskFormat{
+	"ics":         {encode: calendar.Encoder(calendar.Options{Events: true, Name: "goot"}), exts: []string{".ics"}},
+	"csv":         {decodeMapped: interchange.DecodeCSV, encode: interchange.EncodeCSV, exts: []string{".csv"}},
+	"ics-todo":    {encode: calendar.Encoder(calendar.Options{Todos: true, Name: "goot"})},
+	"json":        {decodeMapped: interchange.DecodeJSON, encode: interchange.EncodeJSON, exts: []string{".json"}},
+	"yaml":        {decodeMapped: interchange.DecodeYAML, encode: interchange.EncodeYAML, exts: []string{".yaml", ".yml"}},
+	"taskwarrior": {decode: taskwarrior.Decode, encode: taskwarrior.Encode, id: taskwarrior.UUID},
+	"todotxt":     {decode: todotxt.Decode, encode: todotxt.Encode, exts: []string{".txt"}},
+}
+
+func formatNames() string {
+	return strings.Join(slices.Sorted(maps.Keys(taskFormats)), ", ")
+}
+
+// lookupFormat returns the format called name, or the one of the file
+// extension of path if name is empty.
+func lookupFormat(name, path string) (taskFormat, error) {
+	if name == "" {
+		ext := strings.ToLower(filepath.Ext(path))
+		for _, n := range slices.Sorted(maps.Keys(taskFormats)) {
+			if slices.Contains(taskFormats[n].exts, ext) {
+				return taskFormats[n], nil
+			}
+		}
+		return taskFormat{}, fmt.Errorf("cannot guess the format of '%s', use --format (%s)", path, formatNames())
+	}
+	f, ok := taskFormats[name]
+	if !ok {
+		return taskFormat{}, fmt.Errorf("unknown format '%s' (%s)", name, formatNames())
+	}
+	return f, nil
+}
+
+// fileArg returns the file named by args, or "" for stdin and stdout.
+func fileArg(args []string) string {
+	if len(args) == 1 && args[0] != "-" {
+		return args[0]
+	}
+	return ""
+}
+
+// addFormatCmds adds a "<format> [file]" subcommand to cmd for every format,
+// so that "goot import taskwarrior" works like "goot import -f taskwarrior".
+func addFormatCmds(cmd *cobra.Command, short string, importing bool, run func(cmd *cobra.Command, f taskFormat, path string) error) {
+	for _, name := range slices.Sorted(maps.Keys(taskFormats)) {
+		f := taskFormats[name]
+		if importing && f.decode == nil && f.decodeMapped == nil {
+			continue
+		}
+		cmd.AddCommand(&cobra.Command{
+			Use:   name + " [file]",
+			Short: fmt.Sprintf(short, name),
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return run(cmd, f, fileArg(args))
+			},
+		})
+	}
+}
+
+func NewExportCmd(s services.TaskService) *cobra.Command {
+	var format string
+	export := func(cmd *cobra.Command, f taskFormat, path string) error {
+		ts, err := s.GetAllTasks()
+		if err != nil {
+			return err
+		}
+
+		if path == "" {
+			return f.encode(cmd.OutOrStdout(), ts)
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		if err := f.encode(file, ts); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write export file: %w", err)
+		}
+		cmd.Printf("Exported %d tasks to %s\n", len(ts), path)
+		return nil
+	}
+
+	cmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Exports all tasks to a file, or to stdout",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := fileArg(args)
+			f, err := lookupFormat(format, path)
+			if err != nil {
+				return err
+			}
+			return export(cmd, f, path)
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "", "Format of the export ("+formatNames()+"), guessed from the file name if unset")
+	addFormatCmds(cmd, "Exports all tasks in %s format to a file, or to stdout", false, export)
+	return cmd
+}
+
+// importOptions are the flags of the import commands.
+type importOptions struct {
+	// fields renames the fields of the imported file, for formats with
+	// decodeMapped.
+	fields map[string]string
+	// duplicates are the ways imported tasks are matched against stored
+	// ones: "remote-id", "title-due" or "none".
+	duplicates []string
+	dryRun     bool
+}
+
+func NewImportCmd(s services.TaskService) *cobra.Command {
+	var format string
+	var opts importOptions
+	cmd := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Creates the tasks of a file, or of stdin",
+		Long: `Creates the tasks of a file, or of stdin.
+
+Tasks of formats with IDs, such as Taskwarrior UUIDs, update the tasks they
+were imported as before. Other tasks are skipped if they duplicate a stored
+task, by default if they share an ID in a synced API or the title and due
+date. The fields of json, yaml and csv files are renamed with --map, e.g.
+--map "Task Name=title,Deadline=due".`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := fileArg(args)
+			f, err := lookupFormat(format, path)
+			if err != nil {
+				return err
+			}
+			return importTasks(cmd, s, f, path, opts)
+		},
+	}
+	flags := cmd.PersistentFlags()
+	cmd.Flags().StringVarP(&format, "format", "f", "", "Format of the import ("+formatNames()+"), guessed from the file name if unset")
+	flags.StringToStringVar(&opts.fields, "map", nil, "Renames fields of the file to goot fields (file field=goot field)")
+	flags.StringSliceVar(&opts.duplicates, "duplicates", []string{"remote-id", "title-due"}, "How duplicates of stored tasks are detected (remote-id, title-due, none)")
+	flags.BoolVarP(&opts.dryRun, "dry-run", "n", false, "Only print what would be imported")
+	addFormatCmds(cmd, "Creates or updates the tasks of a %s file, or of stdin", true, func(cmd *cobra.Command, f taskFormat, path string) error {
+		return importTasks(cmd, s, f, path, opts)
+	})
+	return cmd
+}
+
+// duplicateIndex finds the stored tasks imported tasks duplicate.
+type duplicateIndex struct {
+	byRemoteID map[string]*tasks.Task
+	byTitleDue map[string]*tasks.Task
+}
+
+func newDuplicateIndex(stored tasks.Tasks, by []string) (*duplicateIndex, error) {
+	idx := &duplicateIndex{}
+	for _, b := range by {
+		switch b {
+		case "remote-id":
+			idx.byRemoteID = make(map[string]*tasks.Task)
+		case "title-due":
+			idx.byTitleDue = make(map[string]*tasks.Task)
+		case "none":
+		default:
+			return nil, fmt.Errorf("unknown duplicate detection '%s' (remote-id, title-due, none)", b)
+		}
+	}
+	for i := range stored {
+		idx.add(&stored[i])
+	}
+	return idx, nil
+}
+
+func remoteIDKey(api, id string) string { return api + "\x00" + id }
+
+func titleDueKey(t *tasks.Task) string {
+	return strings.ToLower(strings.TrimSpace(t.Title)) + "\x00" + t.Due.UTC().Format(time.RFC3339)
+}
+
+func (idx *duplicateIndex) add(t *tasks.Task) {
+	if idx.byRemoteID != nil {
+		for api, id := range interchange.RemoteIDs(t) {
+			idx.byRemoteID[remoteIDKey(api, id)] = t
+		}
+	}
+	if idx.byTitleDue != nil {
+		idx.byTitleDue[titleDueKey(t)] = t
+	}
+}
+
+// find returns the stored task t duplicates, or nil.
+func (idx *duplicateIndex) find(t *tasks.Task) *tasks.Task {
+	if idx.byRemoteID != nil {
+		for api, id := range interchange.RemoteIDs(t) {
+			if dup, ok := idx.byRemoteID[remoteIDKey(api, id)]; ok {
+				return dup
+			}
+		}
+	}
+	if idx.byTitleDue != nil {
+		return idx.byTitleDue[titleDueKey(t)]
+	}
+	return nil
+}
+
+func importTasks(cmd *cobra.Command, s services.TaskService, f taskFormat, path string, opts importOptions) error {
+	decode := f.decode
+	switch {
+	case f.decodeMapped != nil:
+		decode = func(r io.Reader) (tasks.Tasks, error) { return f.decodeMapped(r, opts.fields) }
+	case decode == nil:
+		return errors.New("tasks cannot be imported from this format")
+	case len(opts.fields) > 0:
+		return errors.New("--map is not supported by this format")
+	}
+
+	r := cmd.InOrStdin()
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+	ts, err := decode(r)
+	if err != nil {
+		return err
+	}
+
+	all, err := s.GetAllTasks()
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]*tasks.Task)
+	if f.id != nil {
+		for i := range all {
+			stored[f.id(all[i])] = &all[i]
+		}
+	}
+	dups, err := newDuplicateIndex(all, opts.duplicates)
+	if err != nil {
+		return err
+	}
+
+	var created, updated, skipped int
+	for i := range ts {
+		task := &ts[i]
+		if f.id != nil {
+			if old, ok := stored[f.id(*task)]; ok {
+				if opts.dryRun {
+					if changed(old, task) {
+						cmd.Printf("Would update '%s'\n", task.Title)
+						updated++
+					}
+					continue
+				}
+				ok, err := updateImported(cmd, s, old, task)
+				if err != nil {
+					cmd.PrintErrf("Skipping task '%s': %v\n", task.Title, err)
+				} else if ok {
+					updated++
+				}
+				continue
+			}
+		}
+		if task.Deleted {
+			continue
+		}
+		if dup := dups.find(task); dup != nil {
+			cmd.PrintErrf("Skipping task '%s': duplicate of task %d\n", task.Title, dup.ID)
+			skipped++
+			continue
+		}
+		if opts.dryRun {
+			cmd.Printf("Would create '%s'\n", task.Title)
+		} else if _, err := s.CreateTask(cmd.Context(), task); err != nil {
+			cmd.PrintErrf("Skipping task '%s': %v\n", task.Title, err)
+			continue
+		}
+		// Duplicates within the file are skipped too.
+		dups.add(task)
+		created++
+	}
+	if opts.dryRun {
+		cmd.Printf("Would import %d new and update %d of %d tasks, skipping %d duplicates\n", created, updated, len(ts), skipped)
+	} else {
+		cmd.Printf("Imported %d new and updated %d of %d tasks, skipped %d duplicates\n", created, updated, len(ts), skipped)
+	}
+	return nil
+}
+
+// changed reports whether the imported task differs from the stored task old.
+func changed(old, task *tasks.Task) bool {
+	return task.Deleted || task.Title != old.Title || task.Description != old.Description || !task.Due.Equal(old.Due) ||
+		task.Priority != old.Priority || task.Recurrence != old.Recurrence ||
+		!slices.Equal(task.Tags, old.Tags) || task.Completed != old.Completed
+}
+
+// updateImported applies the imported version of the stored task old. It
+// reports whether old changed.
+func updateImported(cmd *cobra.Command, s services.TaskService, old, task *tasks.Task) (bool, error) {
+	if !changed(old, task) {
+		return false, nil
+	}
+	if task.Deleted {
+		return true, s.DeleteTaskByID(cmd.Context(), old.ID)
+	}
+	task.ID = old.ID
+	task.Notified = old.Notified && task.Due.Equal(old.Due)
+	_, err := s.UpdateTask(cmd.Context(), task)
+	return true, err
+}
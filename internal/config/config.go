@@ -2,23 +2,137 @@ package config
 
 import (
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	APIs   map[string]bool `mapstructure:"apis"`
-	Google struct {
-		Sync   bool   `mapstructure:"sync"`
-		ListId string `mapstructure:"list-id"`
-	} `mapstructure:"google"`
-	SyncOnStartup bool `mapstructure:"sync-on-startup"`
+	APIs          map[string]bool `mapstructure:"apis"`
+	SyncOnStartup bool            `mapstructure:"sync-on-startup"`
+
+	// Timeouts bound every single call to an API, keyed like APIs.
+	Timeouts map[string]time.Duration `mapstructure:"timeouts"`
+	// RateLimits override the known request quotas of APIs, keyed like APIs.
+	RateLimits map[string]RateLimit `mapstructure:"rate-limits"`
 
 	MaxLength struct {
 		Title       int `mapstructure:"title"`
 		Description int `mapstructure:"description"`
 	} `mapstructure:"max-length"`
+
+	Daemon struct {
+		PollInterval time.Duration `mapstructure:"poll-interval"`
+		TimeWindow   time.Duration `mapstructure:"time-window"`
+
+		// SyncInterval of 0 disables periodic sync.
+		SyncInterval   time.Duration `mapstructure:"sync-interval"`
+		MaxSyncBackoff time.Duration `mapstructure:"max-sync-backoff"`
+		// OnlineCheckAddr is a host:port dialed before syncing, so that
+		// syncs are postponed while it cannot be reached. Empty disables
+		// the check.
+		OnlineCheckAddr string `mapstructure:"online-check-addr"`
+	} `mapstructure:"daemon"`
+
+	Workers struct {
+		Count     int `mapstructure:"count"`
+		QueueSize int `mapstructure:"queue-size"`
+	} `mapstructure:"workers"`
+
+	Log Log `mapstructure:"log"`
+
+	Metrics struct {
+		// Listen is the address of the daemon /metrics endpoint, disabled if empty.
+		Listen string `mapstructure:"listen"`
+	} `mapstructure:"metrics"`
+
+	Server struct {
+		// Listen is a loopback host:port or "unix:" followed by a socket path.
+		Listen string `mapstructure:"listen"`
+		Token  string `mapstructure:"token"`
+	} `mapstructure:"server"`
+
+	Hooks []Hook `mapstructure:"hooks"`
+
+	// Views are named task queries, such as "today": "due<=today and not
+	// completed", listed with 'goot list @today'.
+	Views map[string]string `mapstructure:"views"`
+
+	// Providers holds the remaining sections, the settings of the API
+	// providers keyed by their config key (e.g. "google").
+	Providers map[string]any `mapstructure:",remain"`
+}
+
+// Hook runs Command or posts to Webhook whenever one of Events is published.
+// The event is passed as JSON on stdin or as the request body.
+type Hook struct {
+	// Events are event types such as "task.completed", all if empty.
+	Events []string `mapstructure:"events"`
+	// Format is "taskwarrior" to pass the task of the event the way
+	// Taskwarrior passes it to on-add and on-modify hooks instead.
+	Format  string            `mapstructure:"format"`
+	Command []string          `mapstructure:"command"`
+	Webhook string            `mapstructure:"webhook"`
+	Headers map[string]string `mapstructure:"headers"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+}
+
+type RateLimit struct {
+	// Rate is the average number of requests per second, 0 disables limiting.
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+type Log struct {
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"`
+	// File is stderr if empty.
+	File string `mapstructure:"file"`
+	// MaxSize in megabytes after which File is rotated, 0 disables rotation.
+	MaxSize    int `mapstructure:"max-size"`
+	MaxBackups int `mapstructure:"max-backups"`
+}
+
+// APITimeout returns the call timeout of api, or def if none is configured.
+func (c *Config) APITimeout(api string, def time.Duration) time.Duration {
+	if t, ok := c.Timeouts[api]; ok {
+		return t
+	}
+	return def
+}
+
+// DecodeProvider decodes the settings section of the provider key into v,
+// leaving v unchanged if there is none.
+func (c *Config) DecodeProvider(key string, v any) error {
+	section, ok := c.Providers[key]
+	if !ok {
+		return nil
+	}
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeDurationHookFunc(),
+		Result:     v,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(section); err != nil {
+		return fmt.Errorf("invalid '%s' settings: %w", key, err)
+	}
+	return nil
+}
+
+// APIRateLimit returns the rate limit of api, or def if none is configured.
+func (c *Config) APIRateLimit(api string, def RateLimit) RateLimit {
+	if rl, ok := c.RateLimits[api]; ok {
+		return rl
+	}
+	return def
 }
 
 func LoadConfig(cfgPath string) (*Config, error) {
@@ -26,22 +140,218 @@ func LoadConfig(cfgPath string) (*Config, error) {
 		return nil, fmt.Errorf("error loading .env file: %w", err)
 	}
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("json")
-	viper.AddConfigPath(cfgPath)
+	setup(viper.GetViper(), cfgPath)
+	return readConfig(viper.GetViper())
+}
 
-	if err := viper.ReadInConfig(); err != nil {
+// Read loads the config.json file of cfgPath without touching the global
+// config used by LoadConfig and ReloadConfig, nor loading a .env file, so
+// that several configs can be used by the same process.
+func Read(cfgPath string) (*Config, error) {
+	v := viper.New()
+	setup(v, cfgPath)
+	return readConfig(v)
+}
+
+func setup(v *viper.Viper, cfgPath string) {
+	v.SetConfigName("config")
+	v.SetConfigType("json")
+	v.AddConfigPath(cfgPath)
+
+	v.SetDefault("daemon.poll-interval", time.Minute)
+	v.SetDefault("daemon.time-window", time.Minute)
+	v.SetDefault("daemon.sync-interval", 15*time.Minute)
+	v.SetDefault("daemon.max-sync-backoff", time.Hour)
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "text")
+	v.SetDefault("log.max-size", 10)
+	v.SetDefault("log.max-backups", 3)
+	v.SetDefault("server.listen", "127.0.0.1:8765")
+	v.BindEnv("server.token", "GOOT_SERVER_TOKEN")
+	v.SetDefault("workers.count", 3)
+	v.SetDefault("workers.queue-size", 5)
+}
+
+// ReloadConfig re-reads the config file found by a previous LoadConfig call.
+func ReloadConfig() (*Config, error) {
+	return readConfig(viper.GetViper())
+}
+
+func readConfig(v *viper.Viper) (*Config, error) {
+	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("error reading config file: %v", err)
 	}
 
 	cfg := &Config{}
-	if err := viper.Unmarshal(cfg); err != nil {
+	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("error unmarshalling config into struct: %v", err)
 	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	return cfg, nil
 }
 
+// validate rejects the settings the daemon and the worker pool cannot run
+// with, such as a zero poll interval.
+func (c *Config) validate() error {
+	if c.Daemon.PollInterval <= 0 {
+		return fmt.Errorf("daemon.poll-interval must be positive, got %s", c.Daemon.PollInterval)
+	}
+	if c.Daemon.MaxSyncBackoff <= 0 {
+		return fmt.Errorf("daemon.max-sync-backoff must be positive, got %s", c.Daemon.MaxSyncBackoff)
+	}
+	if c.Daemon.TimeWindow < 0 {
+		return fmt.Errorf("daemon.time-window must not be negative, got %s", c.Daemon.TimeWindow)
+	}
+	if c.Daemon.SyncInterval < 0 {
+		return fmt.Errorf("daemon.sync-interval must not be negative, got %s", c.Daemon.SyncInterval)
+	}
+	if c.Workers.Count <= 0 {
+		return fmt.Errorf("workers.count must be positive, got %d", c.Workers.Count)
+	}
+	if c.Workers.QueueSize < 0 {
+		return fmt.Errorf("workers.queue-size must not be negative, got %d", c.Workers.QueueSize)
+	}
+	for _, api := range slices.Sorted(maps.Keys(c.Timeouts)) {
+		if c.Timeouts[api] <= 0 {
+			return fmt.Errorf("timeouts.%s must be positive, got %s", api, c.Timeouts[api])
+		}
+	}
+	for i, hook := range c.Hooks {
+		if hook.Timeout < 0 {
+			return fmt.Errorf("hooks[%d].timeout must not be negative, got %s", i, hook.Timeout)
+		}
+	}
+	return nil
+}
+
+// File returns the path of the config file in use.
+func File() string {
+	return viper.ConfigFileUsed()
+}
+
+// Diff returns a human readable line for every setting that differs between
+// old and new, keyed by its config file path (e.g. "apis.todoist: true -> false").
+// The values of secrets, such as tokens and hook headers, are not shown.
+func Diff(old, new *Config) []string {
+	var changes []string
+	diffValues("", reflect.ValueOf(*old), reflect.ValueOf(*new), &changes)
+	return changes
+}
+
+// secretKeys are the names of the settings whose values Diff hides, in any
+// section.
+var secretKeys = []string{"token", "password", "secret", "client-secret", "headers"}
+
+func isSecret(key string) bool {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	name, _, _ = strings.Cut(name, "[")
+	return slices.Contains(secretKeys, name) ||
+		strings.HasSuffix(name, "-token") || strings.HasSuffix(name, "-password") || strings.HasSuffix(name, "-secret")
+}
+
+func diffValues(prefix string, a, b reflect.Value, changes *[]string) {
+	a, b = unwrap(a), unwrap(b)
+	if isSecret(prefix) {
+		if !equalValues(a, b) {
+			*changes = append(*changes, prefix+": changed")
+		}
+		return
+	}
+	// Compare a section added or removed setting by setting, so that its
+	// secrets stay hidden.
+	if !a.IsValid() && b.IsValid() && isContainer(b) {
+		a = reflect.Zero(b.Type())
+	} else if a.IsValid() && !b.IsValid() && isContainer(a) {
+		b = reflect.Zero(a.Type())
+	}
+
+	if a.IsValid() && b.IsValid() && a.Type() == b.Type() {
+		switch a.Kind() {
+		case reflect.Struct:
+			if a.Type() == reflect.TypeOf(time.Time{}) {
+				break
+			}
+			for i := range a.NumField() {
+				// Squashed and remaining settings have no key of their own.
+				key, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("mapstructure"), ",")
+				diffValues(joinKey(prefix, key), a.Field(i), b.Field(i), changes)
+			}
+			return
+		case reflect.Map:
+			keys := make(map[string]bool)
+			for _, k := range a.MapKeys() {
+				keys[k.String()] = true
+			}
+			for _, k := range b.MapKeys() {
+				keys[k.String()] = true
+			}
+			for _, k := range slices.Sorted(maps.Keys(keys)) {
+				kv := reflect.ValueOf(k).Convert(a.Type().Key())
+				diffValues(joinKey(prefix, k), a.MapIndex(kv), b.MapIndex(kv), changes)
+			}
+			return
+		case reflect.Slice:
+			if a.Type().Elem().Kind() != reflect.Struct {
+				break
+			}
+			for i := range max(a.Len(), b.Len()) {
+				var av, bv reflect.Value
+				if i < a.Len() {
+					av = a.Index(i)
+				}
+				if i < b.Len() {
+					bv = b.Index(i)
+				}
+				diffValues(fmt.Sprintf("%s[%d]", prefix, i), av, bv, changes)
+			}
+			return
+		}
+	}
+
+	if !equalValues(a, b) {
+		*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", prefix, formatValue(a), formatValue(b)))
+	}
+}
+
+// unwrap returns the value held by the interface v, invalid if nil.
+func unwrap(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isContainer(v reflect.Value) bool {
+	return v.Kind() == reflect.Map || v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{})
+}
+
+func equalValues(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" || key == "" {
+		return prefix + key
+	}
+	return prefix + "." + key
+}
+
+func formatValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<unset>"
+	}
+	return fmt.Sprint(v.Interface())
+}
+
 func SetGoogleSync(v bool) {
 	viper.Set("google.sync", v)
 	viper.WriteConfig()
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	old := &Config{APIs: map[string]bool{"google": true, "todoist": true}}
+	old.Daemon.PollInterval = time.Minute
+	old.Workers.Count = 3
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		want   []string
+	}{
+		{"Unchanged", func(cfg *Config) {}, nil},
+		{"Poll interval", func(cfg *Config) { cfg.Daemon.PollInterval = 30 * time.Second }, []string{"daemon.poll-interval: 1m0s -> 30s"}},
+		{"Disabled API", func(cfg *Config) { cfg.APIs["todoist"] = false }, []string{"apis.todoist: true -> false"}},
+		{"Added API", func(cfg *Config) { cfg.APIs["ticktick"] = true }, []string{"apis.ticktick: <unset> -> true"}},
+		{"Several", func(cfg *Config) {
+			cfg.Workers.Count = 5
+			cfg.SyncOnStartup = true
+		}, []string{"sync-on-startup: false -> true", "workers.count: 3 -> 5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			new := *old
+			new.APIs = map[string]bool{"google": true, "todoist": true}
+			tt.modify(&new)
+
+			got := Diff(old, &new)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffHidesSecrets(t *testing.T) {
+	old := &Config{Hooks: []Hook{{Webhook: "https://example.com", Headers: map[string]string{"Authorization": "Bearer old"}}}}
+	old.Server.Token = "old-token"
+
+	new := &Config{
+		Hooks: []Hook{{Webhook: "https://example.com", Headers: map[string]string{"Authorization": "Bearer new"}}},
+		Providers: map[string]any{
+			"caldav": map[string]any{"url": "https://dav.example.com", "password": "hunter2"},
+		},
+	}
+	new.Server.Token = "new-token"
+
+	want := []string{
+		"server.token: changed",
+		"hooks[0].headers: changed",
+		"caldav.password: changed",
+		"caldav.url: <unset> -> https://dav.example.com",
+	}
+	got := Diff(old, new)
+	if !slices.Equal(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+	for _, change := range got {
+		for _, secret := range []string{"old-token", "new-token", "Bearer", "hunter2"} {
+			if strings.Contains(change, secret) {
+				t.Errorf("Diff() leaked %q in %q", secret, change)
+			}
+		}
+	}
+}
+
+func TestReadRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{"Defaults", `{}`, ""},
+		{"Sync disabled", `{"daemon": {"sync-interval": "0s"}}`, ""},
+		{"Zero poll interval", `{"daemon": {"poll-interval": "0s"}}`, "daemon.poll-interval"},
+		{"Negative time window", `{"daemon": {"time-window": "-1m"}}`, "daemon.time-window"},
+		{"Negative sync interval", `{"daemon": {"sync-interval": "-1m"}}`, "daemon.sync-interval"},
+		{"Zero max backoff", `{"daemon": {"max-sync-backoff": "0s"}}`, "daemon.max-sync-backoff"},
+		{"No workers", `{"workers": {"count": 0}}`, "workers.count"},
+		{"Zero API timeout", `{"timeouts": {"google": "0s"}}`, "timeouts.google"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Read(dir)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Read() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,44 +3,96 @@ package daemon
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
 	"github.com/zeerodex/goot/internal/services"
 	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/internal/workers"
 )
 
 type TaskProcessor struct {
 	s            services.TaskService
 	timeWindow   time.Duration
 	pollInterval time.Duration
+
+	mu           sync.RWMutex
+	reconfigured chan struct{}
+	lastTick     atomic.Int64
+
+	log *slog.Logger
+}
+
+func NewTaskProcessor(s services.TaskService, timeWindow, pollInterval time.Duration, logger *slog.Logger) *TaskProcessor {
+	return &TaskProcessor{
+		s:            s,
+		timeWindow:   timeWindow,
+		pollInterval: pollInterval,
+		reconfigured: make(chan struct{}, 1),
+		log:          logger.With("component", "processor"),
+	}
+}
+
+// Reconfigure changes the time window and poll interval of a running processor.
+func (tp *TaskProcessor) Reconfigure(timeWindow, pollInterval time.Duration) {
+	tp.mu.Lock()
+	tp.timeWindow = timeWindow
+	tp.pollInterval = pollInterval
+	tp.mu.Unlock()
+
+	select {
+	case tp.reconfigured <- struct{}{}:
+	default:
+	}
+}
+
+func (tp *TaskProcessor) intervals() (timeWindow, pollInterval time.Duration) {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	return tp.timeWindow, tp.pollInterval
 }
 
-func NewTaskProcessor(s services.TaskService, timeWindow, pollInterval time.Duration) *TaskProcessor {
-	return &TaskProcessor{s: s, timeWindow: timeWindow, pollInterval: pollInterval}
+// Alive reports whether the processor loop has ticked recently.
+func (tp *TaskProcessor) Alive() bool {
+	_, pollInterval := tp.intervals()
+	return time.Since(time.Unix(0, tp.lastTick.Load())) <= 2*pollInterval
 }
 
 func (tp *TaskProcessor) Start(ctx context.Context) {
-	ticker := time.NewTicker(tp.pollInterval)
+	timeWindow, pollInterval := tp.intervals()
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
-	log.Printf("[INFO] Task processor started\npollInterval: %s\ntimeWindow:%s", tp.pollInterval, tp.timeWindow)
+	tp.lastTick.Store(time.Now().UnixNano())
+
+	tp.log.Info("task processor started", "poll_interval", pollInterval, "time_window", timeWindow)
 
 	for {
 		select {
 		case <-ticker.C:
-			log.Println("[DEBUG] Tick")
+			tp.lastTick.Store(time.Now().UnixNano())
+			tp.log.Debug("tick")
 			tasks, err := tp.FetchTasks()
 			if err != nil {
-				log.Printf("[ERROR] Failed to fetch tasks: %v", err)
+				tp.log.Error("failed to fetch tasks", "err", err)
 			}
 			if err = tp.ProcessTasks(tasks); err != nil {
-				log.Printf("[ERROR] Failed to process tasks: %v", err)
+				tp.log.Error("failed to process tasks", "err", err)
 			}
+		case <-tp.reconfigured:
+			timeWindow, pollInterval = tp.intervals()
+			ticker.Reset(pollInterval)
+			tp.log.Info("task processor reconfigured", "poll_interval", pollInterval, "time_window", timeWindow)
 		case <-ctx.Done():
 			return
 		}
@@ -49,7 +101,7 @@ func (tp *TaskProcessor) Start(ctx context.Context) {
 
 func (tp *TaskProcessor) ProcessTasks(tasks tasks.Tasks) error {
 	if len(tasks) < 1 {
-		log.Println("[DEBUG] No pending tasks")
+		tp.log.Debug("no pending tasks")
 		return nil
 	}
 	now := time.Now()
@@ -58,10 +110,11 @@ func (tp *TaskProcessor) ProcessTasks(tasks tasks.Tasks) error {
 		timeDiff := now.Sub(task.Due)
 		if timeDiff >= 0 && timeDiff <= time.Minute && !task.Notified {
 			go tp.SendTaskDueNofitication(task)
+			tp.s.Events().Publish(events.Event{Type: events.TaskOverdue, Task: &task})
 			if err := tp.s.MarkAsNotified(task.ID); err != nil {
 				return fmt.Errorf("error marking task ID %d as notified: %w", task.ID, err)
 			}
-			log.Printf("[INFO] Task ID %d has been processed", task.ID)
+			tp.log.Info("task processed", "task_id", task.ID)
 		}
 	}
 	return nil
@@ -71,8 +124,9 @@ func (tp *TaskProcessor) FetchTasks() (tasks.Tasks, error) {
 	now := time.Now()
 	now = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), 0, 0, now.Location())
 
-	minTime := now.Add(-tp.timeWindow)
-	maxTime := now.Add(tp.timeWindow)
+	timeWindow, _ := tp.intervals()
+	minTime := now.Add(-timeWindow)
+	maxTime := now.Add(timeWindow)
 
 	tasks, err := tp.s.GetAllPendingTasks(minTime, maxTime)
 	if err != nil {
@@ -82,29 +136,123 @@ func (tp *TaskProcessor) FetchTasks() (tasks.Tasks, error) {
 	return tasks, nil
 }
 
-func (tp TaskProcessor) SendTaskDueNofitication(task tasks.Task) {
+func (tp *TaskProcessor) SendTaskDueNofitication(task tasks.Task) {
 	icon := "task-due-symbolic"
 	cmd := exec.Command("notify-send", "Task due!", task.Title, "-i", icon)
 	err := cmd.Run()
 	if err != nil {
-		log.Printf("[ERROR] Failed to send notification for task ID %d: %v", task.ID, err)
+		tp.log.Error("failed to send notification", "task_id", task.ID, "err", err)
+		metrics.Notifications.WithLabelValues("failed").Inc()
 		return
 	}
-	log.Printf("[INFO] Notification sent for task ID %d", task.ID)
+	tp.log.Info("notification sent", "task_id", task.ID)
+	metrics.Notifications.WithLabelValues("sent").Inc()
 }
 
-func StartDaemon(s services.TaskService) {
-	tp := NewTaskProcessor(s, time.Minute, time.Minute)
+// StartDaemon runs the daemon until ctx is done or SIGINT or SIGTERM is
+// received. SIGHUP reloads the config.
+func StartDaemon(ctx context.Context, s services.TaskService, cfg *config.Config, logger *slog.Logger) {
+	tp := NewTaskProcessor(s, cfg.Daemon.TimeWindow, cfg.Daemon.PollInterval, logger)
+	ss := NewSyncScheduler(s, cfg.Daemon.SyncInterval, cfg.Daemon.MaxSyncBackoff, cfg.Daemon.OnlineCheckAddr, logger)
+	log := logger.With("component", "daemon")
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	if err := writePIDFile(); err != nil {
+		log.Warn("failed to write pid file, 'goot daemon reload' will not work", "err", err)
+	}
+	defer removePIDFile()
+	defer removeStatusFile()
+
 	sigs := make(chan os.Signal, 1)
-	// TODO: SIGHUP
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 	go tp.Start(ctx)
+	go ss.Start(ctx)
+	go handleResults(log, s, ss)
+	go runWatchdog(ctx, log, tp.Alive)
+	stopWatchers := startWatchers(ctx, log, s, ss)
+
+	if cfg.Metrics.Listen != "" {
+		metrics.RegisterQueueDepth(s.WP().QueueLen)
+		go metrics.Serve(ctx, cfg.Metrics.Listen, log)
+	}
 
-	log.Printf("[INFO] Signal received: %s, shutting down...", <-sigs)
+	if err := sdNotify("READY=1"); err != nil {
+		log.Warn("failed to notify systemd about readiness", "err", err)
+	}
+
+loop:
+	for {
+		select {
+		case sig := <-sigs:
+			if sig == syscall.SIGHUP {
+				log.Info("SIGHUP received, reloading config")
+				sdNotify("RELOADING=1")
+				stopWatchers()
+				cfg = reload(log, s, tp, ss, cfg)
+				stopWatchers = startWatchers(ctx, log, s, ss)
+				sdNotify("READY=1")
+				continue
+			}
+			log.Info("signal received, shutting down", "signal", sig.String())
+			break loop
+		case <-ctx.Done():
+			log.Info("shutting down")
+			break loop
+		}
+	}
+	sdNotify("STOPPING=1")
 	cancel()
+	stopWatchers()
+}
+
+// handleResults consumes the worker pool results until the pool is stopped,
+// so that workers never block on a full result queue.
+func handleResults(log *slog.Logger, s services.TaskService, ss *SyncScheduler) {
+	for res := range s.WP().Results() {
+		ss.HandleResult(res)
+		if !res.Success && (res.Operation != workers.SyncTasksOp || res.API == "") {
+			log.Error("job failed", "job_id", res.JobID, "err", res.ParseErr())
+		}
+	}
+}
+
+// reload re-reads the config file and applies it to s, tp and ss. The old config
+// is returned and kept in use if the new one cannot be loaded or applied.
+func reload(log *slog.Logger, s services.TaskService, tp *TaskProcessor, ss *SyncScheduler, old *config.Config) *config.Config {
+	cfg, err := config.ReloadConfig()
+	if err != nil {
+		log.Error("failed to reload config, keeping the running one", "err", err)
+		return old
+	}
+
+	changes := config.Diff(old, cfg)
+	if len(changes) == 0 {
+		log.Info("config unchanged")
+		return old
+	}
+	for _, change := range changes {
+		log.Info("config changed", "change", change)
+	}
+	if cfg.Workers.QueueSize != old.Workers.QueueSize {
+		log.Warn("workers.queue-size takes effect after the daemon is restarted")
+	}
+	if cfg.Metrics.Listen != old.Metrics.Listen {
+		log.Warn("metrics.listen takes effect after the daemon is restarted")
+	}
+
+	if err := s.Reload(cfg); err != nil {
+		log.Error("failed to apply reloaded config", "err", err)
+		return old
+	}
+	if err := logging.SetLevel(cfg.Log.Level); err != nil {
+		log.Error("failed to apply reloaded log level", "err", err)
+	}
+	tp.Reconfigure(cfg.Daemon.TimeWindow, cfg.Daemon.PollInterval)
+	ss.Reconfigure(cfg.Daemon.SyncInterval, cfg.Daemon.MaxSyncBackoff, cfg.Daemon.OnlineCheckAddr)
+
+	log.Info("config reloaded", "queued_jobs", s.WP().QueueLen())
+	return cfg
 }
@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+func runtimeDir() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return dir
+}
+
+// PIDFile returns the path of the file the running daemon writes its pid to.
+func PIDFile() string {
+	return filepath.Join(runtimeDir(), "goot.pid")
+}
+
+func writePIDFile() error {
+	return os.WriteFile(PIDFile(), []byte(strconv.Itoa(pid)), 0o644)
+}
+
+func removePIDFile() {
+	os.Remove(PIDFile())
+}
+
+// runningPID returns the pid of the running daemon.
+func runningPID() (int, error) {
+	b, err := os.ReadFile(PIDFile())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, errors.New("daemon is not running")
+		}
+		return 0, fmt.Errorf("failed to read pid file: %w", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pid file '%s': %w", PIDFile(), err)
+	}
+
+	if err := syscall.Kill(pid, 0); err != nil {
+		return 0, fmt.Errorf("daemon is not running (stale pid file '%s')", PIDFile())
+	}
+	return pid, nil
+}
+
+// Reload asks the running daemon to reload its config by sending it SIGHUP.
+func Reload() error {
+	pid, err := runningPID()
+	if err != nil {
+		return err
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGHUP); err != nil {
+		return fmt.Errorf("failed to signal daemon (pid %d): %w", pid, err)
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/services"
+	"github.com/zeerodex/goot/internal/workers"
+)
+
+const (
+	schedulerTick  = 15 * time.Second
+	minSyncBackoff = 30 * time.Second
+	// pendingTimeout is the time after which a sync whose result never
+	// arrived is submitted again, so that a lost result cannot stop the
+	// sync of an API for good.
+	pendingTimeout = 10 * time.Minute
+)
+
+// SyncScheduler periodically submits a sync job for every enabled API and
+// backs off exponentially for APIs whose sync keeps failing.
+type SyncScheduler struct {
+	s               services.TaskService
+	interval        time.Duration
+	maxBackoff      time.Duration
+	onlineCheckAddr string
+
+	mu        sync.Mutex
+	providers map[string]*ProviderStatus
+	// pending holds the time the running sync of APIs was submitted at.
+	pending map[string]time.Time
+	// stale holds the APIs that changed while their sync was running.
+	stale map[string]bool
+	// seq numbers the status snapshots taken under mu.
+	seq int
+
+	// statusMu serializes writes of the status file, which are done without
+	// holding mu, skipping snapshots older than the written one.
+	statusMu sync.Mutex
+	written  int
+
+	log *slog.Logger
+}
+
+func NewSyncScheduler(s services.TaskService, interval, maxBackoff time.Duration, onlineCheckAddr string, logger *slog.Logger) *SyncScheduler {
+	return &SyncScheduler{
+		s:               s,
+		interval:        interval,
+		maxBackoff:      maxBackoff,
+		onlineCheckAddr: onlineCheckAddr,
+		providers:       make(map[string]*ProviderStatus),
+		pending:         make(map[string]time.Time),
+		stale:           make(map[string]bool),
+		log:             logger.With("component", "scheduler"),
+	}
+}
+
+// Reconfigure changes the sync settings of a running scheduler. Already
+// planned syncs are rescheduled according to the new interval.
+func (ss *SyncScheduler) Reconfigure(interval, maxBackoff time.Duration, onlineCheckAddr string) {
+	ss.mu.Lock()
+	ss.interval = interval
+	ss.maxBackoff = maxBackoff
+	ss.onlineCheckAddr = onlineCheckAddr
+	for _, p := range ss.providers {
+		if p.Failures == 0 && !p.LastSuccess.IsZero() {
+			p.NextSync = p.LastSuccess.Add(interval)
+		}
+	}
+	status := ss.status()
+	ss.mu.Unlock()
+
+	ss.writeStatus(status)
+}
+
+func (ss *SyncScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	ss.log.Info("sync scheduler started", "sync_interval", ss.syncInterval())
+
+	ss.tick(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			ss.tick(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (ss *SyncScheduler) syncInterval() time.Duration {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.interval
+}
+
+func (ss *SyncScheduler) tick(ctx context.Context) {
+	now := time.Now()
+	due, onlineCheckAddr := ss.dueAPIs(now)
+	if len(due) == 0 {
+		return
+	}
+
+	// The scheduler is not locked during I/O, which would block the
+	// results of running syncs.
+	if !online(ctx, onlineCheckAddr) {
+		ss.log.Info("offline, skipping sync", "apis", due)
+		ss.mu.Lock()
+		for _, name := range due {
+			delete(ss.pending, name)
+			if p, ok := ss.providers[name]; ok {
+				p.NextSync = now.Add(minSyncBackoff)
+			}
+		}
+		status := ss.status()
+		ss.mu.Unlock()
+		ss.writeStatus(status)
+		return
+	}
+
+	for _, name := range due {
+		ss.submit(ctx, name)
+	}
+}
+
+// dueAPIs returns the APIs whose sync is due, marking them pending, and the
+// address checked to know if goot is online.
+func (ss *SyncScheduler) dueAPIs(now time.Time) ([]string, string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.interval <= 0 {
+		return nil, ""
+	}
+
+	names := ss.s.WP().APINames()
+	ss.forgetDisabled(names)
+
+	var due []string
+	for _, name := range names {
+		p, ok := ss.providers[name]
+		if !ok {
+			p = &ProviderStatus{}
+			ss.providers[name] = p
+		}
+		if !ss.isPending(name, now) && !now.Before(p.NextSync) {
+			ss.pending[name] = now
+			due = append(due, name)
+		}
+	}
+	return due, ss.onlineCheckAddr
+}
+
+// isPending reports whether a sync of name is running. A sync submitted
+// more than pendingTimeout ago is assumed lost.
+func (ss *SyncScheduler) isPending(name string, now time.Time) bool {
+	submitted, ok := ss.pending[name]
+	if !ok {
+		return false
+	}
+	if now.Sub(submitted) < pendingTimeout {
+		return true
+	}
+	ss.log.Warn("no result of sync, submitting it again", "api", name, "submitted_at", submitted)
+	delete(ss.pending, name)
+	return false
+}
+
+// submit queues a sync job for name, which the caller marked pending.
+func (ss *SyncScheduler) submit(ctx context.Context, name string) {
+	err := ss.s.WP().Submit(ctx, workers.APIJob{
+		Operation: workers.SyncTasksOp,
+		API:       name,
+	})
+
+	ss.mu.Lock()
+	if err != nil {
+		delete(ss.pending, name)
+		if _, ok := ss.providers[name]; ok {
+			ss.recordFailure(name, err)
+		}
+	} else {
+		ss.log.Debug("sync submitted", "api", name)
+	}
+	status := ss.status()
+	ss.mu.Unlock()
+
+	ss.writeStatus(status)
+}
+
+// SyncNow submits a sync job for the API name unless one is already
+// running, in which case the API is synced again on the next tick after it
+// succeeds.
+func (ss *SyncScheduler) SyncNow(ctx context.Context, name string) {
+	now := time.Now()
+	ss.mu.Lock()
+	p, ok := ss.providers[name]
+	if !ok {
+		p = &ProviderStatus{}
+		ss.providers[name] = p
+	}
+	if ss.isPending(name, now) {
+		ss.stale[name] = true
+		ss.mu.Unlock()
+		return
+	}
+	if p.Failures > 0 && now.Before(p.NextSync) {
+		ss.mu.Unlock()
+		return
+	}
+	ss.pending[name] = now
+	ss.mu.Unlock()
+
+	ss.submit(ctx, name)
+}
+
+// HandleResult records the outcome of a sync job submitted by the scheduler.
+func (ss *SyncScheduler) HandleResult(res workers.APIJobResult) {
+	if res.Operation != workers.SyncTasksOp || res.API == "" {
+		return
+	}
+
+	ss.mu.Lock()
+	if _, ok := ss.providers[res.API]; !ok {
+		ss.mu.Unlock()
+		return
+	}
+	delete(ss.pending, res.API)
+
+	if res.Success {
+		p := ss.providers[res.API]
+		p.LastSuccess = time.Now()
+		p.Failures = 0
+		p.NextSync = p.LastSuccess.Add(ss.interval)
+		if ss.stale[res.API] {
+			delete(ss.stale, res.API)
+			p.NextSync = p.LastSuccess
+		}
+		ss.log.Info("synced", "api", res.API, "job_id", res.JobID)
+	} else {
+		ss.recordFailure(res.API, res.Err)
+	}
+	status := ss.status()
+	ss.mu.Unlock()
+
+	ss.writeStatus(status)
+}
+
+func (ss *SyncScheduler) recordFailure(name string, err error) {
+	p := ss.providers[name]
+	p.LastError = err.Error()
+	p.LastErrorAt = time.Now()
+	p.Failures++
+	backoff := ss.backoff(p.Failures)
+	p.NextSync = p.LastErrorAt.Add(backoff)
+	ss.log.Error("failed to sync", "api", name, "attempt", p.Failures, "retry_in", backoff, "err", err)
+}
+
+func (ss *SyncScheduler) backoff(failures int) time.Duration {
+	backoff := minSyncBackoff
+	for i := 1; i < failures && backoff < ss.maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, ss.maxBackoff)
+}
+
+func (ss *SyncScheduler) forgetDisabled(names []string) {
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[name] = true
+	}
+	for name := range ss.providers {
+		if !enabled[name] {
+			delete(ss.providers, name)
+			delete(ss.pending, name)
+			delete(ss.stale, name)
+		}
+	}
+}
+
+// online reports whether addr can be reached, true if addr is empty.
+func online(ctx context.Context, addr string) bool {
+	if addr == "" {
+		return true
+	}
+	d := net.Dialer{Timeout: 3 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// status returns a snapshot of the scheduler state, numbered by seq. The
+// caller must hold mu.
+func (ss *SyncScheduler) status() snapshot {
+	ss.seq++
+	status := Status{
+		PID:          pid,
+		StartedAt:    startedAt,
+		SyncInterval: ss.interval.String(),
+		Providers:    make(map[string]ProviderStatus, len(ss.providers)),
+	}
+	for name, p := range ss.providers {
+		status.Providers[name] = *p
+	}
+	return snapshot{seq: ss.seq, status: status}
+}
+
+type snapshot struct {
+	seq    int
+	status Status
+}
+
+// writeStatus writes s to the status file unless a newer snapshot has been
+// written already. It must be called without holding mu.
+func (ss *SyncScheduler) writeStatus(s snapshot) {
+	ss.statusMu.Lock()
+	defer ss.statusMu.Unlock()
+
+	if s.seq < ss.written {
+		return
+	}
+	ss.written = s.seq
+	if err := writeStatusFile(s.status); err != nil {
+		ss.log.Warn("failed to write status file", "err", err)
+	}
+}
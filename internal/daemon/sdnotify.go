@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends state to the service manager when running under systemd
+// with Type=notify. It is a no-op otherwise.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// watchdogInterval returns how often the watchdog has to be pinged, or 0 if
+// systemd has not enabled the watchdog for this process.
+func watchdogInterval() time.Duration {
+	usec, err := strconv.Atoi(os.Getenv("WATCHDOG_USEC"))
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if wpid := os.Getenv("WATCHDOG_PID"); wpid != "" && wpid != strconv.Itoa(pid) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// runWatchdog pings the systemd watchdog until ctx is done, as long as alive
+// reports the daemon as healthy.
+func runWatchdog(ctx context.Context, log *slog.Logger, alive func() bool) {
+	interval := watchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !alive() {
+				log.Warn("task processor is not responding, skipping watchdog ping")
+				continue
+			}
+			if err := sdNotify("WATCHDOG=1"); err != nil {
+				log.Warn("failed to ping systemd watchdog", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	pid       = os.Getpid()
+	startedAt = time.Now()
+)
+
+// Status is the state of a running daemon as written to StatusFile.
+type Status struct {
+	PID          int                       `json:"pid"`
+	StartedAt    time.Time                 `json:"started_at"`
+	SyncInterval string                    `json:"sync_interval"`
+	Providers    map[string]ProviderStatus `json:"providers"`
+}
+
+// ProviderStatus holds the periodic sync state of a single API.
+type ProviderStatus struct {
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+	Failures    int       `json:"failures"`
+	NextSync    time.Time `json:"next_sync,omitzero"`
+}
+
+// StatusFile returns the path of the file the running daemon reports its status to.
+func StatusFile() string {
+	return filepath.Join(runtimeDir(), "goot-status.json")
+}
+
+func writeStatusFile(status Status) error {
+	b, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+	tmp := StatusFile() + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, StatusFile())
+}
+
+func removeStatusFile() {
+	os.Remove(StatusFile())
+}
+
+// ReadStatus returns the status of the running daemon.
+func ReadStatus() (*Status, error) {
+	if _, err := runningPID(); err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(StatusFile())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errors.New("daemon has not reported its status yet")
+		}
+		return nil, fmt.Errorf("failed to read status file: %w", err)
+	}
+
+	var status Status
+	if err := json.Unmarshal(b, &status); err != nil {
+		return nil, fmt.Errorf("invalid status file '%s': %w", StatusFile(), err)
+	}
+	return &status, nil
+}
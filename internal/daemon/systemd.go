@@ -0,0 +1,153 @@
+package daemon
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const serviceUnit = "goot.service"
+
+// Units written by older versions, removed on install and uninstall.
+const (
+	syncServiceUnit = "goot-sync.service"
+	syncTimerUnit   = "goot-sync.timer"
+)
+
+var serviceTmpl = template.Must(template.New(serviceUnit).Funcs(template.FuncMap{
+	"quote":  quoteArg,
+	"escape": escapeSpecifiers,
+}).Parse(`# Generated by 'goot daemon install'.
+# Config: {{.ConfigFile}}
+# Database: {{.DBFile}}
+[Unit]
+Description=goot task manager daemon
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=notify
+NotifyAccess=main
+ExecStart={{quote .Executable}} daemon
+ExecReload=/bin/kill -HUP $MAINPID
+WorkingDirectory={{escape .WorkDir}}
+Restart=on-failure
+RestartSec=10
+WatchdogSec={{.Watchdog.Seconds}}
+
+[Install]
+WantedBy=default.target
+`))
+
+// UnitOptions configures the systemd user units written by InstallUnits.
+type UnitOptions struct {
+	Executable string
+	WorkDir    string
+	ConfigFile string
+	DBFile     string
+	Watchdog   time.Duration
+}
+
+// escapeSpecifiers escapes the '%' specifiers systemd expands in unit
+// settings.
+func escapeSpecifiers(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// quoteArg quotes s as a single argument of a systemd command line, which
+// also expands '$' variables and '%' specifiers.
+func quoteArg(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$").Replace(escapeSpecifiers(s))
+	return `"` + s + `"`
+}
+
+// UnitDir returns the directory systemd looks up user units in.
+func UnitDir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+// InstallUnits writes the systemd user units for the daemon and reloads the
+// user service manager. It returns the paths of the written units.
+func InstallUnits(opts UnitOptions) ([]string, error) {
+	dir, err := UnitDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create unit directory '%s': %w", dir, err)
+	}
+
+	// Paths are written on a line of their own, which a newline would end.
+	for _, path := range []string{opts.Executable, opts.WorkDir, opts.ConfigFile, opts.DBFile} {
+		if strings.ContainsAny(path, "\r\n") {
+			return nil, fmt.Errorf("path '%s' cannot contain a line break", path)
+		}
+	}
+
+	// The daemon syncs periodically itself, a timer running 'goot sync' as
+	// well would sync twice.
+	systemctl("disable", "--now", syncTimerUnit)
+	removeUnits(dir, syncServiceUnit, syncTimerUnit)
+
+	var buf bytes.Buffer
+	if err := serviceTmpl.Execute(&buf, opts); err != nil {
+		return nil, fmt.Errorf("failed to render unit '%s': %w", serviceUnit, err)
+	}
+	path := filepath.Join(dir, serviceUnit)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write unit '%s': %w", path, err)
+	}
+
+	return []string{path}, systemctl("daemon-reload")
+}
+
+// UninstallUnits stops, disables and removes the units written by InstallUnits.
+func UninstallUnits() ([]string, error) {
+	dir, err := UnitDir()
+	if err != nil {
+		return nil, err
+	}
+
+	units := []string{serviceUnit, syncTimerUnit, syncServiceUnit}
+	// Units may not be enabled or loaded, so failures here are expected.
+	systemctl(append([]string{"disable", "--now"}, units...)...)
+
+	removed := removeUnits(dir, units...)
+	if len(removed) == 0 {
+		return nil, errors.New("no goot units installed")
+	}
+	return removed, systemctl("daemon-reload")
+}
+
+func removeUnits(dir string, units ...string) []string {
+	var removed []string
+	for _, unit := range units {
+		path := filepath.Join(dir, unit)
+		if err := os.Remove(path); err == nil {
+			removed = append(removed, path)
+		}
+	}
+	return removed
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", append([]string{"--user"}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl --user %v failed: %w: %s", args, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
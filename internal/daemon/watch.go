@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/zeerodex/goot/internal/services"
+)
+
+// startWatchers syncs the APIs of s able to watch their tasks as soon as
+// they change. The returned function stops the watchers and waits for
+// them, it is called before the APIs are reloaded.
+func startWatchers(ctx context.Context, log *slog.Logger, s services.TaskService, ss *SyncScheduler) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for name, w := range s.WP().Watchers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Info("watching API for changes", "api", name)
+			err := w.Watch(ctx, func() { ss.SyncNow(ctx, name) })
+			if err != nil && ctx.Err() == nil {
+				log.Error("failed to watch API, syncing on interval only", "api", name, "err", err)
+			}
+		}()
+	}
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -2,12 +2,22 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// File is the path of the sqlite database, relative to the working directory.
+const File = "database.db"
+
 func InitDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "database.db")
+	return Open(File)
+}
+
+// Open opens the sqlite database at path, creating the schema if needed.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, err
 	}
@@ -26,15 +36,115 @@ func InitDB() (*sql.DB, error) {
 	title TEXT,
 	description TEXT,
 	due TEXT,
+	priority INTEGER DEFAULT 0,
+	recurrence TEXT DEFAULT '',
+	tags TEXT DEFAULT '',
 	last_modified TEXT,
 	completed BOOl DEFAULT 0,
 	deleted BOOLEAN DEFAULT 0,
-	notified BOOLEAN DEFAULT 0)`
+	notified BOOLEAN DEFAULT 0);
+
+	CREATE TABLE IF NOT EXISTS task_api_ids (
+	task_id INTEGER NOT NULL,
+	api TEXT NOT NULL,
+	api_id TEXT NOT NULL,
+	PRIMARY KEY (task_id, api))`
 
 	_, err = db.Exec(stmt)
 	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err = migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err = setupFTS(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
+
+// migrate adds the columns introduced after the tasks table was first
+// created to existing databases.
+func migrate(db *sql.DB) error {
+	columns := []struct{ name, def string }{
+		{"priority", "INTEGER DEFAULT 0"},
+		{"recurrence", "TEXT DEFAULT ''"},
+		{"tags", "TEXT DEFAULT ''"},
+	}
+	for _, c := range columns {
+		var n int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('tasks') WHERE name = ?", c.name).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("failed to inspect tasks table: %w", err)
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE tasks ADD COLUMN %s %s", c.name, c.def)); err != nil {
+			return fmt.Errorf("failed to add column %s: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+// ftsTriggers keep tasks_fts, an external content FTS5 index of the titles
+// and descriptions of tasks, in sync with the tasks table.
+var ftsTriggers = map[string]string{
+	"tasks_fts_insert": `CREATE TRIGGER tasks_fts_insert AFTER INSERT ON tasks BEGIN
+		INSERT INTO tasks_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+	END`,
+	"tasks_fts_delete": `CREATE TRIGGER tasks_fts_delete AFTER DELETE ON tasks BEGIN
+		INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+	END`,
+	"tasks_fts_update": `CREATE TRIGGER tasks_fts_update AFTER UPDATE OF title, description ON tasks BEGIN
+		INSERT INTO tasks_fts (tasks_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+		INSERT INTO tasks_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+	END`,
+}
+
+// setupFTS creates the full-text index of tasks if sqlite was built with
+// FTS5 (the sqlite_fts5 build tag). Otherwise it drops the triggers
+// maintaining the index, which would fail every write, and the index is
+// rebuilt the next time a build with FTS5 opens the database.
+func setupFTS(db *sql.DB) error {
+	_, err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(
+		title, description, content='tasks', content_rowid='id', tokenize='unicode61 remove_diacritics 2')`)
+	if err != nil {
+		if !strings.Contains(err.Error(), "no such module: fts5") {
+			return fmt.Errorf("failed to create full-text index: %w", err)
+		}
+		for name := range ftsTriggers {
+			if _, err := db.Exec("DROP TRIGGER IF EXISTS " + name); err != nil {
+				return fmt.Errorf("failed to drop trigger %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	rebuild := false
+	for name, stmt := range ftsTriggers {
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = ?", name).Scan(&n); err != nil {
+			return fmt.Errorf("failed to inspect trigger %s: %w", name, err)
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create trigger %s: %w", name, err)
+		}
+		rebuild = true
+	}
+	if rebuild {
+		if _, err := db.Exec("INSERT INTO tasks_fts (tasks_fts) VALUES ('rebuild')"); err != nil {
+			return fmt.Errorf("failed to build full-text index: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package events
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+type Type string
+
+const (
+	TaskCreated     Type = "task.created"
+	TaskUpdated     Type = "task.updated"
+	TaskCompleted   Type = "task.completed"
+	TaskUncompleted Type = "task.uncompleted"
+	TaskDeleted     Type = "task.deleted"
+	TaskOverdue     Type = "task.overdue"
+	SyncCompleted   Type = "sync.completed"
+	SyncFailed      Type = "sync.failed"
+)
+
+// Types lists every event type published on the bus.
+var Types = []Type{TaskCreated, TaskUpdated, TaskCompleted, TaskUncompleted, TaskDeleted, TaskOverdue, SyncCompleted, SyncFailed}
+
+// SourceLocal is the Event.Source of changes made by goot itself rather
+// than pulled from an API by sync.
+const SourceLocal = "local"
+
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// Source is SourceLocal or the name of the API the change came from.
+	Source string      `json:"source"`
+	Task   *tasks.Task `json:"task,omitempty"`
+	API    string      `json:"api,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type Handler func(Event)
+
+const subscriberQueueSize = 64
+
+type subscriber struct {
+	types   []Type
+	handler Handler
+	queue   chan Event
+	done    chan struct{}
+}
+
+// Bus delivers published events to subscribers. Every subscriber gets its
+// own goroutine, so a slow handler delays neither publishers nor other
+// subscribers.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []*subscriber
+	closed bool
+	log    *slog.Logger
+}
+
+func NewBus(logger *slog.Logger) *Bus {
+	return &Bus{log: logger.With("component", "events")}
+}
+
+// Subscribe calls handler for every published event of the given types, or
+// of any type if none is given. The returned function unsubscribes handler.
+func (b *Bus) Subscribe(handler Handler, types ...Type) func() {
+	sub := &subscriber{
+		types:   types,
+		handler: handler,
+		queue:   make(chan Event, subscriberQueueSize),
+		done:    make(chan struct{}),
+	}
+	go sub.run()
+
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if i := slices.Index(b.subs, sub); i >= 0 {
+			b.subs = slices.Delete(b.subs, i, i+1)
+			close(sub.queue)
+		}
+	}
+}
+
+func (s *subscriber) run() {
+	defer close(s.done)
+	for e := range s.queue {
+		s.handler(e)
+	}
+}
+
+// Publish delivers e to the subscribers of its type. Events are dropped for
+// subscribers whose queue is full.
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Source == "" {
+		e.Source = SourceLocal
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	for _, sub := range b.subs {
+		if len(sub.types) > 0 && !slices.Contains(sub.types, e.Type) {
+			continue
+		}
+		select {
+		case sub.queue <- e:
+		default:
+			b.log.Warn("subscriber queue full, dropping event", "type", e.Type)
+		}
+	}
+}
+
+// Close stops accepting events and waits for subscribers to handle the
+// events already published.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = nil
+	for _, sub := range subs {
+		close(sub.queue)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		<-sub.done
+	}
+}
@@ -0,0 +1,171 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/taskwarrior"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Runner runs the configured user hooks for events published on a bus.
+type Runner struct {
+	mu     sync.RWMutex
+	hooks  []config.Hook
+	client *http.Client
+	log    *slog.Logger
+}
+
+func NewRunner(hooks []config.Hook, logger *slog.Logger) *Runner {
+	return &Runner{
+		hooks:  hooks,
+		client: &http.Client{},
+		log:    logger.With("component", "hooks"),
+	}
+}
+
+// SetHooks replaces the configured hooks.
+func (r *Runner) SetHooks(hooks []config.Hook) {
+	r.mu.Lock()
+	r.hooks = hooks
+	r.mu.Unlock()
+}
+
+// Handle runs every hook subscribed to e. It is meant to be subscribed to
+// an events.Bus.
+func (r *Runner) Handle(e events.Event) {
+	r.mu.RLock()
+	hooks := r.hooks
+	r.mu.RUnlock()
+
+	payloads := make(map[string][]byte)
+	for _, hook := range hooks {
+		if len(hook.Events) > 0 && !slices.Contains(hook.Events, string(e.Type)) {
+			continue
+		}
+		payload, ok := payloads[hook.Format]
+		if !ok {
+			var err error
+			payload, err = encode(hook.Format, e)
+			if err != nil {
+				r.log.Error("failed to encode event", "type", e.Type, "format", hook.Format, "err", err)
+				continue
+			}
+			payloads[hook.Format] = payload
+		}
+		if payload == nil {
+			continue
+		}
+		if err := r.run(hook, e, payload); err != nil {
+			r.log.Error("hook failed", "type", e.Type, "hook", describe(hook), "err", err)
+		} else {
+			r.log.Debug("hook ran", "type", e.Type, "hook", describe(hook))
+		}
+	}
+}
+
+// encode returns the payload of e in format, or nil if e cannot be passed
+// in format.
+func encode(format string, e events.Event) ([]byte, error) {
+	switch format {
+	case "":
+		return json.Marshal(e)
+	case taskwarrior.Name:
+		if e.Task == nil {
+			return nil, nil
+		}
+		line, err := json.Marshal(taskwarrior.FromTask(*e.Task))
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, '\n')
+		if e.Type == events.TaskCreated {
+			return line, nil
+		}
+		// on-modify hooks read the original task and then the modified one.
+		// The original is not kept, so the task is passed twice.
+		return slices.Concat(line, line), nil
+	default:
+		return nil, fmt.Errorf("unknown hook format '%s'", format)
+	}
+}
+
+func (r *Runner) run(hook config.Hook, e events.Event, payload []byte) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	switch {
+	case len(hook.Command) > 0:
+		return runCommand(ctx, hook, e, payload)
+	case hook.Webhook != "":
+		return r.postWebhook(ctx, hook, payload)
+	default:
+		return fmt.Errorf("neither command nor webhook set")
+	}
+}
+
+func runCommand(ctx context.Context, hook config.Hook, e events.Event, payload []byte) error {
+	cmd := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "GOOT_EVENT="+string(e.Type), "GOOT_SOURCE="+e.Source)
+	if e.Task != nil {
+		cmd.Env = append(cmd.Env, "GOOT_TASK_ID="+strconv.Itoa(e.Task.ID))
+	}
+	if e.API != "" {
+		cmd.Env = append(cmd.Env, "GOOT_API="+e.API)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (r *Runner) postWebhook(ctx context.Context, hook config.Hook, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.Webhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func describe(hook config.Hook) string {
+	if len(hook.Command) > 0 {
+		return hook.Command[0]
+	}
+	return hook.Webhook
+}
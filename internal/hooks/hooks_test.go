@@ -0,0 +1,101 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/internal/taskwarrior"
+)
+
+func TestRunner(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		got []events.Event
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			t.Errorf("X-Token = %q, want %q", r.Header.Get("X-Token"), "secret")
+		}
+		var e events.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("decoding webhook body: %v", err)
+		}
+		mu.Lock()
+		got = append(got, e)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "out.json")
+	runner := NewRunner([]config.Hook{
+		{Events: []string{"task.completed"}, Command: []string{"sh", "-c", `cat > "$0"; echo "$GOOT_EVENT $GOOT_TASK_ID" >> "$0"`, out}},
+		{Events: []string{"sync.failed"}, Webhook: srv.URL, Headers: map[string]string{"X-Token": "secret"}},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	bus := events.NewBus(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	bus.Subscribe(runner.Handle)
+	bus.Publish(events.Event{Type: events.TaskCompleted, Task: &tasks.Task{ID: 7, Title: "done"}})
+	bus.Publish(events.Event{Type: events.TaskCreated, Task: &tasks.Task{ID: 8}})
+	bus.Publish(events.Event{Type: events.SyncFailed, API: "todoist", Error: "offline"})
+	bus.Close()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("command hook did not run: %v", err)
+	}
+	var e events.Event
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		t.Fatalf("decoding command stdin: %v", err)
+	}
+	if e.Type != events.TaskCompleted || e.Task == nil || e.Task.ID != 7 {
+		t.Errorf("command got event %+v, want task.completed for task 7", e)
+	}
+	if want := "task.completed 7\n"; !bytes.HasSuffix(data, []byte(want)) {
+		t.Errorf("command env output = %q, want suffix %q", data, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 || got[0].Type != events.SyncFailed || got[0].API != "todoist" || got[0].Error != "offline" {
+		t.Errorf("webhook got %+v, want one sync.failed for todoist", got)
+	}
+}
+
+func TestRunnerTaskwarriorFormat(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	runner := NewRunner([]config.Hook{
+		{Format: "taskwarrior", Command: []string{"sh", "-c", `cat >> "$0"`, out}},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	runner.Handle(events.Event{Type: events.TaskCreated, Task: &tasks.Task{ID: 7, Title: "new"}})
+	runner.Handle(events.Event{Type: events.SyncFailed, API: "todoist"})
+	runner.Handle(events.Event{Type: events.TaskCompleted, Task: &tasks.Task{ID: 7, Title: "new", Completed: true}})
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("command hook did not run: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("command got %d lines, want one for on-add and two for on-modify:\n%s", len(lines), data)
+	}
+	var tw taskwarrior.Task
+	if err := json.Unmarshal([]byte(lines[2]), &tw); err != nil {
+		t.Fatalf("decoding command stdin: %v", err)
+	}
+	if tw.Description != "new" || tw.Status != taskwarrior.StatusCompleted || tw.UUID != taskwarrior.UUID(tasks.Task{ID: 7}) {
+		t.Errorf("command got %+v", tw)
+	}
+}
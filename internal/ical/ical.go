@@ -0,0 +1,325 @@
+// Package ical reads and writes the subset of iCalendar (RFC 5545) goot
+// needs: components, properties with parameters, text escaping and
+// date-times. Unknown components and properties are preserved as is.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+)
+
+// Property is a content line such as "DUE;VALUE=DATE:20250601".
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Component is a BEGIN/END block such as VCALENDAR or VTODO.
+type Component struct {
+	Name     string
+	Props    []Property
+	Children []*Component
+}
+
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Get returns the first property called name, or nil.
+func (c *Component) Get(name string) *Property {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// Text returns the unescaped value of the property name, or "".
+func (c *Component) Text(name string) string {
+	if p := c.Get(name); p != nil {
+		return UnescapeText(p.Value)
+	}
+	return ""
+}
+
+// Set replaces the properties called name with a single one.
+func (c *Component) Set(name, value string, params map[string]string) {
+	c.Del(name)
+	c.Props = append(c.Props, Property{Name: name, Params: params, Value: value})
+}
+
+// SetText sets the property name to the escaped text value.
+func (c *Component) SetText(name, value string) {
+	c.Set(name, EscapeText(value), nil)
+}
+
+// SetTime sets the property name to t, as a date if dateOnly and as a UTC
+// date-time otherwise.
+func (c *Component) SetTime(name string, t time.Time, dateOnly bool) {
+	if dateOnly {
+		c.Set(name, t.Format(dateLayout), map[string]string{"VALUE": "DATE"})
+		return
+	}
+	c.Set(name, t.UTC().Format(dateTimeLayout)+"Z", nil)
+}
+
+// Time parses the date or date-time property name. dateOnly reports a
+// DATE value, returned as midnight in loc.
+func (c *Component) Time(name string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	p := c.Get(name)
+	if p == nil {
+		return time.Time{}, false, nil
+	}
+	return p.Time(loc)
+}
+
+// Del removes the properties called name.
+func (c *Component) Del(name string) {
+	props := c.Props[:0]
+	for _, p := range c.Props {
+		if p.Name != name {
+			props = append(props, p)
+		}
+	}
+	c.Props = props
+}
+
+// Child returns the first child component called name, or nil.
+func (c *Component) Child(name string) *Component {
+	for _, child := range c.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Time parses the property as a DATE or DATE-TIME value. Floating times
+// and dates are interpreted in loc, TZID parameters are honoured when the
+// zone is known.
+func (p *Property) Time(loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if p.Params["VALUE"] == "DATE" || len(p.Value) == len(dateLayout) {
+		t, err = time.ParseInLocation(dateLayout, p.Value, loc)
+		return t, true, err
+	}
+	if v, ok := strings.CutSuffix(p.Value, "Z"); ok {
+		t, err = time.ParseInLocation(dateTimeLayout, v, time.UTC)
+		return t, false, err
+	}
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	t, err = time.ParseInLocation(dateTimeLayout, p.Value, loc)
+	return t, false, err
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// EscapeText escapes a TEXT value.
+func EscapeText(s string) string {
+	return textEscaper.Replace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+// UnescapeText reverses EscapeText.
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Decode reads a single top-level component, usually a VCALENDAR.
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var stack []*Component
+	var root *Component
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		switch p.Name {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(p.Value))
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, c)
+			} else if root != nil {
+				return nil, errors.New("ical: more than one top-level component")
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("ical: unexpected END:%s", p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("ical: property %s outside of a component", p.Name)
+			}
+			c := stack[len(stack)-1]
+			c.Props = append(c.Props, p)
+		}
+	}
+	if root == nil {
+		return nil, errors.New("ical: no component found")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("ical: missing END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("ical: failed to read: %w", err)
+	}
+	return lines, nil
+}
+
+func parseLine(line string) (Property, error) {
+	var p Property
+
+	// The value starts at the first colon outside of a quoted parameter.
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, fmt.Errorf("ical: invalid content line %q", line)
+	}
+	p.Value = line[colon+1:]
+
+	parts := splitParams(line[:colon])
+	p.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		if p.Params == nil {
+			p.Params = make(map[string]string)
+		}
+		p.Params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, nil
+}
+
+func splitParams(s string) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ';' && !quoted {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// Encode writes c with CRLF line endings, folding lines longer than 75
+// octets.
+func (c *Component) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	c.encode(bw)
+	return bw.Flush()
+}
+
+func (c *Component) String() string {
+	var b strings.Builder
+	c.Encode(&b)
+	return b.String()
+}
+
+func (c *Component) encode(w *bufio.Writer) {
+	writeLine(w, "BEGIN:"+c.Name)
+	for _, p := range c.Props {
+		writeLine(w, p.String())
+	}
+	for _, child := range c.Children {
+		child.encode(w)
+	}
+	writeLine(w, "END:"+c.Name)
+}
+
+func (p Property) String() string {
+	var b strings.Builder
+	b.WriteString(p.Name)
+	for _, k := range slices.Sorted(maps.Keys(p.Params)) {
+		v := p.Params[k]
+		if strings.ContainsAny(v, ";:,") {
+			v = `"` + v + `"`
+		}
+		b.WriteString(";" + k + "=" + v)
+	}
+	b.WriteString(":" + p.Value)
+	return b.String()
+}
+
+func writeLine(w *bufio.Writer, line string) {
+	// Continuation lines start with a space, which counts towards the limit.
+	limit := 75
+	for len(line) > limit {
+		// Do not split UTF-8 sequences.
+		i := limit
+		for i > 0 && line[i]&0xC0 == 0x80 {
+			i--
+		}
+		w.WriteString(line[:i] + "\r\n ")
+		line = line[i:]
+		limit = 74
+	}
+	w.WriteString(line + "\r\n")
+}
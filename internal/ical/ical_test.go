@@ -0,0 +1,74 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	todo := NewComponent("VTODO")
+	todo.SetText("SUMMARY", "Buy milk, eggs; and "+strings.Repeat("bread ", 20))
+	todo.SetText("DESCRIPTION", "line one\nline two \\ end")
+	todo.SetTime("DUE", time.Date(2025, 6, 1, 0, 0, 0, 0, time.Local), true)
+	todo.Set("X-PARAM", "v", map[string]string{"X-KEY": "a:b"})
+	cal := NewComponent("VCALENDAR")
+	cal.Children = append(cal.Children, todo)
+
+	encoded := cal.String()
+	for _, line := range strings.Split(encoded, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	decoded, err := Decode(strings.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	got := decoded.Child("VTODO")
+	if got == nil {
+		t.Fatal("VTODO not decoded")
+	}
+	for _, name := range []string{"SUMMARY", "DESCRIPTION"} {
+		if got.Text(name) != todo.Text(name) {
+			t.Errorf("%s = %q, want %q", name, got.Text(name), todo.Text(name))
+		}
+	}
+	if p := got.Get("X-PARAM"); p == nil || p.Params["X-KEY"] != "a:b" || p.Value != "v" {
+		t.Errorf("X-PARAM = %+v", p)
+	}
+}
+
+func TestPropertyTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+
+	tests := []struct {
+		line     string
+		want     time.Time
+		dateOnly bool
+	}{
+		{"DUE;VALUE=DATE:20250601", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), true},
+		{"DUE:20250601T143000Z", time.Date(2025, 6, 1, 14, 30, 0, 0, time.UTC), false},
+		{"DUE;TZID=Europe/Berlin:20250601T143000", time.Date(2025, 6, 1, 14, 30, 0, 0, berlin), false},
+		{"DUE:20250601T143000", time.Date(2025, 6, 1, 14, 30, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			p, err := parseLine(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, dateOnly, err := p.Time(time.UTC)
+			if err != nil {
+				t.Fatalf("Time() error = %v", err)
+			}
+			if !got.Equal(tt.want) || dateOnly != tt.dateOnly {
+				t.Errorf("Time() = %v, %t, want %v, %t", got, dateOnly, tt.want, tt.dateOnly)
+			}
+		})
+	}
+}
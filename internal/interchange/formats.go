@@ -0,0 +1,158 @@
+package interchange
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func newDocument(ts tasks.Tasks) Document {
+	doc := Document{Version: Version, Tasks: make([]Task, len(ts))}
+	for i := range ts {
+		doc.Tasks[i] = FromTask(&ts[i])
+	}
+	return doc
+}
+
+// documentRecords returns the tasks of a decoded document, or of a bare
+// list of tasks.
+func documentRecords(v any) ([]record, error) {
+	list := v
+	if doc, ok := v.(map[string]any); ok {
+		version, err := strconv.Atoi(str(doc["version"]))
+		if err != nil {
+			return nil, fmt.Errorf("document has no valid version")
+		}
+		if version > Version {
+			return nil, fmt.Errorf("unsupported schema version %d, goot supports up to %d", version, Version)
+		}
+		list = doc["tasks"]
+	}
+	items, ok := list.([]any)
+	if !ok && list != nil {
+		return nil, fmt.Errorf("expected a list of tasks")
+	}
+	records := make([]record, len(items))
+	for i, item := range items {
+		r, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("task %d is not an object", i+1)
+		}
+		records[i] = r
+	}
+	return records, nil
+}
+
+// EncodeJSON writes ts as an indented JSON document.
+func EncodeJSON(w io.Writer, ts tasks.Tasks) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newDocument(ts))
+}
+
+// DecodeJSON reads a JSON document, or a JSON list of tasks, renaming the
+// keys of the tasks by fields.
+func DecodeJSON(r io.Reader, fields map[string]string) (tasks.Tasks, error) {
+	var v any
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	records, err := documentRecords(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecords(records, fields)
+}
+
+// EncodeYAML writes ts as a YAML document.
+func EncodeYAML(w io.Writer, ts tasks.Tasks) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(newDocument(ts)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// DecodeYAML reads a YAML document, or a YAML list of tasks, renaming the
+// keys of the tasks by fields.
+func DecodeYAML(r io.Reader, fields map[string]string) (tasks.Tasks, error) {
+	var v any
+	if err := yaml.NewDecoder(r).Decode(&v); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	records, err := documentRecords(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecords(records, fields)
+}
+
+const remoteIDPrefix = "remote_ids."
+
+// EncodeCSV writes ts as CSV with a header row, and a remote ID column for
+// every API one of ts is synced with.
+func EncodeCSV(w io.Writer, ts tasks.Tasks) error {
+	doc := newDocument(ts)
+	apis := make(map[string]bool)
+	for _, t := range doc.Tasks {
+		for name := range t.RemoteIDs {
+			apis[name] = true
+		}
+	}
+	names := slices.Sorted(maps.Keys(apis))
+
+	cw := csv.NewWriter(w)
+	header := slices.Clone(Fields[:len(Fields)-1])
+	for _, name := range names {
+		header = append(header, remoteIDPrefix+name)
+	}
+	cw.Write(header)
+	for _, t := range doc.Tasks {
+		row := []string{
+			strconv.Itoa(t.ID), t.Title, t.Description, t.Due, t.Priority, t.Recurrence,
+			strings.Join(t.Tags, ","), strconv.FormatBool(t.Completed), t.LastModified,
+		}
+		for _, name := range names {
+			row = append(row, t.RemoteIDs[name])
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// DecodeCSV reads CSV with a header row, renaming its columns by fields.
+func DecodeCSV(r io.Reader, fields map[string]string) (tasks.Tasks, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	header := rows[0]
+	records := make([]record, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		rec := make(record, len(header))
+		for i, col := range header {
+			if i < len(row) {
+				rec[strings.TrimSpace(col)] = row[i]
+			}
+		}
+		records = append(records, rec)
+	}
+	return decodeRecords(records, fields)
+}
@@ -0,0 +1,332 @@
+// Package interchange defines the versioned schema goot exchanges tasks in
+// with other tools, encoded as JSON, YAML or CSV.
+//
+// JSON and YAML files hold a document:
+//
+//	{"version": 1, "tasks": [{"title": "Pay rent", "due": "2026-11-01"}]}
+//
+// Version 1 tasks have the fields:
+//
+//   - id: the goot ID of an exported task, ignored on import
+//   - title: required
+//   - description
+//   - due: a date such as "2026-11-01", or an RFC 3339 date-time
+//   - priority: none, low, medium or high
+//   - recurrence: an iCalendar RRULE value such as "FREQ=WEEKLY"
+//   - tags: a list of tags, or a comma separated string
+//   - completed: true or false
+//   - last_modified: an RFC 3339 date-time
+//   - remote_ids: the IDs of the task in synced APIs, keyed by API name
+//
+// CSV files have a header row naming the fields, remote IDs in columns
+// such as "remote_ids.todoist", and no version. Import also accepts a bare
+// list of tasks, such as the output of 'goot list --json'. Versions only
+// add fields, so that documents of older versions remain valid.
+package interchange
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+// Version is the version of the schema written by goot.
+const Version = 1
+
+// Document is the top level value of JSON and YAML files.
+type Document struct {
+	Version int    `json:"version" yaml:"version"`
+	Tasks   []Task `json:"tasks" yaml:"tasks"`
+}
+
+// Task is a task in the interchange schema.
+type Task struct {
+	ID           int               `json:"id,omitempty" yaml:"id,omitempty"`
+	Title        string            `json:"title" yaml:"title"`
+	Description  string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Due          string            `json:"due,omitempty" yaml:"due,omitempty"`
+	Priority     string            `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Recurrence   string            `json:"recurrence,omitempty" yaml:"recurrence,omitempty"`
+	Tags         []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Completed    bool              `json:"completed" yaml:"completed"`
+	LastModified string            `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+	RemoteIDs    map[string]string `json:"remote_ids,omitempty" yaml:"remote_ids,omitempty"`
+}
+
+// Fields are the names of the fields of Task.
+var Fields = []string{"id", "title", "description", "due", "priority", "recurrence", "tags", "completed", "last_modified", "remote_ids"}
+
+const dateLayout = "2006-01-02"
+
+// RemoteIDs returns the IDs of task in every API, keyed by API name.
+func RemoteIDs(task *tasks.Task) map[string]string {
+	ids := maps.Clone(task.APIIDs)
+	if ids == nil {
+		ids = make(map[string]string)
+	}
+	if task.GoogleID != "" {
+		ids["gtasks"] = task.GoogleID
+	}
+	if task.TodoistID != "" {
+		ids["todoist"] = task.TodoistID
+	}
+	return ids
+}
+
+// SetRemoteID stores id as the ID of task in the API name.
+func SetRemoteID(task *tasks.Task, name, id string) {
+	switch name {
+	case "gtasks":
+		task.GoogleID = id
+	case "todoist":
+		task.TodoistID = id
+	default:
+		task.SetAPIID(name, id)
+	}
+}
+
+func FromTask(task *tasks.Task) Task {
+	t := Task{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Recurrence:  task.Recurrence,
+		Tags:        task.Tags,
+		Completed:   task.Completed,
+	}
+	if task.Priority != tasks.PriorityNone {
+		t.Priority = task.Priority.String()
+	}
+	if !task.Due.IsZero() {
+		if timeutil.IsOnlyDate(task.Due) {
+			t.Due = task.Due.Format(dateLayout)
+		} else {
+			t.Due = task.Due.Format(time.RFC3339)
+		}
+	}
+	if !task.LastModified.IsZero() {
+		t.LastModified = task.LastModified.UTC().Format(time.RFC3339)
+	}
+	if ids := RemoteIDs(task); len(ids) > 0 {
+		t.RemoteIDs = ids
+	}
+	return t
+}
+
+// Task converts t to a task. The ID is not kept, as it is only meaningful
+// in the database t was exported from.
+func (t Task) Task() (tasks.Task, error) {
+	if strings.TrimSpace(t.Title) == "" {
+		return tasks.Task{}, fmt.Errorf("task has no title")
+	}
+	task := tasks.Task{
+		Title:       t.Title,
+		Description: t.Description,
+		Recurrence:  t.Recurrence,
+		Tags:        t.Tags,
+		Completed:   t.Completed,
+	}
+	var err error
+	if t.Due != "" {
+		if task.Due, err = parseTime(t.Due); err != nil {
+			return tasks.Task{}, fmt.Errorf("invalid due of task '%s': %w", t.Title, err)
+		}
+	}
+	if t.LastModified != "" {
+		if task.LastModified, err = time.Parse(time.RFC3339, t.LastModified); err != nil {
+			return tasks.Task{}, fmt.Errorf("invalid last_modified of task '%s': %w", t.Title, err)
+		}
+	}
+	if t.Priority != "" {
+		if task.Priority, err = tasks.ParsePriority(strings.ToLower(t.Priority)); err != nil {
+			return tasks.Task{}, fmt.Errorf("invalid priority of task '%s': %w", t.Title, err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(t.RemoteIDs)) {
+		if t.RemoteIDs[name] != "" {
+			SetRemoteID(&task, name, t.RemoteIDs[name])
+		}
+	}
+	return task, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation(dateLayout, s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		// 'goot list --json' writes tasks without due date as the zero time.
+		if t.IsZero() {
+			return time.Time{}, nil
+		}
+		return t.Local(), nil
+	}
+	return timeutil.ParseAndValidateTimestamp(s)
+}
+
+// record is a task as decoded from a file, before its keys are mapped to
+// fields.
+type record map[string]any
+
+// aliases map the keys of 'goot list --json' to fields.
+var aliases = map[string]string{
+	"status":     "completed",
+	"google_id":  "remote_ids.gtasks",
+	"GoogleID":   "remote_ids.gtasks",
+	"todoist_id": "remote_ids.todoist",
+	"TodoistID":  "remote_ids.todoist",
+	"api_ids":    "remote_ids",
+}
+
+// task converts r to a Task, renaming its keys by fields first. Keys that
+// are no field are ignored.
+func (r record) task(fields map[string]string) (Task, error) {
+	var t Task
+	for key, v := range r {
+		field := key
+		if f, ok := fields[key]; ok {
+			field = f
+		} else if f, ok := aliases[key]; ok {
+			field = f
+		}
+
+		if name, ok := strings.CutPrefix(field, "remote_ids."); ok {
+			if id := str(v); id != "" {
+				if t.RemoteIDs == nil {
+					t.RemoteIDs = make(map[string]string)
+				}
+				t.RemoteIDs[name] = id
+			}
+			continue
+		}
+
+		switch field {
+		case "title":
+			t.Title = str(v)
+		case "description":
+			t.Description = str(v)
+		case "due":
+			t.Due = str(v)
+		case "recurrence":
+			t.Recurrence = str(v)
+		case "last_modified":
+			t.LastModified = str(v)
+		case "priority":
+			// 'goot list --json' writes priorities as numbers.
+			switch n := v.(type) {
+			case float64:
+				t.Priority = tasks.Priority(n).String()
+			case int:
+				t.Priority = tasks.Priority(n).String()
+			default:
+				t.Priority = str(v)
+			}
+		case "tags":
+			t.Tags = strs(v)
+		case "completed":
+			b, err := boolean(v)
+			if err != nil {
+				return Task{}, fmt.Errorf("invalid %s '%v': %w", key, v, err)
+			}
+			t.Completed = b
+		case "remote_ids":
+			if v == nil {
+				continue
+			}
+			ids, ok := v.(map[string]any)
+			if !ok {
+				return Task{}, fmt.Errorf("invalid %s '%v'", key, v)
+			}
+			for name, id := range ids {
+				if t.RemoteIDs == nil {
+					t.RemoteIDs = make(map[string]string)
+				}
+				t.RemoteIDs[name] = str(id)
+			}
+		}
+	}
+	return t, nil
+}
+
+// decodeRecords converts records to tasks, renaming their keys by fields.
+func decodeRecords(records []record, fields map[string]string) (tasks.Tasks, error) {
+	for from, to := range fields {
+		if !slices.Contains(Fields, to) && !strings.HasPrefix(to, "remote_ids.") {
+			return nil, fmt.Errorf("cannot map '%s' to unknown field '%s'", from, to)
+		}
+	}
+
+	ts := make(tasks.Tasks, 0, len(records))
+	for i, r := range records {
+		t, err := r.task(fields)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		task, err := t.Task()
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		ts = append(ts, task)
+	}
+	return ts, nil
+}
+
+func str(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		// YAML decodes unquoted dates as timestamps.
+		if v.Location() == time.UTC && timeutil.IsOnlyDate(v) {
+			return v.Format(dateLayout)
+		}
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func strs(v any) []string {
+	var out []string
+	switch v := v.(type) {
+	case []any:
+		for _, s := range v {
+			if s := str(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	default:
+		for s := range strings.SplitSeq(str(v), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+func boolean(v any) (bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(str(v))) {
+	case "", "false", "no", "0", "pending":
+		return false, nil
+	case "true", "yes", "1", "x", "done", "completed":
+		return true, nil
+	}
+	return false, fmt.Errorf("not a boolean")
+}
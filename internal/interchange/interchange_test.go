@@ -0,0 +1,112 @@
+package interchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func testTasks() tasks.Tasks {
+	rent := tasks.Task{
+		ID: 1, Title: "Pay rent", Description: "to the landlord, by transfer",
+		Due: time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local), Recurrence: "FREQ=MONTHLY",
+		Tags: []string{"home", "money"}, GoogleID: "g1",
+		LastModified: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+	}
+	rent.SetAPIID("caldav", "c1")
+	call := tasks.Task{
+		ID: 2, Title: "Call mom", Due: time.Date(2026, 10, 20, 18, 30, 0, 0, time.Local),
+		Priority: tasks.PriorityHigh, Completed: true,
+		LastModified: time.Date(2026, 10, 2, 8, 0, 0, 0, time.UTC),
+	}
+	return tasks.Tasks{rent, call}
+}
+
+func TestRoundTrip(t *testing.T) {
+	formats := map[string]struct {
+		encode func(io.Writer, tasks.Tasks) error
+		decode func(io.Reader, map[string]string) (tasks.Tasks, error)
+	}{
+		"json": {EncodeJSON, DecodeJSON},
+		"yaml": {EncodeYAML, DecodeYAML},
+		"csv":  {EncodeCSV, DecodeCSV},
+	}
+	want := testTasks()
+	for name, f := range formats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := f.encode(&buf, want); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			got, err := f.decode(&buf, nil)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("decoded %d tasks, want %d", len(got), len(want))
+			}
+			for i := range want {
+				w, g := want[i], got[i]
+				if g.ID != 0 || g.Title != w.Title || g.Description != w.Description || !g.Due.Equal(w.Due) ||
+					g.Priority != w.Priority || g.Recurrence != w.Recurrence || !slices.Equal(g.Tags, w.Tags) ||
+					g.Completed != w.Completed || !g.LastModified.Equal(w.LastModified) ||
+					g.GoogleID != w.GoogleID || g.APIID("caldav") != w.APIID("caldav") {
+					t.Errorf("task %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeListJSON(t *testing.T) {
+	// The output of 'goot list --json'.
+	data, err := json.Marshal(testTasks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeJSON(bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("DecodeJSON: %v", err)
+	}
+	if len(got) != 2 || got[0].GoogleID != "g1" || got[0].APIID("caldav") != "c1" || !got[1].Completed || got[1].Priority != tasks.PriorityHigh {
+		t.Errorf("DecodeJSON() = %+v", got)
+	}
+
+	if _, err := DecodeJSON(strings.NewReader(`{"version": 2, "tasks": []}`), nil); err == nil {
+		t.Error("newer schema version accepted")
+	}
+}
+
+func TestDecodeMapped(t *testing.T) {
+	in := "Task Name,Deadline,Done,Labels,Notes\n" +
+		"Buy milk,2026-10-21,x,\"store, food\",\n" +
+		"Book flights,2026-11-02T09:00:00Z,,travel,window seat\n"
+	got, err := DecodeCSV(strings.NewReader(in), map[string]string{"Task Name": "title", "Deadline": "due", "Done": "completed", "Labels": "tags"})
+	if err != nil {
+		t.Fatalf("DecodeCSV: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("decoded %d tasks, want 2", len(got))
+	}
+	if got[0].Title != "Buy milk" || !got[0].Completed || !slices.Equal(got[0].Tags, []string{"store", "food"}) ||
+		!got[0].Due.Equal(time.Date(2026, 10, 21, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("task 1 = %+v", got[0])
+	}
+	// Unmapped columns that are no field are ignored.
+	if got[1].Description != "" || !got[1].Due.Equal(time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("task 2 = %+v", got[1])
+	}
+
+	if _, err := DecodeCSV(strings.NewReader(in), map[string]string{"Task Name": "name"}); err == nil {
+		t.Error("mapping to an unknown field accepted")
+	}
+	if _, err := DecodeYAML(strings.NewReader("version: 1\ntasks:\n  - due: 2026-10-21\n"), nil); err == nil {
+		t.Error("task without title accepted")
+	}
+}
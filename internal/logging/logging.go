@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/zeerodex/goot/internal/config"
+)
+
+var level = new(slog.LevelVar)
+
+// New creates a logger writing to the destination configured in cfg. The
+// returned closer releases the log file, if any.
+func New(cfg config.Log) (*slog.Logger, io.Closer, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, nil, err
+	}
+
+	var err error
+	var w io.WriteCloser = nopCloser{os.Stderr}
+	if cfg.File != "" {
+		w, err = NewRotatingFile(cfg.File, int64(cfg.MaxSize)*1024*1024, cfg.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		w.Close()
+		return nil, nil, fmt.Errorf("unknown log format '%s', expected text or json", cfg.Format)
+	}
+
+	return slog.New(h), w, nil
+}
+
+// SetLevel changes the minimum level of every logger created by New.
+func SetLevel(s string) error {
+	lvl, err := parseLevel(s)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	return nil
+}
+
+// Mute silences every logger created by New, e.g. while the TUI owns the terminal.
+func Mute() {
+	level.Set(math.MaxInt)
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	var lvl slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := lvl.UnmarshalText([]byte(s)); err != nil {
+		return lvl, fmt.Errorf("unknown log level '%s': %w", s, err)
+	}
+	return lvl, nil
+}
+
+type ctxKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or fallback if there is none.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// NewID returns a short random identifier used to correlate log lines.
+func NewID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
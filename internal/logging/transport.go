@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Transport is an http.RoundTripper logging every request made through it
+// with a request ID. The logger carried by the request context is preferred,
+// so that job IDs set by the caller end up on the same line.
+type Transport struct {
+	Base   http.RoundTripper
+	Logger *slog.Logger
+}
+
+// WrapClient makes client log its requests through logger.
+func WrapClient(client *http.Client, logger *slog.Logger) *http.Client {
+	c := *client
+	c.Transport = &Transport{Base: client.Transport, Logger: logger}
+	return &c
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	logger := FromContext(req.Context(), t.Logger).With(
+		"request_id", NewID(),
+		"method", req.Method,
+		"url", req.URL.Redacted(),
+	)
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		logger.Warn("api request failed", "duration", time.Since(start), "err", err)
+		return nil, err
+	}
+	logger.Debug("api request", "status", resp.StatusCode, "duration", time.Since(start))
+	return resp, nil
+}
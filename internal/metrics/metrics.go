@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "goot"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	APIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "Requests made to task APIs by API and response status.",
+	}, []string{"api", "status"})
+
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Duration of requests made to task APIs.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"api"})
+
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of API worker jobs by operation and result.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"op", "result"})
+
+	APIRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_retries_total",
+		Help:      "Requests to task APIs retried after a 429 Too Many Requests response, by API.",
+	}, []string{"api"})
+
+	SyncConflicts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_conflicts_total",
+		Help:      "Tasks modified both locally and in an API by the side whose change was kept.",
+	}, []string{"api", "winner"})
+
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Due task notifications by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		APIRequests,
+		APIRequestDuration,
+		JobDuration,
+		APIRetries,
+		SyncConflicts,
+		Notifications,
+	)
+}
+
+// RegisterQueueDepth exposes the number of queued API worker jobs as reported by queueLen.
+func RegisterQueueDepth(queueLen func() int) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "job_queue_depth",
+		Help:      "API worker jobs waiting to be processed.",
+	}, func() float64 {
+		return float64(queueLen())
+	}))
+}
+
+// Serve exposes the metrics on addr under /metrics until ctx is done.
+func Serve(ctx context.Context, addr string, logger *slog.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("metrics endpoint listening", "addr", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("metrics endpoint failed", "err", err)
+	}
+}
+
+// Transport is an http.RoundTripper counting and timing requests to an API.
+type Transport struct {
+	Base http.RoundTripper
+	API  string
+}
+
+// WrapClient makes client record metrics for its requests to api.
+func WrapClient(client *http.Client, api string) *http.Client {
+	c := *client
+	c.Transport = &Transport{Base: client.Transport, API: api}
+	return &c
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	APIRequestDuration.WithLabelValues(t.API).Observe(time.Since(start).Seconds())
+	if err != nil {
+		APIRequests.WithLabelValues(t.API, "error").Inc()
+		return nil, err
+	}
+	APIRequests.WithLabelValues(t.API, strconv.Itoa(resp.StatusCode)).Inc()
+	return resp, nil
+}
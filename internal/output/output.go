@@ -0,0 +1,359 @@
+// Package output writes the results of commands in the format chosen with
+// --output: aligned tables, plain text, JSON, newline delimited JSON, CSV,
+// YAML or a Go template executed for every item.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Format names.
+const (
+	Table    = "table"
+	Plain    = "plain"
+	JSON     = "json"
+	NDJSON   = "ndjson"
+	CSV      = "csv"
+	YAML     = "yaml"
+	Template = "template"
+)
+
+// Formats describes the accepted values of --output.
+const Formats = "table, plain, json, ndjson, csv, yaml or template=<go-template>"
+
+// Format is an output format, one of the format names.
+type Format struct {
+	Name string
+	tmpl *template.Template
+}
+
+// funcs are the functions available to templates besides the builtin ones.
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"date": func(layout string, t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	},
+}
+
+// ParseFormat parses the value of --output, such as "csv" or
+// "template={{.ID}} {{.Title}}".
+func ParseFormat(s string) (Format, error) {
+	if text, ok := strings.CutPrefix(s, Template+"="); ok {
+		tmpl, err := template.New("output").Funcs(funcs).Parse(text)
+		if err != nil {
+			return Format{}, fmt.Errorf("invalid output template: %w", err)
+		}
+		return Format{Name: Template, tmpl: tmpl}, nil
+	}
+	switch s {
+	case Table, Plain, JSON, NDJSON, CSV, YAML:
+		return Format{Name: s}, nil
+	case Template:
+		return Format{}, fmt.Errorf("missing template, write --output 'template={{.Title}}'")
+	}
+	return Format{}, fmt.Errorf("invalid output format '%s', want %s", s, Formats)
+}
+
+// Column is a column of the table, plain and CSV output of items of type
+// T. The other formats encode the items themselves.
+type Column[T any] struct {
+	// Name is the CSV header of the column, upper cased in tables.
+	Name  string
+	Value func(T) string
+	// Render returns the table cell of item styled with r, Value if nil.
+	Render func(item T, r *lipgloss.Renderer) string
+	// Flex columns are truncated to fit tables in the terminal width.
+	Flex bool
+}
+
+func (c Column[T]) cell(item T, r *lipgloss.Renderer) string {
+	if c.Render != nil {
+		return c.Render(item, r)
+	}
+	return c.Value(item)
+}
+
+// Writer writes items to an output in a Format.
+type Writer struct {
+	out    io.Writer
+	format Format
+	r      *lipgloss.Renderer
+	// width is the width of the terminal tables are fitted in, 0 if out
+	// is no terminal.
+	width int
+}
+
+// NewWriter returns a Writer of f to out. Tables are colored, unless
+// NO_COLOR is set, and fitted to the terminal width if out is a terminal.
+func NewWriter(out io.Writer, f Format) *Writer {
+	w := &Writer{out: out, format: f, r: lipgloss.NewRenderer(out)}
+	file, isFile := out.(*os.File)
+	tty := isFile && term.IsTerminal(file.Fd())
+	if tty {
+		if width, _, err := term.GetSize(file.Fd()); err == nil {
+			w.width = width
+		}
+	}
+	if !tty || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		w.r.SetColorProfile(termenv.Ascii)
+	}
+	return w
+}
+
+// Format returns the format of w.
+func (w *Writer) Format() Format {
+	return w.format
+}
+
+// Renderer returns the renderer styling the tables of w, without colors
+// if they are disabled.
+func (w *Writer) Renderer() *lipgloss.Renderer {
+	return w.r
+}
+
+// Printf writes text meant for people, such as a title, in table output
+// only, so that other formats stay parsable.
+func (w *Writer) Printf(format string, args ...any) {
+	if w.format.Name == Table {
+		fmt.Fprintf(w.out, format, args...)
+	}
+}
+
+// Write writes items, as rows of columns in tables, plain text and CSV.
+func Write[T any](w *Writer, columns []Column[T], items []T) error {
+	if items == nil {
+		// Encode no items as [] rather than null.
+		items = []T{}
+	}
+	switch w.format.Name {
+	case Table:
+		return w.table(header(columns, w.r), rows(columns, items, w.r), flex(columns))
+	case Plain:
+		for _, row := range rows(columns, items, nil) {
+			if _, err := fmt.Fprintln(w.out, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		cw := csv.NewWriter(w.out)
+		names := make([]string, len(columns))
+		for i, c := range columns {
+			names[i] = c.Name
+		}
+		cw.Write(names)
+		for _, item := range items {
+			record := make([]string, len(columns))
+			for i, c := range columns {
+				record[i] = c.Value(item)
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	case NDJSON, Template:
+		for _, item := range items {
+			if err := w.encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return w.encode(items)
+}
+
+// WriteOne writes a single item, as a column per line in tables and plain
+// text.
+func WriteOne[T any](w *Writer, columns []Column[T], item T) error {
+	switch w.format.Name {
+	case Table, Plain:
+		var table [][]string
+		for _, c := range columns {
+			name := strings.ToUpper(c.Name)
+			if w.format.Name == Table {
+				name = w.r.NewStyle().Bold(true).Render(name)
+				table = append(table, []string{name, singleLine(c.cell(item, w.r))})
+			} else {
+				table = append(table, []string{name, singleLine(c.Value(item))})
+			}
+		}
+		if w.format.Name == Plain {
+			for _, row := range table {
+				if _, err := fmt.Fprintln(w.out, strings.Join(row, "\t")); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return w.table(nil, table, []bool{false, true})
+	case CSV:
+		return Write(w, columns, []T{item})
+	}
+	return w.encode(item)
+}
+
+// encode writes v in the JSON, NDJSON, YAML or template format of w.
+func (w *Writer) encode(v any) error {
+	switch w.format.Name {
+	case JSON:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w.out, "%s\n", b)
+		return err
+	case NDJSON:
+		return json.NewEncoder(w.out).Encode(v)
+	case YAML:
+		// Encode the JSON form, so that keys are the same in both.
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic any
+		if err := json.Unmarshal(b, &generic); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w.out)
+		enc.SetIndent(2)
+		if err := enc.Encode(generic); err != nil {
+			return err
+		}
+		return enc.Close()
+	case Template:
+		var buf bytes.Buffer
+		if err := w.format.tmpl.Execute(&buf, v); err != nil {
+			return fmt.Errorf("failed to execute output template: %w", err)
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err := w.out.Write(buf.Bytes())
+		return err
+	}
+	return fmt.Errorf("cannot write %T as %s", v, w.format.Name)
+}
+
+func header[T any](columns []Column[T], r *lipgloss.Renderer) []string {
+	style := r.NewStyle().Bold(true)
+	cells := make([]string, len(columns))
+	for i, c := range columns {
+		cells[i] = style.Render(strings.ToUpper(c.Name))
+	}
+	return cells
+}
+
+// rows returns the cells of items, styled with r unless nil.
+func rows[T any](columns []Column[T], items []T, r *lipgloss.Renderer) [][]string {
+	table := make([][]string, len(items))
+	for i, item := range items {
+		row := make([]string, len(columns))
+		for j, c := range columns {
+			if r != nil {
+				row[j] = singleLine(c.cell(item, r))
+			} else {
+				row[j] = singleLine(c.Value(item))
+			}
+		}
+		table[i] = row
+	}
+	return table
+}
+
+func flex[T any](columns []Column[T]) []bool {
+	f := make([]bool, len(columns))
+	for i, c := range columns {
+		f[i] = c.Flex
+	}
+	return f
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// columnGap separates the columns of tables.
+const columnGap = "  "
+
+// minFlexWidth is the width flex columns are not truncated below.
+const minFlexWidth = 12
+
+// table writes header, if not nil, and rows aligned in columns, truncating
+// the flex ones to fit the terminal width.
+func (w *Writer) table(header []string, rows [][]string, flex []bool) error {
+	all := rows
+	if header != nil {
+		all = append([][]string{header}, rows...)
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	widths := make([]int, len(all[0]))
+	for _, row := range all {
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
+	}
+
+	if w.width > 0 {
+		total := len(columnGap) * (len(widths) - 1)
+		for _, width := range widths {
+			total += width
+		}
+		// Shrink the widest flex column until the table fits.
+		for total > w.width {
+			widest := -1
+			for i, width := range widths {
+				if flex[i] && width > minFlexWidth && (widest < 0 || width > widths[widest]) {
+					widest = i
+				}
+			}
+			if widest < 0 {
+				break
+			}
+			shrink := min(total-w.width, widths[widest]-minFlexWidth)
+			widths[widest] -= shrink
+			total -= shrink
+		}
+	}
+
+	var b strings.Builder
+	for _, row := range all {
+		for i, cell := range row {
+			if ansi.StringWidth(cell) > widths[i] {
+				cell = ansi.Truncate(cell, widths[i], "…")
+			}
+			b.WriteString(cell)
+			// The last column is not padded, leaving no trailing spaces.
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-ansi.StringWidth(cell)) + columnGap)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w.out, b.String())
+	return err
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name"`
+	Note string `json:"note"`
+}
+
+var columns = []Column[item]{
+	{Name: "name", Value: func(i item) string { return i.Name }},
+	{Name: "note", Value: func(i item) string { return i.Note }, Flex: true},
+}
+
+var items = []item{{"milk", "two bottles"}, {"rent", "pay the landlord before the first of the month"}}
+
+func write(t *testing.T, format string, width int) string {
+	t.Helper()
+	f, err := ParseFormat(format)
+	if err != nil {
+		t.Fatalf("ParseFormat(%q) error = %v", format, err)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, f)
+	w.width = width
+	if err := Write(w, columns, items); err != nil {
+		t.Fatalf("Write(%s) error = %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"xml", "template", "template={{.Name"} {
+		if _, err := ParseFormat(s); err == nil {
+			t.Errorf("ParseFormat(%q) accepted", s)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format string
+		width  int
+		want   string
+	}{
+		{Table, 0, "NAME  NOTE\nmilk  two bottles\nrent  pay the landlord before the first of the month\n"},
+		// The flex column is truncated to fit the width.
+		{Table, 30, "NAME  NOTE\nmilk  two bottles\nrent  pay the landlord before…\n"},
+		{Plain, 0, "milk\ttwo bottles\nrent\tpay the landlord before the first of the month\n"},
+		{CSV, 0, "name,note\nmilk,two bottles\nrent,pay the landlord before the first of the month\n"},
+		{NDJSON, 0, `{"name":"milk","note":"two bottles"}` + "\n" + `{"name":"rent","note":"pay the landlord before the first of the month"}` + "\n"},
+		{YAML, 0, "- name: milk\n  note: two bottles\n- name: rent\n  note: pay the landlord before the first of the month\n"},
+		{"template={{upper .Name}}: {{.Note}}", 0, "MILK: two bottles\nRENT: pay the landlord before the first of the month\n"},
+	}
+	for _, tt := range tests {
+		if got := write(t, tt.format, tt.width); got != tt.want {
+			t.Errorf("Write(%s, width %d) = %q, want %q", tt.format, tt.width, got, tt.want)
+		}
+	}
+
+	if got := write(t, JSON, 0); !strings.HasPrefix(got, "[\n  {\n    \"name\": \"milk\"") {
+		t.Errorf("Write(json) = %q", got)
+	}
+}
+
+func TestWriteOne(t *testing.T) {
+	f, _ := ParseFormat(Table)
+	var buf bytes.Buffer
+	if err := WriteOne(NewWriter(&buf, f), columns, item{"milk", "two\nbottles"}); err != nil {
+		t.Fatalf("WriteOne() error = %v", err)
+	}
+	if want := "NAME  milk\nNOTE  two bottles\n"; buf.String() != want {
+		t.Errorf("WriteOne() = %q, want %q", buf.String(), want)
+	}
+
+	f, _ = ParseFormat(JSON)
+	buf.Reset()
+	if err := Write[item](NewWriter(&buf, f), columns, nil); err != nil {
+		t.Fatalf("Write(nil) error = %v", err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("Write(nil) = %q, want []", buf.String())
+	}
+}
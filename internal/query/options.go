@@ -0,0 +1,73 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SortFields are the fields tasks can be sorted by. created is the order
+// tasks were added to goot in.
+var SortFields = []string{"due", "priority", "created", "modified", "title"}
+
+// GroupFields are the fields tasks can be grouped by. list groups tasks by
+// the APIs they are synced with, due by day.
+var GroupFields = []string{"list", "due", "tag", "completed"}
+
+// SortKey orders tasks by Field, one of SortFields.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Options order, group and page the tasks matching a query.
+type Options struct {
+	// Sort orders tasks by the first key, then by the next ones. Tasks are
+	// ordered pending first, by due date, if Sort is empty.
+	Sort []SortKey
+	// GroupBy is one of GroupFields, "" not to group tasks.
+	GroupBy string
+	// Limit is the maximum number of tasks of every group, unlimited if 0,
+	// after skipping the first Offset ones.
+	Limit, Offset int
+}
+
+// ParseSort parses sort keys separated by commas, such as "due,-priority".
+// Keys prefixed with '-' or suffixed with ":desc" sort in descending order.
+func ParseSort(s string) ([]SortKey, error) {
+	var keys []SortKey
+	for field := range strings.SplitSeq(s, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		var key SortKey
+		if f, ok := strings.CutPrefix(field, "-"); ok {
+			field, key.Desc = f, true
+		} else if f, dir, ok := strings.Cut(field, ":"); ok {
+			switch dir {
+			case "asc":
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction '%s', want asc or desc", dir)
+			}
+			field = f
+		}
+		if !slices.Contains(SortFields, field) {
+			return nil, fmt.Errorf("invalid sort key '%s', want one of %s", field, strings.Join(SortFields, ", "))
+		}
+		key.Field = field
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ParseGroup validates the field tasks are grouped by, "" for none.
+func ParseGroup(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s != "" && !slices.Contains(GroupFields, s) {
+		return "", fmt.Errorf("invalid group '%s', want one of %s", s, strings.Join(GroupFields, ", "))
+	}
+	return s, nil
+}
@@ -0,0 +1,525 @@
+// Package query parses the task query language of 'goot list -q', such as
+//
+//	due<=tomorrow and not completed and tag:work or priority>=medium
+//
+// A query is made of conditions combined with 'and', 'or', 'not' and
+// parentheses; 'and' binds tighter than 'or' and may be left out. The
+// conditions are:
+//
+//   - due, compared with <, <=, =, !=, >= or > to a date such as today,
+//     tomorrow, yesterday, now, friday (the next one, today included),
+//     2026-11-01, 2026-11-01T09:00, +3d or -1w (days, weeks, months or
+//     years from today), or none. A date stands for the whole day, so that
+//     due<=tomorrow includes tasks due tomorrow evening.
+//   - priority, compared to none, low, medium, high or 0 to 3
+//   - tag:name, a task tagged name
+//   - api:name, a task synced with the API name
+//   - title:text and description:text, containing text, or title=text for
+//     an exact title
+//   - completed and recurring, optionally compared to true or false
+//   - any other word or "quoted text", contained in the title or the
+//     description
+//   - @name, the named view name
+//
+// Names of fields, keywords and values are case insensitive.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+// ErrSyntax is returned for queries that cannot be parsed.
+var ErrSyntax = errors.New("invalid query")
+
+// DefaultViews are the named views available without configuration. Views
+// of the config file override them.
+var DefaultViews = map[string]string{
+	"today":     "due<=today and not completed",
+	"overdue":   "due<today and not completed",
+	"upcoming":  "due>today and due<=+7d and not completed",
+	"someday":   "due=none and not completed",
+	"completed": "completed",
+}
+
+// Expr is a parsed query, one of And, Or, Not, Due, Priority, Tag, API,
+// Text and Flag.
+type Expr interface {
+	String() string
+}
+
+type And struct{ Left, Right Expr }
+
+type Or struct{ Left, Right Expr }
+
+type Not struct{ X Expr }
+
+// Op is a comparison operator.
+type Op string
+
+const (
+	Eq Op = "="
+	Ne Op = "!="
+	Lt Op = "<"
+	Le Op = "<="
+	Gt Op = ">"
+	Ge Op = ">="
+)
+
+// Due compares the due date of tasks to the time span [From, To). Tasks
+// without due date only match Due{Op: Eq} with zero From and To.
+type Due struct {
+	Op       Op
+	From, To time.Time
+}
+
+type Priority struct {
+	Op    Op
+	Value tasks.Priority
+}
+
+type Tag struct{ Name string }
+
+type API struct{ Name string }
+
+// Text matches tasks whose Field, "title", "description" or "" for both,
+// contains Value, or equals it if Exact.
+type Text struct {
+	Field string
+	Value string
+	Exact bool
+}
+
+// Flag matches tasks whose boolean Field, "completed" or "recurring", is
+// set.
+type Flag struct{ Field string }
+
+func (e And) String() string { return "(" + e.Left.String() + " and " + e.Right.String() + ")" }
+func (e Or) String() string  { return "(" + e.Left.String() + " or " + e.Right.String() + ")" }
+func (e Not) String() string { return "not " + e.X.String() }
+
+func (e Due) String() string {
+	if e.From.IsZero() {
+		return "due" + string(e.Op) + "none"
+	}
+	return fmt.Sprintf("due%s[%s,%s)", e.Op, e.From.Format(wallLayout), e.To.Format(wallLayout))
+}
+
+func (e Priority) String() string { return "priority" + string(e.Op) + e.Value.String() }
+func (e Tag) String() string      { return "tag:" + e.Name }
+func (e API) String() string      { return "api:" + e.Name }
+func (e Flag) String() string     { return e.Field }
+
+func (e Text) String() string {
+	field := e.Field
+	if field == "" {
+		field = "text"
+	}
+	if e.Exact {
+		return field + "=" + strconv.Quote(e.Value)
+	}
+	return field + ":" + strconv.Quote(e.Value)
+}
+
+// wallLayout formats due dates by their wall clock, the way they compare.
+const wallLayout = "2006-01-02T15:04:05"
+
+// WallClock formats t as its wall clock time, ignoring its location. Due
+// dates are compared that way, as goot stores dates without time as
+// midnight UTC.
+func WallClock(t time.Time) string {
+	return t.Format(wallLayout)
+}
+
+// now returns the current time, replaced in tests.
+var now = time.Now
+
+// Parse parses query q, expanding the views it references by name.
+func Parse(q string, views map[string]string) (Expr, error) {
+	p := &parser{src: q, views: views}
+	e, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("%w '%s': %v", ErrSyntax, q, err)
+	}
+	return e, nil
+}
+
+type parser struct {
+	src   string
+	pos   int
+	views map[string]string
+	// expanding are the views being expanded, to detect cycles.
+	expanding []string
+}
+
+func (p *parser) parse() (Expr, error) {
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	p.space()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected '%s'", p.src[p.pos:])
+	}
+	return e, nil
+}
+
+func (p *parser) or() (Expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		explicit := p.keyword("and")
+		p.space()
+		if !explicit && (p.pos == len(p.src) || p.src[p.pos] == ')' || p.peekKeyword("or")) {
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.keyword("not") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Expr, error) {
+	p.space()
+	if p.pos == len(p.src) {
+		return nil, errors.New("unexpected end")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '(':
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		p.space()
+		if p.pos == len(p.src) || p.src[p.pos] != ')' {
+			return nil, errors.New("missing ')'")
+		}
+		p.pos++
+		return e, nil
+	case c == ')':
+		return nil, errors.New("unexpected ')'")
+	case c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return Text{Value: s}, nil
+	case c == '@':
+		p.pos++
+		return p.view(p.word())
+	}
+
+	name := p.word()
+	if name == "" {
+		return nil, fmt.Errorf("unexpected '%s'", p.src[p.pos:])
+	}
+	op, ok := p.op()
+	if !ok {
+		switch field := strings.ToLower(name); field {
+		case "completed", "done", "recurring":
+			return flag(field, true), nil
+		case "and", "or":
+			return nil, fmt.Errorf("unexpected '%s'", name)
+		}
+		return Text{Value: name}, nil
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return condition(strings.ToLower(name), op, value)
+}
+
+func (p *parser) view(name string) (Expr, error) {
+	if name == "" {
+		return nil, errors.New("missing view name after '@'")
+	}
+	// Viper lowercases the keys of the config file.
+	name = strings.ToLower(name)
+	q, ok := p.views[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown view '@%s', want one of %s", name, strings.Join(ViewNames(p.views), ", "))
+	}
+	if slices.Contains(p.expanding, name) {
+		return nil, fmt.Errorf("view '@%s' references itself", name)
+	}
+	sub := &parser{src: q, views: p.views, expanding: append(slices.Clone(p.expanding), name)}
+	e, err := sub.parse()
+	if err != nil {
+		return nil, fmt.Errorf("view '@%s': %w", name, err)
+	}
+	return e, nil
+}
+
+// ViewNames returns the sorted names of views, prefixed with '@'.
+func ViewNames(views map[string]string) []string {
+	names := slices.Sorted(maps.Keys(views))
+	for i, name := range names {
+		names[i] = "@" + name
+	}
+	return names
+}
+
+func (p *parser) space() {
+	for p.pos < len(p.src) && isSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// isSpace reports whether c is an ASCII space. Bytes of multi-byte UTF-8
+// characters never are.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// isWordEnd reports whether c ends a word: spaces, parentheses, quotes
+// and operators.
+func isWordEnd(c byte) bool {
+	return isSpace(c) || strings.IndexByte(`()":=!<>`, c) >= 0
+}
+
+func (p *parser) word() string {
+	start := p.pos
+	for p.pos < len(p.src) && !isWordEnd(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) peekKeyword(kw string) bool {
+	p.space()
+	end := p.pos + len(kw)
+	// Keywords are followed by a space or a parenthesis, so that fields
+	// named like them, as in "not:x", are no keywords.
+	return end <= len(p.src) && strings.EqualFold(p.src[p.pos:end], kw) &&
+		(end == len(p.src) || isSpace(p.src[end]) || strings.IndexByte(`()"`, p.src[end]) >= 0)
+}
+
+func (p *parser) keyword(kw string) bool {
+	if !p.peekKeyword(kw) {
+		return false
+	}
+	p.pos += len(kw)
+	return true
+}
+
+func (p *parser) op() (Op, bool) {
+	for _, op := range []string{"<=", ">=", "!=", "<", ">", "=", ":"} {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			p.pos += len(op)
+			if op == ":" {
+				return "", true
+			}
+			return Op(op), true
+		}
+	}
+	return "", false
+}
+
+// value reads the value of a condition, which ends at a space or a ')'
+// unless quoted.
+func (p *parser) value() (string, error) {
+	if p.pos < len(p.src) && p.src[p.pos] == '"' {
+		return p.quoted()
+	}
+	start := p.pos
+	for p.pos < len(p.src) && !isSpace(p.src[p.pos]) && p.src[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", errors.New("missing value")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *parser) quoted() (string, error) {
+	end := strings.IndexByte(p.src[p.pos+1:], '"')
+	if end < 0 {
+		return "", errors.New("missing closing '\"'")
+	}
+	s := p.src[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+	return s, nil
+}
+
+// condition builds the condition name op value. op is "" for ':'.
+func condition(name string, op Op, value string) (Expr, error) {
+	switch name {
+	case "due":
+		if op == "" {
+			op = Eq
+		}
+		return due(op, value)
+	case "priority":
+		if op == "" {
+			op = Eq
+		}
+		prio, err := priority(value)
+		if err != nil {
+			return nil, err
+		}
+		return Priority{op, prio}, nil
+	case "tag", "tags":
+		return negate(op, Tag{value})
+	case "api":
+		return negate(op, API{value})
+	case "title", "description", "desc":
+		if name == "desc" {
+			name = "description"
+		}
+		if op == "" {
+			return Text{Field: name, Value: value}, nil
+		}
+		return negate(op, Text{Field: name, Value: value, Exact: true})
+	case "completed", "done", "recurring":
+		if op != "" && op != Eq && op != Ne {
+			return nil, fmt.Errorf("cannot compare %s with '%s'", name, op)
+		}
+		b, err := strconv.ParseBool(strings.ToLower(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s', want true or false", name, value)
+		}
+		return flag(name, b == (op != Ne)), nil
+	}
+	return nil, fmt.Errorf("unknown field '%s'", name)
+}
+
+func flag(name string, set bool) Expr {
+	if name == "done" {
+		name = "completed"
+	}
+	if !set {
+		return Not{Flag{name}}
+	}
+	return Flag{name}
+}
+
+// negate returns e for the operators ':' and '=', and not e for '!='.
+func negate(op Op, e Expr) (Expr, error) {
+	switch op {
+	case "", Eq:
+		return e, nil
+	case Ne:
+		return Not{e}, nil
+	}
+	return nil, fmt.Errorf("cannot compare %s with '%s'", e, op)
+}
+
+func priority(value string) (tasks.Priority, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < int(tasks.PriorityNone) || n > int(tasks.PriorityHigh) {
+			return 0, fmt.Errorf("invalid priority %d, want 0 to 3", n)
+		}
+		return tasks.Priority(n), nil
+	}
+	return tasks.ParsePriority(strings.ToLower(value))
+}
+
+func due(op Op, value string) (Expr, error) {
+	if strings.EqualFold(value, "none") {
+		switch op {
+		case Eq:
+			return Due{Op: Eq}, nil
+		case Ne:
+			return Not{Due{Op: Eq}}, nil
+		}
+		return nil, fmt.Errorf("cannot compare due with '%s' none", op)
+	}
+	from, to, err := span(value)
+	if err != nil {
+		return nil, err
+	}
+	return Due{Op: op, From: from, To: to}, nil
+}
+
+// span returns the time span a due date value stands for: a whole day
+// for dates, a second for times.
+func span(value string) (from, to time.Time, err error) {
+	t := now()
+	today := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	day := func(d time.Time) (time.Time, time.Time, error) {
+		return d, d.AddDate(0, 0, 1), nil
+	}
+
+	v := strings.ToLower(value)
+	switch v {
+	case "now":
+		at := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+		return at, at.Add(time.Second), nil
+	case "today":
+		return day(today)
+	case "tomorrow":
+		return day(today.AddDate(0, 0, 1))
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1))
+	}
+	if wd, err := timeutil.ParseWeekDay(v); err == nil {
+		return day(today.AddDate(0, 0, (int(wd)-int(today.Weekday())+7)%7))
+	}
+	if d, err := time.Parse("2006-01-02", v); err == nil {
+		return day(d)
+	}
+	for _, layout := range []string{"2006-01-02t15:04", "2006-01-02t15:04:05", "2006-01-02 15:04"} {
+		if at, err := time.Parse(layout, v); err == nil {
+			return at, at.Add(time.Second), nil
+		}
+	}
+	if len(v) >= 3 && (v[0] == '+' || v[0] == '-') {
+		unit := strings.TrimLeft(v[1:], "0123456789")
+		if n, err := strconv.Atoi(v[1 : len(v)-len(unit)]); err == nil {
+			if v[0] == '-' {
+				n = -n
+			}
+			switch unit {
+			case "d":
+				return day(today.AddDate(0, 0, n))
+			case "w":
+				return day(today.AddDate(0, 0, 7*n))
+			case "m":
+				return day(today.AddDate(0, n, 0))
+			case "y":
+				return day(today.AddDate(n, 0, 0))
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid due date '%s'", value)
+}
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// A Wednesday.
+	now = func() time.Time { return time.Date(2026, 10, 21, 14, 30, 0, 0, time.Local) }
+	t.Cleanup(func() { now = time.Now })
+
+	views := map[string]string{"today": "due<=today and not completed", "work": "tag:work"}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"completed", "completed"},
+		{"not done", "not completed"},
+		{"completed=false", "not completed"},
+		{"due<=tomorrow", "due<=[2026-10-22T00:00:00,2026-10-23T00:00:00)"},
+		{"due=2026-11-01", "due=[2026-11-01T00:00:00,2026-11-02T00:00:00)"},
+		{"due>2026-11-01T09:00", "due>[2026-11-01T09:00:00,2026-11-01T09:00:01)"},
+		{"due<now", "due<[2026-10-21T14:30:00,2026-10-21T14:30:01)"},
+		{"due:friday", "due=[2026-10-23T00:00:00,2026-10-24T00:00:00)"},
+		{"due=wed", "due=[2026-10-21T00:00:00,2026-10-22T00:00:00)"},
+		{"due>=+1w", "due>=[2026-10-28T00:00:00,2026-10-29T00:00:00)"},
+		{"due<-2d", "due<[2026-10-19T00:00:00,2026-10-20T00:00:00)"},
+		{"due=none", "due=none"},
+		{"due!=none", "not due=none"},
+		{"priority>=2", "priority>=medium"},
+		{"Priority:HIGH", "priority=high"},
+		{"tag:work", "tag:work"},
+		{"tag!=home", "not tag:home"},
+		{"api:todoist", "api:todoist"},
+		{"title:rent", `title:"rent"`},
+		{"desc=x", `description="x"`},
+		{`"water the plants"`, `text:"water the plants"`},
+		{`title:"pay rent"`, `title:"pay rent"`},
+		{"milk eggs", `(text:"milk" and text:"eggs")`},
+		// 'and' binds tighter than 'or'.
+		{"due<=tomorrow and not completed and tag:work or priority>=2",
+			"(((due<=[2026-10-22T00:00:00,2026-10-23T00:00:00) and not completed) and tag:work) or priority>=medium)"},
+		{"tag:a and (tag:b OR tag:c)", "(tag:a and (tag:b or tag:c))"},
+		{"not(completed)", "not completed"},
+		{"@work", "tag:work"},
+		{"@Today and @work", "((due<=[2026-10-21T00:00:00,2026-10-22T00:00:00) and not completed) and tag:work)"},
+		// Words starting like keywords are no keywords.
+		{"order", `text:"order"`},
+		{"note:x or nothing", ""},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.query, views)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("Parse(%q) = %s, want error", tt.query, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.query, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	views := map[string]string{"loop": "tag:a or @loop", "bad": "due<"}
+	for _, q := range []string{
+		"",
+		"(tag:a",
+		"tag:a)",
+		"tag:a and",
+		"or tag:a",
+		"due<=someday",
+		"priority>urgent",
+		"tag<work",
+		"completed<true",
+		"due<none",
+		"color:red",
+		`title:"unterminated`,
+		"@missing",
+		"@loop",
+		"@bad",
+	} {
+		if e, err := Parse(q, views); err == nil {
+			t.Errorf("Parse(%q) = %s, want error", q, e)
+		} else if !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) error = %v, want ErrSyntax", q, err)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	got, err := ParseSort("due, -Priority,title:desc,created:asc")
+	if err != nil {
+		t.Fatalf("ParseSort() error = %v", err)
+	}
+	want := []SortKey{{"due", false}, {"priority", true}, {"title", true}, {"created", false}}
+	if !slices.Equal(got, want) {
+		t.Errorf("ParseSort() = %v, want %v", got, want)
+	}
+	for _, s := range []string{"color", "due:up", "-"} {
+		if _, err := ParseSort(s); err == nil {
+			t.Errorf("ParseSort(%q) accepted", s)
+		}
+	}
+
+	if g, err := ParseGroup("Tag"); err != nil || g != "tag" {
+		t.Errorf("ParseGroup(Tag) = %q, %v", g, err)
+	}
+	if _, err := ParseGroup("project"); err == nil {
+		t.Error("ParseGroup(project) accepted")
+	}
+}
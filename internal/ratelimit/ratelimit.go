@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket that adapts to throttling: Throttle halves its
+// rate and pauses it, and every Success recovers a twentieth of the
+// configured rate.
+type Limiter struct {
+	mu sync.Mutex
+
+	maxRate float64
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	paused  time.Time
+
+	now func() time.Time
+}
+
+// minRateDivisor bounds how far repeated throttling can lower the rate.
+const minRateDivisor = 64
+
+// New returns a limiter allowing rate requests per second on average and
+// bursts of up to burst requests. A rate of 0 or less disables limiting.
+func New(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		maxRate: rate,
+		rate:    rate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		now:     time.Now,
+	}
+}
+
+// Wait blocks until a request may be made or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil || l.maxRate <= 0 {
+		return nil
+	}
+
+	for {
+		delay := l.reserve()
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token and returns 0, or returns how long to wait before
+// trying again.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.paused) {
+		return l.paused.Sub(now)
+	}
+
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Throttle records a rate limited response. Requests are paused for
+// retryAfter, or one second if zero, and the rate is halved.
+func (l *Limiter) Throttle(retryAfter time.Duration) {
+	if l == nil || l.maxRate <= 0 {
+		return
+	}
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.paused = l.now().Add(retryAfter)
+	l.rate = max(l.rate/2, l.maxRate/minRateDivisor)
+	l.tokens = 0
+}
+
+// Success records a response that was not rate limited.
+func (l *Limiter) Success() {
+	if l == nil || l.maxRate <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = min(l.maxRate, l.rate+l.maxRate/20)
+}
+
+// Rate returns the current rate in requests per second.
+func (l *Limiter) Rate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/zeerodex/goot/internal/metrics"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(2, 2)
+	l.now = func() time.Time { return now }
+
+	for i := range 2 {
+		if d := l.reserve(); d != 0 {
+			t.Fatalf("reserve() #%d = %v, want burst to pass", i, d)
+		}
+	}
+	if d := l.reserve(); d != 500*time.Millisecond {
+		t.Errorf("reserve() after burst = %v, want %v", d, 500*time.Millisecond)
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if d := l.reserve(); d != 0 {
+		t.Errorf("reserve() after refill = %v, want 0", d)
+	}
+
+	l.Throttle(3 * time.Second)
+	if got := l.Rate(); got != 1 {
+		t.Errorf("Rate() after Throttle = %v, want 1", got)
+	}
+	if d := l.reserve(); d != 3*time.Second {
+		t.Errorf("reserve() while paused = %v, want %v", d, 3*time.Second)
+	}
+
+	for range 30 {
+		l.Success()
+	}
+	if got := l.Rate(); got != 2 {
+		t.Errorf("Rate() after successes = %v, want 2", got)
+	}
+}
+
+func TestTransportRetriesThrottled(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt %d body = %q, want %q", calls, body, "payload")
+		}
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	l := New(100, 1)
+	client := WrapClient(srv.Client(), l, "test", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	retries := testutil.ToFloat64(metrics.APIRetries.WithLabelValues("test"))
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Errorf("got status %d after %d calls, want 200 after 2", resp.StatusCode, calls)
+	}
+	if got := l.Rate(); got >= 100 {
+		t.Errorf("Rate() = %v, want it lowered after 429", got)
+	}
+	if got := testutil.ToFloat64(metrics.APIRetries.WithLabelValues("test")) - retries; got != 1 {
+		t.Errorf("APIRetries increased by %v, want 1", got)
+	}
+}
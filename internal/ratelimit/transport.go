@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
+)
+
+// maxRetries is how many times a throttled request is retried.
+const maxRetries = 3
+
+// Transport is an http.RoundTripper that waits for Limiter before every
+// request and retries requests answered with 429 Too Many Requests.
+type Transport struct {
+	Base    http.RoundTripper
+	Limiter *Limiter
+	// API labels the retries counted in metrics.APIRetries.
+	API string
+	Log *slog.Logger
+}
+
+// WrapClient makes client wait for limiter before its requests to api.
+func WrapClient(client *http.Client, limiter *Limiter, api string, logger *slog.Logger) *http.Client {
+	c := *client
+	c.Transport = &Transport{Base: client.Transport, Limiter: limiter, API: api, Log: logger}
+	return &c
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := t.Limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusTooManyRequests {
+			t.Limiter.Success()
+			return resp, nil
+		}
+
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+		t.Limiter.Throttle(retryAfter)
+		logging.FromContext(req.Context(), t.Log).Warn("rate limited",
+			"url", req.URL.Redacted(), "retry_after", retryAfter, "attempt", attempt+1)
+
+		if attempt == maxRetries {
+			return resp, nil
+		}
+		retry, ok := rewind(req)
+		if !ok {
+			return resp, nil
+		}
+		resp.Body.Close()
+		metrics.APIRetries.WithLabelValues(t.API).Inc()
+		req = retry
+	}
+}
+
+// rewind returns a copy of req with a fresh body for another attempt, or
+// false if the body cannot be read again.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, true
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
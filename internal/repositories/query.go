@@ -0,0 +1,289 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zeerodex/goot/internal/query"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// dueWall is the due date of tasks by its wall clock, see query.WallClock.
+// Tasks without due date store the zero time.
+const (
+	dueWall = "COALESCE(substr(tasks.due, 1, 19), '')"
+	noDue   = "0001-01-01T00:00:00"
+)
+
+// listsQuery lists the APIs tasks are synced with, the lists they are in.
+const listsQuery = `SELECT id AS task_id, 'gtasks' AS api FROM tasks WHERE COALESCE(google_id, '') <> ''
+	UNION ALL SELECT id, 'todoist' FROM tasks WHERE COALESCE(todoist_id, '') <> ''
+	UNION ALL SELECT task_id, api FROM task_api_ids`
+
+// groupings join the tasks table to the values tasks are grouped by, as
+// key, and order the groups. Tasks with several values, such as tags, are
+// in several groups.
+var groupings = map[string]struct{ join, key, order string }{
+	"": {key: "''"},
+	"completed": {
+		key: "CASE WHEN tasks.completed THEN 'completed' ELSE 'pending' END",
+		// Pending tasks first.
+		order: "group_key DESC",
+	},
+	"due": {key: "CASE WHEN " + dueWall + " > '" + noDue + "' THEN substr(tasks.due, 1, 10) ELSE '' END"},
+	"tag": {
+		join: "LEFT JOIN json_each(CASE WHEN tasks.tags LIKE '[%' THEN tasks.tags ELSE '[]' END) AS tag",
+		key:  "COALESCE(tag.value, '')",
+	},
+	"list": {
+		join: "LEFT JOIN (" + listsQuery + ") AS list ON list.task_id = tasks.id",
+		key:  "COALESCE(list.api, '')",
+	},
+}
+
+// ListTasks returns the tasks matching filter, all of them if filter is
+// nil, in the groups of opts.GroupBy or in a single group with key "".
+// Groups have the number of their tasks matching filter, even if the page
+// of opts holds none of them.
+func (r *taskRepository) ListTasks(filter query.Expr, opts query.Options) ([]tasks.Group, error) {
+	grouping, ok := groupings[opts.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid group '%s'", opts.GroupBy)
+	}
+	order, err := orderBy(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	where := "1"
+	var args []any
+	if filter != nil {
+		if where, err = whereClause(filter, &args); err != nil {
+			return nil, err
+		}
+	}
+	from := "FROM tasks " + grouping.join + " WHERE tasks.deleted = 0 AND " + where
+	groupOrder := grouping.order
+	if groupOrder == "" {
+		// Tasks without value last.
+		groupOrder = "group_key = '', group_key COLLATE NOCASE"
+	}
+
+	rows, err := r.db.Query("SELECT group_key, COUNT(*) FROM (SELECT "+grouping.key+" AS group_key "+from+") GROUP BY group_key ORDER BY "+groupOrder, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	defer rows.Close()
+	var groups []tasks.Group
+	index := make(map[string]int)
+	for rows.Next() {
+		var g tasks.Group
+		if err := rows.Scan(&g.Key, &g.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan task count row: %w", err)
+		}
+		index[g.Key] = len(groups)
+		groups = append(groups, g)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating task count rows: %w", err)
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err = r.db.Query(`SELECT id, google_id, todoist_id, title, description, due, priority, recurrence, tags, completed, notified, last_modified, deleted, group_key FROM (
+		SELECT tasks.id, tasks.google_id, tasks.todoist_id, tasks.title, tasks.description, tasks.due, tasks.priority, tasks.recurrence, tasks.tags, tasks.completed, tasks.notified, tasks.last_modified, tasks.deleted,
+			`+grouping.key+` AS group_key, ROW_NUMBER() OVER (PARTITION BY `+grouping.key+` ORDER BY `+order+`) AS group_row `+from+`)
+		WHERE group_row > ? AND (? < 0 OR group_row <= ?) ORDER BY group_row`,
+		append(args, opts.Offset, limit, opts.Offset+limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var all tasks.Tasks
+	var keys []string
+	for rows.Next() {
+		var task tasks.Task
+		var dueStr, lastModifiedStr, tagsStr, key string
+		if err := rows.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Priority, &task.Recurrence, &tagsStr, &task.Completed, &task.Notified, &lastModifiedStr, &task.Deleted, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan task row: %w", err)
+		}
+		if err := task.SetDueAndLastModified(dueStr, lastModifiedStr); err != nil {
+			return nil, fmt.Errorf("failed to set due/last_modified for task ID %d: %w", task.ID, err)
+		}
+		if task.Tags, err = decodeTags(tagsStr); err != nil {
+			return nil, fmt.Errorf("invalid tags of task ID %d: %w", task.ID, err)
+		}
+		all = append(all, task)
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating task rows: %w", err)
+	}
+	if err = r.loadMappedAPIIDs(all); err != nil {
+		return nil, err
+	}
+	for i, task := range all {
+		g := &groups[index[keys[i]]]
+		g.Tasks = append(g.Tasks, task)
+	}
+	return groups, nil
+}
+
+// orderBy converts sort keys to an ORDER BY clause. Tasks without due date
+// sort last by due date in both directions, and tasks equal by all keys in
+// the order they were created.
+func orderBy(keys []query.SortKey) (string, error) {
+	if len(keys) == 0 {
+		return "tasks.completed, tasks.due, tasks.id", nil
+	}
+	var terms []string
+	for _, k := range keys {
+		dir := ""
+		if k.Desc {
+			dir = " DESC"
+		}
+		switch k.Field {
+		case "due":
+			terms = append(terms, dueWall+" <= '"+noDue+"'", dueWall+dir)
+		case "priority":
+			terms = append(terms, "tasks.priority"+dir)
+		case "created":
+			terms = append(terms, "tasks.id"+dir)
+		case "modified":
+			terms = append(terms, "tasks.last_modified"+dir)
+		case "title":
+			terms = append(terms, "tasks.title COLLATE NOCASE"+dir)
+		default:
+			return "", fmt.Errorf("invalid sort key '%s'", k.Field)
+		}
+	}
+	return strings.Join(append(terms, "tasks.id"), ", "), nil
+}
+
+// whereClause converts q to an SQL condition on the tasks table, appending
+// its parameters to args.
+func whereClause(q query.Expr, args *[]any) (string, error) {
+	switch e := q.(type) {
+	case query.And, query.Or:
+		var left, right query.Expr
+		op := " AND "
+		if and, ok := e.(query.And); ok {
+			left, right = and.Left, and.Right
+		} else {
+			left, right, op = e.(query.Or).Left, e.(query.Or).Right, " OR "
+		}
+		l, err := whereClause(left, args)
+		if err != nil {
+			return "", err
+		}
+		r, err := whereClause(right, args)
+		if err != nil {
+			return "", err
+		}
+		return "(" + l + op + r + ")", nil
+	case query.Not:
+		x, err := whereClause(e.X, args)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + x, nil
+
+	case query.Due:
+		if e.From.IsZero() {
+			*args = append(*args, noDue)
+			return "(" + dueWall + " <= ?)", nil
+		}
+		var cond string
+		switch e.Op {
+		case query.Eq:
+			cond = dueWall + " >= ? AND " + dueWall + " < ?"
+			*args = append(*args, query.WallClock(e.From), query.WallClock(e.To))
+		case query.Ne:
+			cond = "(" + dueWall + " < ? OR " + dueWall + " >= ?)"
+			*args = append(*args, query.WallClock(e.From), query.WallClock(e.To))
+		case query.Lt:
+			cond = dueWall + " < ?"
+			*args = append(*args, query.WallClock(e.From))
+		case query.Le:
+			cond = dueWall + " < ?"
+			*args = append(*args, query.WallClock(e.To))
+		case query.Gt:
+			cond = dueWall + " >= ?"
+			*args = append(*args, query.WallClock(e.To))
+		case query.Ge:
+			cond = dueWall + " >= ?"
+			*args = append(*args, query.WallClock(e.From))
+		default:
+			return "", fmt.Errorf("invalid due operator '%s'", e.Op)
+		}
+		// Tasks without due date match no date comparison.
+		*args = append(*args, noDue)
+		return "(" + cond + " AND " + dueWall + " > ?)", nil
+
+	case query.Priority:
+		switch e.Op {
+		case query.Eq, query.Ne, query.Lt, query.Le, query.Gt, query.Ge:
+		default:
+			return "", fmt.Errorf("invalid priority operator '%s'", e.Op)
+		}
+		op := string(e.Op)
+		if e.Op == query.Ne {
+			op = "<>"
+		}
+		*args = append(*args, int(e.Value))
+		return "priority " + op + " ?", nil
+
+	case query.Tag:
+		*args = append(*args, e.Name)
+		return "EXISTS (SELECT 1 FROM json_each(CASE WHEN tags LIKE '[%' THEN tags ELSE '[]' END) WHERE value = ? COLLATE NOCASE)", nil
+
+	case query.API:
+		if column, ok := apiIDColumns[e.Name]; ok {
+			return "COALESCE(" + column + ", '') <> ''", nil
+		}
+		*args = append(*args, e.Name)
+		return "EXISTS (SELECT 1 FROM task_api_ids WHERE task_id = tasks.id AND api = ?)", nil
+
+	case query.Text:
+		var columns []string
+		switch e.Field {
+		case "":
+			columns = []string{"title", "description"}
+		case "title", "description":
+			columns = []string{e.Field}
+		default:
+			return "", fmt.Errorf("invalid text field '%s'", e.Field)
+		}
+		conds := make([]string, len(columns))
+		for i, column := range columns {
+			if e.Exact {
+				conds[i] = column + " = ? COLLATE NOCASE"
+				*args = append(*args, e.Value)
+			} else {
+				conds[i] = column + ` LIKE ? ESCAPE '\'`
+				*args = append(*args, "%"+escapeLike(e.Value)+"%")
+			}
+		}
+		return "(" + strings.Join(conds, " OR ") + ")", nil
+
+	case query.Flag:
+		switch e.Field {
+		case "completed":
+			return "completed = 1", nil
+		case "recurring":
+			return "COALESCE(recurrence, '') <> ''", nil
+		}
+		return "", fmt.Errorf("invalid flag '%s'", e.Field)
+	}
+	return "", fmt.Errorf("unsupported query '%s'", q)
+}
+
+// escapeLike escapes the wildcards of LIKE patterns.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
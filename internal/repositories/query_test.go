@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/query"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func titles(ts tasks.Tasks) []string {
+	var titles []string
+	for _, task := range ts {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+// createQueryTasks creates tasks of every kind queries tell apart.
+func createQueryTasks(t *testing.T, repo TaskRepository) time.Time {
+	t.Helper()
+
+	// goot stores dates without time as midnight UTC.
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	caldav := tasks.Task{Title: "Dentist", Due: today.AddDate(0, 0, 1).Add(17 * time.Hour), Tags: []string{"Health"}}
+	caldav.SetAPIID("caldav", "c1")
+	for _, task := range []tasks.Task{
+		{Title: "Pay rent", Due: today.AddDate(0, 0, -2), Priority: tasks.PriorityHigh, Tags: []string{"home", "money"}, Recurrence: "FREQ=MONTHLY"},
+		{Title: "Write report", Due: today, Priority: tasks.PriorityMedium, Tags: []string{"work"}, TodoistID: "t1"},
+		caldav,
+		{Title: "Read a book", Description: "50% of it"},
+		{Title: "Call mom", Due: today.AddDate(0, 0, -1), Completed: true},
+	} {
+		if _, err := repo.CreateTask(&task); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+	return today
+}
+
+func TestListTasksFilter(t *testing.T) {
+	repo := newTestRepository(t)
+	createQueryTasks(t, repo)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"not completed", []string{"Read a book", "Pay rent", "Write report", "Dentist"}},
+		{"due<=today and not completed", []string{"Pay rent", "Write report"}},
+		// Completed tasks come last.
+		{"due<=tomorrow", []string{"Pay rent", "Write report", "Dentist", "Call mom"}},
+		{"due=tomorrow", []string{"Dentist"}},
+		{"due>today", []string{"Dentist"}},
+		{"due!=today", []string{"Pay rent", "Dentist", "Call mom"}},
+		{"due=none", []string{"Read a book"}},
+		{"due<now", []string{"Pay rent", "Write report", "Call mom"}},
+		{"priority>=medium", []string{"Pay rent", "Write report"}},
+		{"priority!=none and not completed", []string{"Pay rent", "Write report"}},
+		{"tag:work or tag:health", []string{"Write report", "Dentist"}},
+		{"not tag:money", []string{"Read a book", "Write report", "Dentist", "Call mom"}},
+		{"api:todoist", []string{"Write report"}},
+		{"api:caldav", []string{"Dentist"}},
+		{"recurring", []string{"Pay rent"}},
+		{"title:RE", []string{"Read a book", "Pay rent", "Write report"}},
+		{`title="call MOM"`, []string{"Call mom"}},
+		{`"50%"`, []string{"Read a book"}},
+		{`"5_%"`, nil},
+		{"@overdue", []string{"Pay rent"}},
+	}
+	for _, tt := range tests {
+		q, err := query.Parse(tt.query, query.DefaultViews)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.query, err)
+		}
+		groups, err := repo.ListTasks(q, query.Options{})
+		if err != nil {
+			t.Errorf("ListTasks(%q) error = %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, g := range groups {
+			got = append(got, titles(g.Tasks)...)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ListTasks(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestListTasksSortGroupPage(t *testing.T) {
+	repo := newTestRepository(t)
+	today := createQueryTasks(t, repo)
+
+	list := func(opts query.Options) []tasks.Group {
+		t.Helper()
+		groups, err := repo.ListTasks(nil, opts)
+		if err != nil {
+			t.Fatalf("ListTasks(%+v) error = %v", opts, err)
+		}
+		return groups
+	}
+	sorted := func(sort string) []string {
+		t.Helper()
+		keys, err := query.ParseSort(sort)
+		if err != nil {
+			t.Fatalf("ParseSort(%q) error = %v", sort, err)
+		}
+		groups := list(query.Options{Sort: keys})
+		if len(groups) != 1 || groups[0].Key != "" || groups[0].Count != 5 {
+			t.Fatalf("ListTasks(sort %q) groups = %+v", sort, groups)
+		}
+		return titles(groups[0].Tasks)
+	}
+
+	sorts := []struct {
+		sort string
+		want []string
+	}{
+		{"", []string{"Read a book", "Pay rent", "Write report", "Dentist", "Call mom"}},
+		// Tasks without due date last in both directions.
+		{"due", []string{"Pay rent", "Call mom", "Write report", "Dentist", "Read a book"}},
+		{"-due", []string{"Dentist", "Write report", "Call mom", "Pay rent", "Read a book"}},
+		{"priority:desc,title", []string{"Pay rent", "Write report", "Call mom", "Dentist", "Read a book"}},
+		{"created", []string{"Pay rent", "Write report", "Dentist", "Read a book", "Call mom"}},
+		{"-created", []string{"Call mom", "Read a book", "Dentist", "Write report", "Pay rent"}},
+		{"title", []string{"Call mom", "Dentist", "Pay rent", "Read a book", "Write report"}},
+	}
+	for _, tt := range sorts {
+		if got := sorted(tt.sort); !slices.Equal(got, tt.want) {
+			t.Errorf("ListTasks(sort %q) = %q, want %q", tt.sort, got, tt.want)
+		}
+	}
+
+	type group struct {
+		key    string
+		count  int
+		titles []string
+	}
+	groups := []struct {
+		opts query.Options
+		want []group
+	}{
+		{query.Options{GroupBy: "completed"}, []group{
+			{"pending", 4, []string{"Read a book", "Pay rent", "Write report", "Dentist"}},
+			{"completed", 1, []string{"Call mom"}},
+		}},
+		{query.Options{GroupBy: "tag", Sort: []query.SortKey{{Field: "title"}}}, []group{
+			{"Health", 1, []string{"Dentist"}},
+			{"home", 1, []string{"Pay rent"}},
+			{"money", 1, []string{"Pay rent"}},
+			{"work", 1, []string{"Write report"}},
+			{"", 2, []string{"Call mom", "Read a book"}},
+		}},
+		{query.Options{GroupBy: "list"}, []group{
+			{"caldav", 1, []string{"Dentist"}},
+			{"todoist", 1, []string{"Write report"}},
+			{"", 3, []string{"Read a book", "Pay rent", "Call mom"}},
+		}},
+		{query.Options{GroupBy: "due", Limit: 1}, []group{
+			{today.AddDate(0, 0, -2).Format("2006-01-02"), 1, []string{"Pay rent"}},
+			{today.AddDate(0, 0, -1).Format("2006-01-02"), 1, []string{"Call mom"}},
+			{today.Format("2006-01-02"), 1, []string{"Write report"}},
+			{today.AddDate(0, 0, 1).Format("2006-01-02"), 1, []string{"Dentist"}},
+			{"", 1, []string{"Read a book"}},
+		}},
+		// Pages apply to every group, which keeps its count.
+		{query.Options{GroupBy: "completed", Limit: 2, Offset: 1}, []group{
+			{"pending", 4, []string{"Pay rent", "Write report"}},
+			{"completed", 1, nil},
+		}},
+		{query.Options{Limit: 2, Offset: 4}, []group{
+			{"", 5, []string{"Call mom"}},
+		}},
+	}
+	for _, tt := range groups {
+		var got []group
+		for _, g := range list(tt.opts) {
+			got = append(got, group{g.Key, g.Count, titles(g.Tasks)})
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ListTasks(%+v) = %+v, want %+v", tt.opts, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].key != tt.want[i].key || got[i].count != tt.want[i].count || !slices.Equal(got[i].titles, tt.want[i].titles) {
+				t.Errorf("ListTasks(%+v) group %d = %+v, want %+v", tt.opts, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -2,15 +2,21 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/zeerodex/goot/internal/query"
 	"github.com/zeerodex/goot/internal/tasks"
 )
 
 var ErrTaskNotFound = errors.New("task not found")
 
+// ErrNoAPIID is returned for tasks that do not exist in the requested API.
+var ErrNoAPIID = errors.New("task has no ID in this API")
+
 type TaskRepository interface {
 	CreateTask(task *tasks.Task) (*tasks.Task, error)
 
@@ -20,6 +26,8 @@ type TaskRepository interface {
 	GetTaskByDue(due time.Time) (*tasks.Task, error)
 	GetAllPendingTasks(minTime, maxTime time.Time) (tasks.Tasks, error)
 	GetAllDeletedTasks() (tasks.Tasks, error)
+	SearchTasks(query string, limit int) ([]tasks.SearchResult, error)
+	ListTasks(filter query.Expr, opts query.Options) ([]tasks.Group, error)
 
 	GetTaskGoogleID(id int) (string, error)
 	GetTaskIDByGoogleID(googleId string) (int, error)
@@ -39,15 +47,16 @@ type TaskRepository interface {
 }
 
 type taskRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	log *slog.Logger
 }
 
-func NewTaskRepository(db *sql.DB) TaskRepository {
-	return &taskRepository{db: db}
+func NewTaskRepository(db *sql.DB, logger *slog.Logger) TaskRepository {
+	return &taskRepository{db: db, log: logger.With("component", "repository")}
 }
 
 func (r *taskRepository) CreateTask(task *tasks.Task) (*tasks.Task, error) {
-	stmt, err := r.db.Prepare("INSERT INTO tasks (google_id, todoist_id, title, description, due, completed, last_modified) VALUES (?, ?, ?, ?, ?, ?, ?)")
+	stmt, err := r.db.Prepare("INSERT INTO tasks (google_id, todoist_id, title, description, due, priority, recurrence, tags, completed, last_modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare create task statement: %w", err)
 	}
@@ -59,6 +68,9 @@ func (r *taskRepository) CreateTask(task *tasks.Task) (*tasks.Task, error) {
 		task.Title,
 		task.Description,
 		task.Due.Format(time.RFC3339),
+		task.Priority,
+		task.Recurrence,
+		encodeTags(task.Tags),
 		task.Completed,
 		time.Now().UTC().Format(time.RFC3339),
 	)
@@ -71,11 +83,17 @@ func (r *taskRepository) CreateTask(task *tasks.Task) (*tasks.Task, error) {
 		return nil, fmt.Errorf("failed to retrieve last insert ID for task '%s': %w", task.Title, err)
 	}
 	task.ID = int(id)
+	for apiName, apiID := range task.APIIDs {
+		if err := r.setMappedAPIID(task.ID, apiName, apiID); err != nil {
+			return nil, err
+		}
+	}
+	r.log.Debug("task created", "task_id", task.ID, "google_id", task.GoogleID, "todoist_id", task.TodoistID)
 	return task, nil
 }
 
 func (r *taskRepository) GetAllTasks() (tasks.Tasks, error) {
-	rows, err := r.db.Query("SELECT id, google_id, todoist_id, title, description, due, completed, notified, last_modified, deleted FROM tasks WHERE deleted = 0 ORDER BY completed, due")
+	rows, err := r.db.Query("SELECT id, google_id, todoist_id, title, description, due, priority, recurrence, tags, completed, notified, last_modified, deleted FROM tasks WHERE deleted = 0 ORDER BY completed, due")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query all tasks: %w", err)
 	}
@@ -86,22 +104,29 @@ func (r *taskRepository) GetAllTasks() (tasks.Tasks, error) {
 		var task tasks.Task
 		var dueStr string
 		var lastModifiedStr string
-		if err := rows.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Completed, &task.Notified, &lastModifiedStr, &task.Deleted); err != nil {
+		var tagsStr string
+		if err := rows.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Priority, &task.Recurrence, &tagsStr, &task.Completed, &task.Notified, &lastModifiedStr, &task.Deleted); err != nil {
 			return nil, fmt.Errorf("failed to scan task row: %w", err)
 		}
 		if err := task.SetDueAndLastModified(dueStr, lastModifiedStr); err != nil {
 			return nil, fmt.Errorf("failed to set due/last_modified for task ID %d: %w", task.ID, err)
 		}
+		if task.Tags, err = decodeTags(tagsStr); err != nil {
+			return nil, fmt.Errorf("invalid tags of task ID %d: %w", task.ID, err)
+		}
 		tasksList = append(tasksList, task)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating task rows: %w", err)
 	}
+	if err = r.loadMappedAPIIDs(tasksList); err != nil {
+		return nil, err
+	}
 	return tasksList, nil
 }
 
 func (r *taskRepository) GetAllDeletedTasks() (tasks.Tasks, error) {
-	rows, err := r.db.Query("SELECT id, google_id, todoist_id, title, description, due, completed, notified, last_modified, deleted FROM tasks WHERE deleted = 1 ORDER BY completed, due")
+	rows, err := r.db.Query("SELECT id, google_id, todoist_id, title, description, due, priority, recurrence, tags, completed, notified, last_modified, deleted FROM tasks WHERE deleted = 1 ORDER BY completed, due")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query all tasks: %w", err)
 	}
@@ -112,27 +137,35 @@ func (r *taskRepository) GetAllDeletedTasks() (tasks.Tasks, error) {
 		var task tasks.Task
 		var dueStr string
 		var lastModifiedStr string
-		if err := rows.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Completed, &task.Notified, &lastModifiedStr, &task.Deleted); err != nil {
+		var tagsStr string
+		if err := rows.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Priority, &task.Recurrence, &tagsStr, &task.Completed, &task.Notified, &lastModifiedStr, &task.Deleted); err != nil {
 			return nil, fmt.Errorf("failed to scan task row: %w", err)
 		}
 		if err := task.SetDueAndLastModified(dueStr, lastModifiedStr); err != nil {
 			return nil, fmt.Errorf("failed to set due/last_modified for task ID %d: %w", task.ID, err)
 		}
+		if task.Tags, err = decodeTags(tagsStr); err != nil {
+			return nil, fmt.Errorf("invalid tags of task ID %d: %w", task.ID, err)
+		}
 		tasksList = append(tasksList, task)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating task rows: %w", err)
 	}
+	if err = r.loadMappedAPIIDs(tasksList); err != nil {
+		return nil, err
+	}
 	return tasksList, nil
 }
 
 func (r *taskRepository) GetTaskByID(id int) (*tasks.Task, error) {
-	row := r.db.QueryRow("SELECT id, google_id, todoist_id, title, description, due, completed, notified, last_modified FROM tasks WHERE id = ?", id)
+	row := r.db.QueryRow("SELECT id, google_id, todoist_id, title, description, due, priority, recurrence, tags, completed, notified, last_modified, deleted FROM tasks WHERE id = ?", id)
 
 	var task tasks.Task
 	var dueStr string
 	var lastModifiedStr string
-	err := row.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Completed, &task.Notified, &lastModifiedStr)
+	var tagsStr string
+	err := row.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Priority, &task.Recurrence, &tagsStr, &task.Completed, &task.Notified, &lastModifiedStr, &task.Deleted)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("task with ID %d not found: %w", id, ErrTaskNotFound)
@@ -142,7 +175,14 @@ func (r *taskRepository) GetTaskByID(id int) (*tasks.Task, error) {
 	if err := task.SetDueAndLastModified(dueStr, lastModifiedStr); err != nil {
 		return nil, fmt.Errorf("failed to set due/last_modified for task ID %d: %w", id, err)
 	}
-	return &task, nil
+	if task.Tags, err = decodeTags(tagsStr); err != nil {
+		return nil, fmt.Errorf("invalid tags of task ID %d: %w", id, err)
+	}
+	ts := tasks.Tasks{task}
+	if err := r.loadMappedAPIIDs(ts); err != nil {
+		return nil, err
+	}
+	return &ts[0], nil
 }
 
 func (r *taskRepository) GetTaskByGoogleID(id string) (*tasks.Task, error) {
@@ -229,6 +269,9 @@ func (r *taskRepository) GetTaskGoogleID(id int) (string, error) {
 
 func (r *taskRepository) GetTaskAPIID(id int, apiName string) (string, error) {
 	field := GetAPIIDFieldName(apiName)
+	if field == "" {
+		return r.getMappedAPIID(id, apiName)
+	}
 	row := r.db.QueryRow(fmt.Sprintf("SELECT %s FROM tasks WHERE id = ?", field), id)
 
 	var googleId sql.NullString
@@ -239,8 +282,8 @@ func (r *taskRepository) GetTaskAPIID(id int, apiName string) (string, error) {
 		}
 		return "", fmt.Errorf("failed to retrieve %s for task ID %d: %w", field, id, err)
 	}
-	if !googleId.Valid {
-		return "", fmt.Errorf("%s is NULL for task ID %d", field, id)
+	if !googleId.Valid || googleId.String == "" {
+		return "", fmt.Errorf("%s is not set for task ID %d: %w", field, id, ErrNoAPIID)
 	}
 	return googleId.String, nil
 }
@@ -263,13 +306,13 @@ func (r *taskRepository) GetTaskIDByGoogleID(googleId string) (int, error) {
 }
 
 func (r *taskRepository) UpdateTask(task *tasks.Task) (*tasks.Task, error) {
-	stmt, err := r.db.Prepare("UPDATE tasks SET google_id = ?, title = ?, description = ?, due = ?, completed = ?, notified = ?, last_modified = ? WHERE id = ?")
+	stmt, err := r.db.Prepare("UPDATE tasks SET title = ?, description = ?, due = ?, priority = ?, recurrence = ?, tags = ?, completed = ?, notified = ?, last_modified = ? WHERE id = ?")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare update task statement: %w", err)
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(task.GoogleID, task.Title, task.Description, task.Due.Format(time.RFC3339), task.Completed, task.Notified, time.Now().UTC().Format(time.RFC3339), task.ID)
+	res, err := stmt.Exec(task.Title, task.Description, task.Due.Format(time.RFC3339), task.Priority, task.Recurrence, encodeTags(task.Tags), task.Completed, task.Notified, time.Now().UTC().Format(time.RFC3339), task.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute update task statement for ID %d: %w", task.ID, err)
 	}
@@ -280,6 +323,7 @@ func (r *taskRepository) UpdateTask(task *tasks.Task) (*tasks.Task, error) {
 	if rowsAffected == 0 {
 		return nil, fmt.Errorf("task with ID %d not found for update: %w", task.ID, ErrTaskNotFound)
 	}
+	r.log.Debug("task updated", "task_id", task.ID)
 	return task, nil
 }
 
@@ -301,11 +345,15 @@ func (r *taskRepository) UpdateGoogleID(id int, googleID string) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("task with ID %d not found for update", id)
 	}
+	r.log.Debug("task api id updated", "task_id", id, "google_id", googleID)
 	return nil
 }
 
 func (r *taskRepository) UpdateTaskAPIID(id int, apiId string, apiName string) error {
 	field := GetAPIIDFieldName(apiName)
+	if field == "" {
+		return r.setMappedAPIID(id, apiName, apiId)
+	}
 	stmt, err := r.db.Prepare(fmt.Sprintf("UPDATE tasks SET %s = ? WHERE id = ?", field))
 	if err != nil {
 		return fmt.Errorf("failed to prepare update task statement: %w", err)
@@ -323,6 +371,7 @@ func (r *taskRepository) UpdateTaskAPIID(id int, apiId string, apiName string) e
 	if rowsAffected == 0 {
 		return fmt.Errorf("task with ID %d not found for update", id)
 	}
+	r.log.Debug("task api id updated", "task_id", id, "api", apiName, "api_id", apiId)
 	return nil
 }
 
@@ -344,6 +393,7 @@ func (r *taskRepository) SetTaskCompleted(id int, completed bool) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("task with ID %d not found for set completed: %w", id, ErrTaskNotFound)
 	}
+	r.log.Debug("task completion set", "task_id", id, "completed", completed)
 	return nil
 }
 
@@ -365,6 +415,7 @@ func (r *taskRepository) MarkAsNotified(id int) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("task with ID %d not found for mark as notified: %w", id, ErrTaskNotFound)
 	}
+	r.log.Debug("task marked as notified", "task_id", id)
 	return nil
 }
 
@@ -386,6 +437,10 @@ func (r *taskRepository) DeleteTaskByID(id int) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("task with ID %d not found for deletion", id)
 	}
+	if _, err := r.db.Exec("DELETE FROM task_api_ids WHERE task_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete api ids of task ID %d: %w", id, err)
+	}
+	r.log.Debug("task deleted", "task_id", id)
 	return nil
 }
 
@@ -407,6 +462,7 @@ func (r *taskRepository) SoftDeleteTaskByID(id int) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("task with ID %d not found for soft deletion: %w", id, ErrTaskNotFound)
 	}
+	r.log.Debug("task soft deleted", "task_id", id)
 	return nil
 }
 
@@ -431,18 +487,88 @@ func (r *taskRepository) DeleteTaskByTitle(title string) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("task with title '%s' not found for deletion: %w", title, ErrTaskNotFound)
 	}
+	r.log.Debug("tasks deleted by title", "title", title, "count", rowsAffected)
 	return nil
 }
 
+// encodeTags stores tags as a JSON array, or "" if there are none.
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(tags)
+	return string(b)
+}
+
+func decodeTags(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var tags []string
+	err := json.Unmarshal([]byte(s), &tags)
+	return tags, err
+}
+
+// apiIDColumns are the tasks table columns holding the IDs of the first
+// supported APIs. IDs of other APIs are kept in task_api_ids.
+var apiIDColumns = map[string]string{
+	"gtasks":  "google_id",
+	"todoist": "todoist_id",
+}
+
+// GetAPIIDFieldName returns the tasks column holding the IDs of apiName, or
+// "" if they are kept in task_api_ids.
 func GetAPIIDFieldName(apiName string) string {
-	var field string
-	switch apiName {
-	case "todoist":
-		field = "todoist_id"
-	case "gtasks":
-		field = "google_id"
-	default:
-		field = ""
-	}
-	return field
+	return apiIDColumns[apiName]
+}
+
+func (r *taskRepository) getMappedAPIID(id int, apiName string) (string, error) {
+	var apiID string
+	err := r.db.QueryRow("SELECT api_id FROM task_api_ids WHERE task_id = ? AND api = ?", id, apiName).Scan(&apiID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%s ID of task ID %d not found: %w", apiName, id, ErrNoAPIID)
+		}
+		return "", fmt.Errorf("failed to retrieve %s ID for task ID %d: %w", apiName, id, err)
+	}
+	return apiID, nil
+}
+
+func (r *taskRepository) setMappedAPIID(id int, apiName, apiID string) error {
+	_, err := r.db.Exec(`INSERT INTO task_api_ids (task_id, api, api_id) VALUES (?, ?, ?)
+		ON CONFLICT (task_id, api) DO UPDATE SET api_id = excluded.api_id`, id, apiName, apiID)
+	if err != nil {
+		return fmt.Errorf("failed to set %s ID of task ID %d: %w", apiName, id, err)
+	}
+	r.log.Debug("task api id updated", "task_id", id, "api", apiName, "api_id", apiID)
+	return nil
+}
+
+// loadMappedAPIIDs fills the APIIDs of ts from task_api_ids.
+func (r *taskRepository) loadMappedAPIIDs(ts tasks.Tasks) error {
+	if len(ts) == 0 {
+		return nil
+	}
+	byID := make(map[int]*tasks.Task, len(ts))
+	for i := range ts {
+		byID[ts[i].ID] = &ts[i]
+	}
+
+	rows, err := r.db.Query("SELECT task_id, api, api_id FROM task_api_ids")
+	if err != nil {
+		return fmt.Errorf("failed to query api ids: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var apiName, apiID string
+		if err := rows.Scan(&id, &apiName, &apiID); err != nil {
+			return fmt.Errorf("failed to scan api id row: %w", err)
+		}
+		if t, ok := byID[id]; ok {
+			t.SetAPIID(apiName, apiID)
+		}
+	}
+	return rows.Err()
 }
@@ -0,0 +1,263 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// ErrInvalidQuery is returned for search queries without any term.
+var ErrInvalidQuery = errors.New("invalid search query")
+
+// queryTerm is a word or a quoted phrase of a search query.
+type queryTerm struct {
+	text string
+	// prefix terms, written with a trailing '*', match the words they start.
+	prefix bool
+	// op is "OR" or "NOT" for the operator between the previous term and
+	// this one, "" for AND.
+	op string
+}
+
+// parseQuery splits a search query into terms. Quotes need not be closed,
+// so that queries typed interactively are always valid.
+func parseQuery(q string) ([]queryTerm, error) {
+	var terms []queryTerm
+	op := ""
+	rest := strings.TrimSpace(q)
+	for rest != "" {
+		var text string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				text, rest = rest[1:], ""
+			} else {
+				text, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+			if (text == "OR" || text == "NOT") && len(terms) > 0 {
+				op = text
+				rest = strings.TrimSpace(rest)
+				continue
+			}
+		}
+		prefix := strings.HasPrefix(rest, "*") || strings.HasSuffix(text, "*")
+		rest = strings.TrimSpace(strings.TrimLeft(rest, "*"))
+		text = strings.TrimSpace(strings.TrimRight(text, "*"))
+		if text == "" {
+			continue
+		}
+		terms = append(terms, queryTerm{text: text, prefix: prefix, op: op})
+		op = ""
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("%w '%s'", ErrInvalidQuery, q)
+	}
+	return terms, nil
+}
+
+// ftsQuery converts terms to an FTS5 query. Every term is quoted, so that
+// punctuation in it is not read as FTS5 syntax.
+func ftsQuery(terms []queryTerm) string {
+	var b strings.Builder
+	for i, t := range terms {
+		if i > 0 {
+			b.WriteByte(' ')
+			if t.op != "" {
+				b.WriteString(t.op + " ")
+			}
+		}
+		b.WriteString(`"` + strings.ReplaceAll(t.text, `"`, `""`) + `"`)
+		if t.prefix {
+			b.WriteByte('*')
+		}
+	}
+	return b.String()
+}
+
+// SearchTasks returns the tasks whose title or description match query,
+// best matches first. query is made of words and quoted phrases, matched
+// as prefixes with a trailing '*' and combined with OR and NOT. limit is
+// the maximum number of results, unlimited if 0.
+func (r *taskRepository) SearchTasks(query string, limit int) ([]tasks.SearchResult, error) {
+	terms, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	if _, err := r.db.Exec("SELECT rowid FROM tasks_fts LIMIT 0"); err != nil {
+		r.log.Debug("full-text index unavailable, scanning tasks", "err", err)
+		return r.scanTasks(terms, limit)
+	}
+
+	rows, err := r.db.Query(`SELECT t.id, t.google_id, t.todoist_id, t.title, t.description, t.due, t.priority, t.recurrence, t.tags, t.completed, t.notified, t.last_modified, t.deleted,
+		snippet(tasks_fts, -1, ?, ?, '…', 12)
+		FROM tasks_fts JOIN tasks t ON t.id = tasks_fts.rowid
+		WHERE tasks_fts MATCH ? AND t.deleted = 0
+		ORDER BY bm25(tasks_fts, 5.0, 1.0) LIMIT ?`, tasks.HighlightStart, tasks.HighlightEnd, ftsQuery(terms), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var results []tasks.SearchResult
+	for rows.Next() {
+		var res tasks.SearchResult
+		task := &res.Task
+		var dueStr, lastModifiedStr, tagsStr string
+		if err := rows.Scan(&task.ID, &task.GoogleID, &task.TodoistID, &task.Title, &task.Description, &dueStr, &task.Priority, &task.Recurrence, &tagsStr, &task.Completed, &task.Notified, &lastModifiedStr, &task.Deleted, &res.Snippet); err != nil {
+			return nil, fmt.Errorf("failed to scan search result row: %w", err)
+		}
+		if err := task.SetDueAndLastModified(dueStr, lastModifiedStr); err != nil {
+			return nil, fmt.Errorf("failed to set due/last_modified for task ID %d: %w", task.ID, err)
+		}
+		if task.Tags, err = decodeTags(tagsStr); err != nil {
+			return nil, fmt.Errorf("invalid tags of task ID %d: %w", task.ID, err)
+		}
+		results = append(results, res)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search result rows: %w", err)
+	}
+
+	ts := make(tasks.Tasks, len(results))
+	for i := range results {
+		ts[i] = results[i].Task
+	}
+	if err := r.loadMappedAPIIDs(ts); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Task = ts[i]
+	}
+	return results, nil
+}
+
+// scanTasks is SearchTasks for sqlite builds without FTS5. Matches are
+// case-insensitive substrings, ranked by the number of terms found in the
+// title.
+func (r *taskRepository) scanTasks(terms []queryTerm, limit int) ([]tasks.SearchResult, error) {
+	all, err := r.GetAllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	type scored struct {
+		res   tasks.SearchResult
+		score int
+	}
+	var matches []scored
+	for _, task := range all {
+		title, desc := strings.ToLower(task.Title), strings.ToLower(task.Description)
+		ok, score := false, 0
+		for i, t := range terms {
+			text := strings.ToLower(t.text)
+			found := strings.Contains(title, text) || strings.Contains(desc, text)
+			switch {
+			case i == 0:
+				ok = found
+			case t.op == "OR":
+				ok = ok || found
+			case t.op == "NOT":
+				ok = ok && !found
+			default:
+				ok = ok && found
+			}
+			if t.op != "NOT" && strings.Contains(title, text) {
+				score++
+			}
+		}
+		if ok {
+			matches = append(matches, scored{tasks.SearchResult{Task: task, Snippet: snippet(task, terms)}, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return b.score - a.score })
+
+	var results []tasks.SearchResult
+	for _, m := range matches {
+		if limit > 0 && len(results) == limit {
+			break
+		}
+		results = append(results, m.res)
+	}
+	return results, nil
+}
+
+// snippetRunes is the length of the description excerpts of snippet.
+const snippetRunes = 60
+
+// snippet highlights terms in the title of task, or in an excerpt of its
+// description if the title matches none.
+func snippet(task tasks.Task, terms []queryTerm) string {
+	text := task.Title
+	if s, ok := highlight(text, terms); ok {
+		return s
+	}
+
+	text = strings.Join(strings.Fields(task.Description), " ")
+	start := len(text)
+	for _, t := range terms {
+		if i := indexFold(text, t.text); i >= 0 && i < start && t.op != "NOT" {
+			start = i
+		}
+	}
+	if start == len(text) {
+		return task.Title
+	}
+	// Start a few words before the first match.
+	runes := []rune(text)
+	from := max(len([]rune(text[:start]))-snippetRunes/4, 0)
+	to := min(from+snippetRunes, len(runes))
+	excerpt := string(runes[from:to])
+	if from > 0 {
+		excerpt = "…" + excerpt
+	}
+	if to < len(runes) {
+		excerpt += "…"
+	}
+	s, _ := highlight(excerpt, terms)
+	return s
+}
+
+// indexFold is strings.Index ignoring case.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+func highlight(text string, terms []queryTerm) (string, bool) {
+	found := false
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		matched := 0
+		for _, t := range terms {
+			if n := len(t.text); t.op != "NOT" && n > matched && i+n <= len(text) && strings.EqualFold(text[i:i+n], t.text) {
+				matched = n
+			}
+		}
+		if matched == 0 {
+			b.WriteByte(text[i])
+			i++
+			continue
+		}
+		found = true
+		b.WriteString(tasks.HighlightStart + text[i:i+matched] + tasks.HighlightEnd)
+		i += matched
+	}
+	return b.String(), found
+}
@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zeerodex/goot/internal/database"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`milk`, `"milk"`},
+		{`buy milk`, `"buy" "milk"`},
+		{`"water the plants" garden`, `"water the plants" "garden"`},
+		{`plan*`, `"plan"*`},
+		{`rent OR mortgage`, `"rent" OR "mortgage"`},
+		{`rent NOT paid`, `"rent" NOT "paid"`},
+		{`"unterminated phrase`, `"unterminated phrase"`},
+		{`say "hi`, `"say" "hi"`},
+		{`OR milk`, `"OR" "milk"`},
+		{`a"b`, `"a""b"`},
+	}
+	for _, tt := range tests {
+		terms, err := parseQuery(tt.query)
+		if err != nil {
+			t.Errorf("parseQuery(%q) error = %v", tt.query, err)
+			continue
+		}
+		if got := ftsQuery(terms); got != tt.want {
+			t.Errorf("ftsQuery(parseQuery(%q)) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, q := range []string{"", "  ", `""`, "*"} {
+		if _, err := parseQuery(q); err == nil {
+			t.Errorf("parseQuery(%q) accepted", q)
+		}
+	}
+}
+
+func newTestRepository(t *testing.T) TaskRepository {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("database.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewTaskRepository(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func searchTitles(t *testing.T, repo TaskRepository, query string) []string {
+	t.Helper()
+
+	results, err := repo.SearchTasks(query, 0)
+	if err != nil {
+		t.Fatalf("SearchTasks(%q) error = %v", query, err)
+	}
+	titles := make([]string, len(results))
+	for i, res := range results {
+		titles[i] = res.Task.Title
+	}
+	return titles
+}
+
+// TestSearchTasks runs against the FTS5 index when built with the
+// sqlite_fts5 tag, and against the fallback scan otherwise.
+func TestSearchTasks(t *testing.T) {
+	repo := newTestRepository(t)
+	for _, task := range []tasks.Task{
+		{Title: "Plan the garden", Description: "Water the plants before leaving"},
+		{Title: "Pay rent", Description: "Transfer to the landlord"},
+		{Title: "Buy plants", Description: "Basil and mint for the garden"},
+		{Title: "Call mom"},
+	} {
+		if _, err := repo.CreateTask(&task); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+		// unordered results are equally relevant, in any order.
+		unordered bool
+	}{
+		// Title matches rank first.
+		{"garden", []string{"Plan the garden", "Buy plants"}, false},
+		{"plan*", []string{"Plan the garden", "Buy plants"}, false},
+		{`"water the plants"`, []string{"Plan the garden"}, false},
+		{"rent OR mom", []string{"Call mom", "Pay rent"}, true},
+		{"garden NOT basil", []string{"Plan the garden"}, false},
+		{"landlord", []string{"Pay rent"}, false},
+		{"taxes", nil, false},
+	}
+	for _, tt := range tests {
+		got := searchTitles(t, repo, tt.query)
+		if tt.unordered {
+			slices.Sort(got)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SearchTasks(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	results, err := repo.SearchTasks("landlord", 0)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("SearchTasks(landlord) = %v, %v", results, err)
+	}
+	if want := tasks.HighlightStart + "landlord" + tasks.HighlightEnd; !strings.Contains(results[0].Snippet, want) {
+		t.Errorf("snippet %q does not highlight the match", results[0].Snippet)
+	}
+
+	if results, err := repo.SearchTasks("garden", 1); err != nil || len(results) != 1 {
+		t.Errorf("SearchTasks(garden, 1) = %d results, %v", len(results), err)
+	}
+}
+
+func TestSearchTasksFollowsChanges(t *testing.T) {
+	repo := newTestRepository(t)
+	task, err := repo.CreateTask(&tasks.Task{Title: "Renew passport"})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	task.Title = "Renew visa"
+	task.Description = "Bring the old passport"
+	if _, err := repo.UpdateTask(task); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	if got := searchTitles(t, repo, "visa"); !slices.Equal(got, []string{"Renew visa"}) {
+		t.Errorf("after update, SearchTasks(visa) = %q", got)
+	}
+	if got := searchTitles(t, repo, "passport"); !slices.Equal(got, []string{"Renew visa"}) {
+		t.Errorf("after update, SearchTasks(passport) = %q", got)
+	}
+
+	if err := repo.SoftDeleteTaskByID(task.ID); err != nil {
+		t.Fatalf("SoftDeleteTaskByID() error = %v", err)
+	}
+	if got := searchTitles(t, repo, "visa"); len(got) != 0 {
+		t.Errorf("after soft delete, SearchTasks(visa) = %q", got)
+	}
+	if err := repo.DeleteTaskByID(task.ID); err != nil {
+		t.Fatalf("DeleteTaskByID() error = %v", err)
+	}
+	if got := searchTitles(t, repo, "visa"); len(got) != 0 {
+		t.Errorf("after delete, SearchTasks(visa) = %q", got)
+	}
+}
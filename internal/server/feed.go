@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/calendar"
+)
+
+const (
+	feedPath = "/calendar.ics"
+
+	// feedRefresh is how often subscribed calendar apps are asked to fetch
+	// the feed again.
+	feedRefresh = 15 * time.Minute
+)
+
+// calendarFeed serves the tasks with due dates as an iCalendar feed that
+// calendar apps can subscribe to.
+func (srv *Server) calendarFeed(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+
+	opts := calendar.Options{Name: "goot", Refresh: feedRefresh}
+	switch q.Get("component") {
+	case "", "event":
+		opts.Events = true
+	case "todo":
+		opts.Todos = true
+	case "both":
+		opts.Events, opts.Todos = true, true
+	default:
+		return errorf(http.StatusBadRequest, "invalid component '%s', want event, todo or both", q.Get("component"))
+	}
+
+	var completed *bool
+	if v := q.Get("completed"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "invalid completed filter '%s'", v)
+		}
+		completed = &b
+	}
+	tags := q["tag"]
+	var provider *apis.Provider
+	if name := q.Get("api"); name != "" {
+		p, ok := apis.Lookup(name)
+		if !ok {
+			return errorf(http.StatusBadRequest, "unknown api '%s'", name)
+		}
+		provider = &p
+		opts.Name += " " + name
+	}
+
+	all, err := srv.s.GetAllTasks()
+	if err != nil {
+		return err
+	}
+	feed := all[:0]
+	for _, t := range all {
+		if completed != nil && t.Completed != *completed {
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(t.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			continue
+		}
+		if provider != nil && provider.ID(&t) == "" {
+			continue
+		}
+		feed = append(feed, t)
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.New(feed, opts).Encode(&buf); err != nil {
+		return err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
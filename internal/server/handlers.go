@@ -0,0 +1,494 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/repositories"
+	"github.com/zeerodex/goot/internal/tasks"
+	"github.com/zeerodex/goot/pkg/timeutil"
+)
+
+// Task is the API representation of a task.
+type Task struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Due         time.Time `json:"due,omitzero"`
+	// Priority is none, low, medium or high.
+	Priority string   `json:"priority"`
+	Tags     []string `json:"tags"`
+	// Recurrence is an iCalendar RRULE value such as "FREQ=WEEKLY".
+	Recurrence   string    `json:"recurrence,omitempty"`
+	Completed    bool      `json:"completed"`
+	LastModified time.Time `json:"last_modified"`
+	GoogleID     string    `json:"google_id,omitempty"`
+	TodoistID    string    `json:"todoist_id,omitempty"`
+	// APIIDs holds the IDs of the task in every API, keyed by API name.
+	APIIDs map[string]string `json:"api_ids,omitempty"`
+	// ETag is the ETag header of the task, to send back as If-Match.
+	ETag string `json:"etag"`
+}
+
+func newTask(t *tasks.Task) Task {
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	task := Task{
+		ID:           t.ID,
+		Title:        t.Title,
+		Description:  t.Description,
+		Due:          t.Due,
+		Priority:     t.Priority.String(),
+		Tags:         tags,
+		Recurrence:   t.Recurrence,
+		Completed:    t.Completed,
+		LastModified: t.LastModified,
+		GoogleID:     t.GoogleID,
+		TodoistID:    t.TodoistID,
+		APIIDs:       apis.IDs(t),
+	}
+	task.ETag = task.etag()
+	return task
+}
+
+// etag identifies the current version of the task, every field included.
+func (t Task) etag() string {
+	t.ETag = ""
+	b, _ := json.Marshal(t)
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+type taskList struct {
+	Tasks []Task `json:"tasks"`
+	Count int    `json:"count"`
+}
+
+type createTaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	// Due is RFC 3339 or any format accepted by 'goot add', today if empty.
+	Due        string   `json:"due,omitempty"`
+	Priority   string   `json:"priority,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Recurrence string   `json:"recurrence,omitempty"`
+}
+
+type patchTaskRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// Due is RFC 3339 or any format accepted by 'goot add', an empty string clears it.
+	Due      *string `json:"due,omitempty"`
+	Priority *string `json:"priority,omitempty"`
+	// Tags replace the tags of the task, an empty list clears them.
+	Tags *[]string `json:"tags,omitempty"`
+	// Recurrence is an RRULE value, an empty string clears it.
+	Recurrence *string `json:"recurrence,omitempty"`
+	Completed  *bool   `json:"completed,omitempty"`
+}
+
+type queueStatus struct {
+	Queued         int          `json:"queued"`
+	APIs           []string     `json:"apis"`
+	RecentFailures []jobFailure `json:"recent_failures"`
+}
+
+type accepted struct {
+	Status string `json:"status"`
+}
+
+func (srv *Server) routes() []route {
+	idParam := param{name: "id", in: "path", typ: "integer", desc: "Task ID", required: true}
+	ifMatch := param{name: "If-Match", in: "header", typ: "string", desc: "ETag the task must still have for the request to succeed"}
+
+	return []route{
+		{
+			method: "GET", path: "/tasks", summary: "List tasks",
+			params: []param{
+				{name: "completed", in: "query", typ: "boolean", desc: "Only completed or only uncompleted tasks"},
+				{name: "due_after", in: "query", typ: "string", desc: "Only tasks due at or after this time"},
+				{name: "due_before", in: "query", typ: "string", desc: "Only tasks due at or before this time"},
+				{name: "q", in: "query", typ: "string", desc: "Only tasks whose title or description contains this text"},
+				{name: "priority", in: "query", typ: "string", desc: "Only tasks with this priority: none, low, medium or high"},
+				{name: "tag", in: "query", typ: "string", desc: "Only tasks with one of these tags, may be repeated"},
+				{name: "recurring", in: "query", typ: "boolean", desc: "Only recurring or only non-recurring tasks"},
+			},
+			resp: taskList{}, status: http.StatusOK,
+			handler: srv.listTasks,
+		},
+		{
+			method: "GET", path: "/tasks/{id}", summary: "Get a task",
+			params: []param{idParam, {name: "If-None-Match", in: "header", typ: "string", desc: "Respond 304 if the task still has this ETag"}},
+			resp:   Task{}, status: http.StatusOK,
+			handler: srv.getTask,
+		},
+		{
+			method: "POST", path: "/tasks", summary: "Create a task",
+			body: createTaskRequest{}, resp: Task{}, status: http.StatusCreated,
+			handler: srv.createTask,
+		},
+		{
+			method: "PATCH", path: "/tasks/{id}", summary: "Update fields of a task",
+			params: []param{idParam, ifMatch},
+			body:   patchTaskRequest{}, resp: Task{}, status: http.StatusOK,
+			handler: srv.patchTask,
+		},
+		{
+			method: "POST", path: "/tasks/{id}/complete", summary: "Mark a task completed",
+			params: []param{idParam, ifMatch},
+			resp:   Task{}, status: http.StatusOK,
+			handler: srv.setCompleted(true),
+		},
+		{
+			method: "POST", path: "/tasks/{id}/reopen", summary: "Mark a task uncompleted",
+			params: []param{idParam, ifMatch},
+			resp:   Task{}, status: http.StatusOK,
+			handler: srv.setCompleted(false),
+		},
+		{
+			method: "DELETE", path: "/tasks/{id}", summary: "Delete a task",
+			params:  []param{idParam, ifMatch},
+			status:  http.StatusNoContent,
+			handler: srv.deleteTask,
+		},
+		{
+			method: "POST", path: "/sync", summary: "Queue a sync with every enabled API",
+			resp: accepted{}, status: http.StatusAccepted,
+			handler: srv.sync,
+		},
+		{
+			method: "GET", path: "/queue", summary: "Show the API job queue status",
+			resp: queueStatus{}, status: http.StatusOK,
+			handler: srv.queueStatus,
+		},
+		{
+			method: "GET", path: feedPath, summary: "Subscribe to the tasks with due dates as an iCalendar feed",
+			params: []param{
+				{name: "component", in: "query", typ: "string", desc: "Entries made for every task: event (default), todo or both"},
+				{name: "tag", in: "query", typ: "string", desc: "Only tasks with one of these tags, may be repeated"},
+				{name: "api", in: "query", typ: "string", desc: "Only tasks synced with this API"},
+				{name: "completed", in: "query", typ: "boolean", desc: "Only completed or only uncompleted tasks"},
+				{name: "token", in: "query", typ: "string", desc: "Bearer token, for calendar apps that cannot send headers"},
+			},
+			resp: "", mediaType: "text/calendar", status: http.StatusOK,
+			handler: srv.calendarFeed,
+		},
+	}
+}
+
+func (srv *Server) listTasks(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+
+	var completed *bool
+	if v := q.Get("completed"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "invalid completed filter '%s'", v)
+		}
+		completed = &b
+	}
+	var dueAfter, dueBefore time.Time
+	var err error
+	if v := q.Get("due_after"); v != "" {
+		if dueAfter, err = parseDue(v); err != nil {
+			return errorf(http.StatusBadRequest, "invalid due_after filter: %v", err)
+		}
+	}
+	if v := q.Get("due_before"); v != "" {
+		if dueBefore, err = parseDue(v); err != nil {
+			return errorf(http.StatusBadRequest, "invalid due_before filter: %v", err)
+		}
+	}
+	text := strings.ToLower(q.Get("q"))
+	var priority *tasks.Priority
+	if v := q.Get("priority"); v != "" {
+		p, err := tasks.ParsePriority(v)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "invalid priority filter: %v", err)
+		}
+		priority = &p
+	}
+	tags := q["tag"]
+	var recurring *bool
+	if v := q.Get("recurring"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "invalid recurring filter '%s'", v)
+		}
+		recurring = &b
+	}
+
+	all, err := srv.s.GetAllTasks()
+	if err != nil {
+		return err
+	}
+
+	list := taskList{Tasks: []Task{}}
+	for _, t := range all {
+		if completed != nil && t.Completed != *completed {
+			continue
+		}
+		if !dueAfter.IsZero() && t.Due.Before(dueAfter) {
+			continue
+		}
+		if !dueBefore.IsZero() && t.Due.After(dueBefore) {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(t.Title), text) && !strings.Contains(strings.ToLower(t.Description), text) {
+			continue
+		}
+		if priority != nil && t.Priority != *priority {
+			continue
+		}
+		if len(tags) > 0 && !slices.ContainsFunc(t.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+			continue
+		}
+		if recurring != nil && (t.Recurrence != "") != *recurring {
+			continue
+		}
+		list.Tasks = append(list.Tasks, newTask(&t))
+	}
+	list.Count = len(list.Tasks)
+
+	return writeJSON(w, http.StatusOK, list)
+}
+
+func (srv *Server) getTask(w http.ResponseWriter, r *http.Request) error {
+	task, err := srv.task(r)
+	if err != nil {
+		return err
+	}
+
+	etag := task.etag()
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return writeJSON(w, http.StatusOK, task)
+}
+
+func (srv *Server) createTask(w http.ResponseWriter, r *http.Request) error {
+	var req createTaskRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		return errorf(http.StatusBadRequest, "title is required")
+	}
+	if req.Due == "" {
+		req.Due = "today"
+	}
+	due, err := parseDue(req.Due)
+	if err != nil {
+		return errorf(http.StatusBadRequest, "invalid due: %v", err)
+	}
+
+	priority := tasks.PriorityNone
+	if req.Priority != "" {
+		if priority, err = tasks.ParsePriority(req.Priority); err != nil {
+			return errorf(http.StatusBadRequest, "%v", err)
+		}
+	}
+
+	created, err := srv.s.CreateTask(r.Context(), &tasks.Task{
+		Title:       req.Title,
+		Description: req.Description,
+		Due:         due,
+		Priority:    priority,
+		Tags:        req.Tags,
+		Recurrence:  req.Recurrence,
+	})
+	if err != nil {
+		return errorf(http.StatusUnprocessableEntity, "%v", err)
+	}
+
+	w.Header().Set("Location", basePath+"/tasks/"+strconv.Itoa(created.ID))
+	return srv.writeTask(w, created.ID, http.StatusCreated)
+}
+
+func (srv *Server) patchTask(w http.ResponseWriter, r *http.Request) error {
+	var req patchTaskRequest
+	if err := decodeBody(r, &req); err != nil {
+		return err
+	}
+
+	srv.writeMu.Lock()
+	defer srv.writeMu.Unlock()
+
+	current, err := srv.checkedTask(r)
+	if err != nil {
+		return err
+	}
+
+	t, err := srv.s.GetTaskByID(current.ID)
+	if err != nil {
+		return err
+	}
+	if req.Title != nil {
+		if strings.TrimSpace(*req.Title) == "" {
+			return errorf(http.StatusBadRequest, "title cannot be empty")
+		}
+		t.Title = *req.Title
+	}
+	if req.Description != nil {
+		t.Description = *req.Description
+	}
+	if req.Due != nil {
+		t.Due = time.Time{}
+		if *req.Due != "" {
+			if t.Due, err = parseDue(*req.Due); err != nil {
+				return errorf(http.StatusBadRequest, "invalid due: %v", err)
+			}
+		}
+	}
+
+	if req.Priority != nil {
+		if t.Priority, err = tasks.ParsePriority(*req.Priority); err != nil {
+			return errorf(http.StatusBadRequest, "%v", err)
+		}
+	}
+	if req.Tags != nil {
+		t.Tags = *req.Tags
+	}
+	if req.Recurrence != nil {
+		t.Recurrence = *req.Recurrence
+	}
+
+	if req.Title != nil || req.Description != nil || req.Due != nil ||
+		req.Priority != nil || req.Tags != nil || req.Recurrence != nil {
+		if _, err := srv.s.UpdateTask(r.Context(), t); err != nil {
+			return errorf(http.StatusUnprocessableEntity, "%v", err)
+		}
+	}
+	if req.Completed != nil && *req.Completed != t.Completed {
+		if err := srv.s.SetTaskCompleted(r.Context(), t.ID, *req.Completed); err != nil {
+			return err
+		}
+	}
+
+	return srv.writeTask(w, t.ID, http.StatusOK)
+}
+
+func (srv *Server) setCompleted(completed bool) handlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		srv.writeMu.Lock()
+		defer srv.writeMu.Unlock()
+
+		task, err := srv.checkedTask(r)
+		if err != nil {
+			return err
+		}
+		if task.Completed != completed {
+			if err := srv.s.SetTaskCompleted(r.Context(), task.ID, completed); err != nil {
+				return err
+			}
+		}
+		return srv.writeTask(w, task.ID, http.StatusOK)
+	}
+}
+
+func (srv *Server) deleteTask(w http.ResponseWriter, r *http.Request) error {
+	srv.writeMu.Lock()
+	defer srv.writeMu.Unlock()
+
+	task, err := srv.checkedTask(r)
+	if err != nil {
+		return err
+	}
+	if err := srv.s.DeleteTaskByID(r.Context(), task.ID); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (srv *Server) sync(w http.ResponseWriter, r *http.Request) error {
+	if err := srv.s.Sync(r.Context()); err != nil {
+		return errorf(http.StatusServiceUnavailable, "failed to queue sync: %v", err)
+	}
+	return writeJSON(w, http.StatusAccepted, accepted{Status: "queued"})
+}
+
+func (srv *Server) queueStatus(w http.ResponseWriter, r *http.Request) error {
+	srv.failuresMu.Lock()
+	failures := append([]jobFailure{}, srv.recentFailures...)
+	srv.failuresMu.Unlock()
+
+	return writeJSON(w, http.StatusOK, queueStatus{
+		Queued:         srv.s.WP().QueueLen(),
+		APIs:           srv.s.WP().APINames(),
+		RecentFailures: failures,
+	})
+}
+
+// task returns the task identified by the id path parameter.
+func (srv *Server) task(r *http.Request) (Task, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return Task{}, errorf(http.StatusBadRequest, "invalid task id '%s'", r.PathValue("id"))
+	}
+
+	t, err := srv.s.GetTaskByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTaskNotFound) {
+			return Task{}, errorf(http.StatusNotFound, "task %d not found", id)
+		}
+		return Task{}, err
+	}
+	if t.Deleted {
+		return Task{}, errorf(http.StatusNotFound, "task %d not found", id)
+	}
+	return newTask(t), nil
+}
+
+// checkedTask is like task but fails with 412 if the request has an If-Match
+// header not matching the current ETag of the task.
+func (srv *Server) checkedTask(r *http.Request) (Task, error) {
+	task, err := srv.task(r)
+	if err != nil {
+		return Task{}, err
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != task.etag() {
+		return Task{}, errorf(http.StatusPreconditionFailed, "task %d has been modified", task.ID)
+	}
+	return task, nil
+}
+
+// writeTask responds with the stored state of the task, which may differ from
+// the one passed to the service (e.g. its last modification time).
+func (srv *Server) writeTask(w http.ResponseWriter, id int, status int) error {
+	t, err := srv.s.GetTaskByID(id)
+	if err != nil {
+		return err
+	}
+	task := newTask(t)
+	w.Header().Set("ETag", task.etag())
+	return writeJSON(w, status, task)
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func parseDue(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return timeutil.ParseAndValidateTimestamp(s)
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// route describes an API endpoint. The same description registers the
+// handler and documents it in the OpenAPI document.
+type route struct {
+	method  string
+	path    string
+	summary string
+	params  []param
+	// body and resp are zero values of the request and response types.
+	body any
+	resp any
+	// mediaType is the type of resp, application/json if empty.
+	mediaType string
+	status    int
+	handler   handlerFunc
+}
+
+type param struct {
+	name     string
+	in       string
+	typ      string
+	desc     string
+	required bool
+}
+
+// openAPI generates an OpenAPI 3.1 document describing srv.routes.
+func (srv *Server) openAPI() map[string]any {
+	schemas := map[string]any{
+		"Error": schemaOf(reflect.TypeOf(errorResponse{}), nil),
+	}
+	paths := map[string]map[string]any{}
+
+	for _, rt := range srv.routes() {
+		op := map[string]any{
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+			"responses": map[string]any{
+				strconv.Itoa(rt.status): response(http.StatusText(rt.status), rt.mediaType, rt.resp, schemas),
+				"default":               response("Error", "", errorResponse{}, schemas),
+			},
+		}
+
+		if len(rt.params) > 0 {
+			var params []map[string]any
+			for _, p := range rt.params {
+				params = append(params, map[string]any{
+					"name":        p.name,
+					"in":          p.in,
+					"description": p.desc,
+					"required":    p.required,
+					"schema":      map[string]any{"type": p.typ},
+				})
+			}
+			op["parameters"] = params
+		}
+
+		if rt.body != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaRef(reflect.TypeOf(rt.body), schemas)},
+				},
+			}
+		}
+
+		path := basePath + rt.path
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "goot",
+			"version": apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []map[string]any{{"bearer": []string{}}},
+	}
+}
+
+func operationID(rt route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(rt.method))
+	for part := range strings.SplitSeq(strings.Trim(rt.path, "/"), "/") {
+		part = strings.Trim(part, "{}")
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func response(desc, mediaType string, v any, schemas map[string]any) map[string]any {
+	resp := map[string]any{"description": desc}
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+	if v != nil {
+		resp["content"] = map[string]any{
+			mediaType: map[string]any{"schema": schemaRef(reflect.TypeOf(v), schemas)},
+		}
+	}
+	return resp
+}
+
+// schemaRef returns a $ref to the schema of named struct types, registering
+// it in schemas, and an inline schema for every other type.
+func schemaRef(t reflect.Type, schemas map[string]any) map[string]any {
+	if t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) {
+		name := exportedName(t.Name())
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = nil
+			schemas[name] = schemaOf(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	return schemaOf(t, schemas)
+}
+
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.String:
+		return map[string]any{"type": "string"}
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case t.Kind() == reflect.Struct:
+		props := map[string]any{}
+		var required []string
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = schemaRef(f.Type, schemas)
+			if f.Type.Kind() != reflect.Pointer && !strings.Contains(opts, "omit") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]any{}
+}
+
+func exportedName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
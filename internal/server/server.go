@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/services"
+	"github.com/zeerodex/goot/internal/workers"
+)
+
+const (
+	apiVersion = "v1"
+	basePath   = "/" + apiVersion
+
+	maxRecentFailures = 10
+)
+
+// Server exposes a TaskService over a versioned JSON REST API.
+type Server struct {
+	s     services.TaskService
+	token string
+	log   *slog.Logger
+
+	// writeMu makes the If-Match check and the following write atomic.
+	writeMu sync.Mutex
+
+	failuresMu     sync.Mutex
+	recentFailures []jobFailure
+}
+
+func New(s services.TaskService, token string, logger *slog.Logger) *Server {
+	return &Server{s: s, token: token, log: logger.With("component", "server")}
+}
+
+// Handler returns the HTTP handler serving every route of the API.
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, rt := range srv.routes() {
+		mux.Handle(rt.method+" "+basePath+rt.path, srv.handle(rt))
+	}
+	mux.Handle("GET "+basePath+"/openapi.json", srv.handle(route{
+		handler: func(w http.ResponseWriter, r *http.Request) error {
+			return writeJSON(w, http.StatusOK, srv.openAPI())
+		},
+	}))
+	return srv.withAuth(mux)
+}
+
+// ListenAndServe serves the API on addr until ctx is done. addr is either a
+// loopback host:port or "unix:" followed by a socket path.
+func (srv *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := listen(addr, srv.token != "")
+	if err != nil {
+		return err
+	}
+
+	httpSrv := &http.Server{Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpSrv.Shutdown(shutdownCtx)
+	}()
+	go srv.collectResults()
+
+	srv.log.Info("api server listening", "addr", addr)
+	if err := httpSrv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("api server failed: %w", err)
+	}
+	return nil
+}
+
+func listen(addr string, hasToken bool) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale socket '%s': %w", path, err)
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on socket '%s': %w", path, err)
+		}
+		if err := os.Chmod(path, 0o600); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to restrict socket '%s' permissions: %w", path, err)
+		}
+		return ln, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address '%s': %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("refusing to listen on non-loopback address '%s'", addr)
+	}
+	if !hasToken {
+		return nil, errors.New("a token is required when listening on TCP, set server.token or use a unix socket")
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on '%s': %w", addr, err)
+	}
+	return ln, nil
+}
+
+func (srv *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if srv.token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			// Calendar apps subscribe to a URL and cannot send headers.
+			if !ok && r.URL.Path == basePath+feedPath {
+				token = r.URL.Query().Get("token")
+				ok = token != ""
+			}
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(srv.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="goot"`)
+				writeError(w, errorf(http.StatusUnauthorized, "invalid or missing bearer token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// collectResults drains the worker pool results, so that workers never block
+// on a full result queue, and keeps the latest failures for the queue status.
+func (srv *Server) collectResults() {
+	for res := range srv.s.WP().Results() {
+		if res.Success {
+			continue
+		}
+		srv.log.Error("job failed", "job_id", res.JobID, "err", res.ParseErr())
+
+		srv.failuresMu.Lock()
+		srv.recentFailures = append(srv.recentFailures, newJobFailure(res))
+		if len(srv.recentFailures) > maxRecentFailures {
+			srv.recentFailures = srv.recentFailures[1:]
+		}
+		srv.failuresMu.Unlock()
+	}
+}
+
+type jobFailure struct {
+	JobID     int       `json:"job_id"`
+	Operation string    `json:"operation"`
+	TaskID    int       `json:"task_id,omitempty"`
+	API       string    `json:"api,omitempty"`
+	Error     string    `json:"error"`
+	At        time.Time `json:"at"`
+}
+
+func newJobFailure(res workers.APIJobResult) jobFailure {
+	return jobFailure{
+		JobID:     res.JobID,
+		Operation: string(res.Operation),
+		TaskID:    res.TaskID,
+		API:       res.API,
+		Error:     res.ParseErr().Error(),
+		At:        time.Now(),
+	}
+}
+
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func errorf(status int, format string, args ...any) error {
+	return &httpError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		status = httpErr.status
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v == nil {
+		return nil
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (srv *Server) handle(rt route) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		if err := rt.handler(w, r); err != nil {
+			var httpErr *httpError
+			if !errors.As(err, &httpErr) {
+				srv.log.Error("request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+			}
+			writeError(w, err)
+		}
+		srv.log.Debug("request", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
+	})
+}
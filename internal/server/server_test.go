@@ -0,0 +1,213 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/database"
+	"github.com/zeerodex/goot/internal/repositories"
+	"github.com/zeerodex/goot/internal/services"
+)
+
+const testToken = "secret"
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("database.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{}
+	cfg.MaxLength.Title = 1024
+	cfg.MaxLength.Description = 8192
+	cfg.Workers.Count = 1
+	cfg.Workers.QueueSize = 5
+
+	s, err := services.NewTaskService(repositories.NewTaskRepository(db, logger), cfg, logger)
+	if err != nil {
+		t.Fatalf("services.NewTaskService() error = %v", err)
+	}
+	t.Cleanup(s.Close)
+
+	srv := New(s, testToken, logger)
+	go srv.collectResults()
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string, header map[string]string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("http.NewRequest() error = %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp := do(t, ts, "POST", "/v1/tasks", `{"title":"water plants","due":"2025-05-10 10:00","priority":"low","tags":["home"]}`, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	var created Task
+	json.NewDecoder(resp.Body).Decode(&created)
+	etag := resp.Header.Get("ETag")
+	if created.ID == 0 || etag == "" {
+		t.Fatalf("create returned id %d and etag %q", created.ID, etag)
+	}
+	if created.Priority != "low" || !slices.Equal(created.Tags, []string{"home"}) {
+		t.Errorf("created priority, tags = %q, %v", created.Priority, created.Tags)
+	}
+	path := resp.Header.Get("Location")
+
+	resp = do(t, ts, "GET", path, "", map[string]string{"If-None-Match": etag})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("conditional get status = %d, want %d", resp.StatusCode, http.StatusNotModified)
+	}
+
+	resp = do(t, ts, "PATCH", path, `{"title":"water all plants","priority":"high","tags":[],"recurrence":"FREQ=WEEKLY"}`, map[string]string{"If-Match": etag})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("patch status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var patched Task
+	json.NewDecoder(resp.Body).Decode(&patched)
+	if patched.Title != "water all plants" || patched.Priority != "high" || len(patched.Tags) != 0 || patched.Recurrence != "FREQ=WEEKLY" {
+		t.Errorf("patched task = %+v", patched)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Error("patch did not change the etag")
+	}
+
+	resp = do(t, ts, "PATCH", path, `{"priority":"urgent"}`, nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("patch with invalid priority status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp = do(t, ts, "POST", path+"/complete", "", map[string]string{"If-Match": etag})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("complete with stale etag status = %d, want %d", resp.StatusCode, http.StatusPreconditionFailed)
+	}
+
+	resp = do(t, ts, "GET", "/v1/tasks?completed=false&q=plants", "", nil)
+	var list taskList
+	json.NewDecoder(resp.Body).Decode(&list)
+	if list.Count != 1 {
+		t.Errorf("list count = %d, want 1", list.Count)
+	}
+
+	resp = do(t, ts, "DELETE", path, "", nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+	resp = do(t, ts, "GET", path, "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get deleted status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := ts.Client().Get(ts.URL + "/v1/tasks")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp := do(t, ts, "GET", "/v1/openapi.json", "", nil)
+	var doc struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode openapi error = %v", err)
+	}
+	for path, method := range map[string]string{
+		"/v1/tasks":               "get",
+		"/v1/tasks/{id}":          "patch",
+		"/v1/tasks/{id}/complete": "post",
+		"/v1/queue":               "get",
+		"/v1/calendar.ics":        "get",
+	} {
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("openapi document misses %s %s", method, path)
+		}
+	}
+}
+
+func TestCalendarFeed(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp := do(t, ts, "POST", "/v1/tasks", `{"title":"water plants","due":"2025-05-10 10:00","priority":"low","tags":["home"]}`, nil)
+	var created Task
+	json.NewDecoder(resp.Body).Decode(&created)
+
+	// Calendar apps pass the token in the URL.
+	get := func(query string, header map[string]string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", ts.URL+"/v1/calendar.ics?token="+testToken+query, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("GET error = %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("", nil)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/calendar") {
+		t.Fatalf("feed status = %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	uid := "UID:goot-task-" + strconv.Itoa(created.ID) + "-event@goot"
+	if !strings.Contains(body, uid) || !strings.Contains(body, "SUMMARY:water plants") || strings.Contains(body, "BEGIN:VTODO") {
+		t.Errorf("feed =\n%s", body)
+	}
+
+	resp, _ = get("", map[string]string{"If-None-Match": resp.Header.Get("ETag")})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("unchanged feed status = %d, want %d", resp.StatusCode, http.StatusNotModified)
+	}
+
+	if _, body = get("&tag=work&component=both", nil); strings.Contains(body, "BEGIN:VEVENT") {
+		t.Errorf("feed filtered by tag =\n%s", body)
+	}
+	if resp, _ = get("&component=all", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid component status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
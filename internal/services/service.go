@@ -1,73 +1,176 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/zeerodex/goot/internal/apis"
-	"github.com/zeerodex/goot/internal/apis/gtasksapi"
-	"github.com/zeerodex/goot/internal/apis/todoist"
 	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/hooks"
+	"github.com/zeerodex/goot/internal/query"
+	"github.com/zeerodex/goot/internal/ratelimit"
 	"github.com/zeerodex/goot/internal/repositories"
 	"github.com/zeerodex/goot/internal/tasks"
 	"github.com/zeerodex/goot/internal/workers"
 )
 
 type TaskService interface {
-	CreateTask(task *tasks.Task) (*tasks.Task, error)
+	CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error)
 	GetTaskByID(id int) (*tasks.Task, error)
 	GetAllTasks() (tasks.Tasks, error)
+	// SearchTasks returns the tasks whose title or description match query,
+	// best matches first, at most limit unless limit is 0.
+	SearchTasks(query string, limit int) ([]tasks.SearchResult, error)
+	// QueryTasks returns the tasks matching a query of the query language,
+	// all of them if q is empty.
+	QueryTasks(q string) (tasks.Tasks, error)
+	// ListTasks returns the tasks matching q, all of them if q is empty,
+	// ordered, grouped and paged by opts.
+	ListTasks(q string, opts query.Options) ([]tasks.Group, error)
+	// Views returns the named views usable in queries, keyed by name.
+	Views() map[string]string
 	GetAllPendingTasks(minTime, maxTime time.Time) (tasks.Tasks, error)
-	SetTaskCompleted(id int, completed bool) error
+	SetTaskCompleted(ctx context.Context, id int, completed bool) error
 	MarkAsNotified(id int) error
-	DeleteTaskByID(id int) error
-	UpdateTask(task *tasks.Task) (*tasks.Task, error)
+	DeleteTaskByID(ctx context.Context, id int) error
+	UpdateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error)
 
-	Sync() error
+	Sync(ctx context.Context) error
+	Reload(cfg *config.Config) error
+
+	// LossyFields returns, per API, the fields of task that API cannot store.
+	LossyFields(task *tasks.Task) map[string][]string
 
 	WP() *workers.APIWorkerPool
+	Events() *events.Bus
+	// Close stops the worker pool and waits for pending event hooks.
+	Close()
 }
 
 type taskService struct {
 	repo repositories.TaskRepository
 
 	cfg *config.Config
+	mu  sync.RWMutex
+
+	wp    *workers.APIWorkerPool
+	bus   *events.Bus
+	hooks *hooks.Runner
+	log   *slog.Logger
+}
+
+func NewTaskService(repo repositories.TaskRepository, cfg *config.Config, logger *slog.Logger) (TaskService, error) {
+	apisMap, err := newAPIs(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	gApi apis.API
-	wp   *workers.APIWorkerPool
+	bus := events.NewBus(logger)
+	hooksRunner := hooks.NewRunner(cfg.Hooks, logger)
+	bus.Subscribe(hooksRunner.Handle)
+
+	wp := workers.NewAPIWorkerPool(cfg.Workers.Count, cfg.Workers.QueueSize, apisMap, repo, bus, logger)
+	wp.Start()
+
+	return &taskService{repo: repo, cfg: cfg, wp: wp, bus: bus, hooks: hooksRunner, log: logger}, nil
 }
 
-func NewTaskService(repo repositories.TaskRepository, cfg *config.Config) (TaskService, error) {
+// newAPIs creates the APIs of the registered providers enabled in cfg.
+func newAPIs(cfg *config.Config, logger *slog.Logger) (map[string]apis.API, error) {
 	apisMap := make(map[string]apis.API)
-	for api, enabled := range cfg.APIs {
-		if api == "google" && enabled {
-			googleAPI, err := gtasksapi.NewGTasksApi(cfg.Google.ListId)
-			if err != nil {
-				return nil, fmt.Errorf("failed to enable Google API: %v", err)
-			}
-			apisMap["gtasks"] = googleAPI
+	for key, enabled := range cfg.APIs {
+		if !enabled {
+			continue
 		}
-		if api == "todoist" && enabled {
-			todoistAPI, err := todoist.NewTodoistAPI()
-			if err != nil {
-				return nil, fmt.Errorf("failed to initialize Todoist API: %w", err)
+		if !slices.ContainsFunc(apis.Providers(), func(p apis.Provider) bool { return p.ConfigKey == key }) {
+			logger.Warn("enabled API has no registered provider", "api", key)
+		}
+	}
+
+	for _, p := range apis.Providers() {
+		if !cfg.APIs[p.ConfigKey] {
+			continue
+		}
+
+		var settings any
+		if p.NewSettings != nil {
+			settings = p.NewSettings()
+			if err := cfg.DecodeProvider(p.ConfigKey, settings); err != nil {
+				return nil, err
 			}
-			apisMap["todoist"] = todoistAPI
 		}
+
+		rl := cfg.APIRateLimit(p.ConfigKey, p.RateLimit)
+		api, err := p.New(settings, apis.Options{
+			Limiter: ratelimit.New(rl.Rate, rl.Burst),
+			Logger:  logger,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to enable %s API: %w", p.ConfigKey, err)
+		}
+		apisMap[p.Name] = apis.WithTimeout(api, cfg.APITimeout(p.ConfigKey, apis.DefaultTimeout))
 	}
+	return apisMap, nil
+}
 
-	wp := workers.NewAPIWorkerPool(3, 5, apisMap, repo)
-	wp.Start()
+// Reload rebuilds the enabled APIs and the worker pool from cfg. Jobs
+// already queued are kept and processed with the new APIs.
+func (s *taskService) Reload(cfg *config.Config) error {
+	apisMap, err := newAPIs(cfg, s.log)
+	if err != nil {
+		return err
+	}
+
+	s.wp.Reload(cfg.Workers.Count, apisMap)
+	s.hooks.SetHooks(cfg.Hooks)
+
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+	return nil
+}
 
-	return &taskService{repo: repo, cfg: cfg, wp: wp}, nil
+func (s *taskService) config() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
 }
 
 func (s *taskService) WP() *workers.APIWorkerPool {
 	return s.wp
 }
 
-func (s *taskService) Sync() error {
-	err := s.wp.Submit(workers.APIJob{
+func (s *taskService) LossyFields(task *tasks.Task) map[string][]string {
+	lossy := make(map[string][]string)
+	for name, caps := range s.wp.Capabilities() {
+		if caps.ReadMostly {
+			continue
+		}
+		if fields := caps.Lossy(task); len(fields) > 0 {
+			lossy[name] = fields
+		}
+	}
+	return lossy
+}
+
+func (s *taskService) Events() *events.Bus {
+	return s.bus
+}
+
+func (s *taskService) Close() {
+	s.wp.Stop()
+	s.bus.Close()
+}
+
+func (s *taskService) Sync(ctx context.Context) error {
+	err := s.wp.Submit(ctx, workers.APIJob{
 		Operation: workers.SyncTasksOp,
 	})
 	if err != nil {
@@ -76,7 +179,7 @@ func (s *taskService) Sync() error {
 	return nil
 }
 
-func (s *taskService) UpdateTask(task *tasks.Task) (*tasks.Task, error) {
+func (s *taskService) UpdateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
 	if err := s.ValidateTask(task); err != nil {
 		return nil, fmt.Errorf("unable to validate task: %w", err)
 	}
@@ -85,8 +188,9 @@ func (s *taskService) UpdateTask(task *tasks.Task) (*tasks.Task, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to update local task ID %d: %w", task.ID, err)
 	}
+	s.bus.Publish(events.Event{Type: events.TaskUpdated, Task: task})
 
-	err = s.wp.Submit(workers.APIJob{
+	err = s.wp.Submit(ctx, workers.APIJob{
 		Operation: workers.UpdateTaskOp,
 		Task:      task,
 		TaskID:    task.ID,
@@ -98,7 +202,7 @@ func (s *taskService) UpdateTask(task *tasks.Task) (*tasks.Task, error) {
 	return task, nil
 }
 
-func (s *taskService) CreateTask(task *tasks.Task) (*tasks.Task, error) {
+func (s *taskService) CreateTask(ctx context.Context, task *tasks.Task) (*tasks.Task, error) {
 	if err := s.ValidateTask(task); err != nil {
 		return nil, fmt.Errorf("unable to validate task: %w", err)
 	}
@@ -106,8 +210,9 @@ func (s *taskService) CreateTask(task *tasks.Task) (*tasks.Task, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create task in repository: %w", err)
 	}
+	s.bus.Publish(events.Event{Type: events.TaskCreated, Task: task})
 
-	err = s.wp.Submit(workers.APIJob{
+	err = s.wp.Submit(ctx, workers.APIJob{
 		Operation: workers.CreateTaskOp,
 		Task:      task,
 		TaskID:    task.ID,
@@ -127,16 +232,53 @@ func (s *taskService) GetAllTasks() (tasks.Tasks, error) {
 	return s.repo.GetAllTasks()
 }
 
+func (s *taskService) SearchTasks(query string, limit int) ([]tasks.SearchResult, error) {
+	return s.repo.SearchTasks(query, limit)
+}
+
+func (s *taskService) QueryTasks(q string) (tasks.Tasks, error) {
+	if strings.TrimSpace(q) == "" {
+		return s.repo.GetAllTasks()
+	}
+	groups, err := s.ListTasks(q, query.Options{})
+	if err != nil || len(groups) == 0 {
+		return nil, err
+	}
+	return groups[0].Tasks, nil
+}
+
+func (s *taskService) ListTasks(q string, opts query.Options) ([]tasks.Group, error) {
+	var filter query.Expr
+	if strings.TrimSpace(q) != "" {
+		var err error
+		if filter, err = query.Parse(q, s.Views()); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.ListTasks(filter, opts)
+}
+
+func (s *taskService) Views() map[string]string {
+	views := maps.Clone(query.DefaultViews)
+	maps.Copy(views, s.config().Views)
+	return views
+}
+
 func (s *taskService) GetAllPendingTasks(minTime, maxTime time.Time) (tasks.Tasks, error) {
 	return s.repo.GetAllPendingTasks(minTime, maxTime)
 }
 
-func (s *taskService) SetTaskCompleted(id int, completed bool) error {
+func (s *taskService) SetTaskCompleted(ctx context.Context, id int, completed bool) error {
 	if err := s.repo.SetTaskCompleted(id, completed); err != nil {
 		return err
 	}
+	if completed {
+		s.publishTask(events.TaskCompleted, id)
+	} else {
+		s.publishTask(events.TaskUncompleted, id)
+	}
 
-	err := s.wp.Submit(workers.APIJob{
+	err := s.wp.Submit(ctx, workers.APIJob{
 		Operation: workers.SetTaskCompletedOp,
 		TaskID:    id,
 		Completed: completed,
@@ -148,13 +290,14 @@ func (s *taskService) SetTaskCompleted(id int, completed bool) error {
 	return nil
 }
 
-func (s *taskService) DeleteTaskByID(id int) error {
+func (s *taskService) DeleteTaskByID(ctx context.Context, id int) error {
 	err := s.repo.SoftDeleteTaskByID(id)
 	if err != nil {
 		return err
 	}
+	s.publishTask(events.TaskDeleted, id)
 
-	err = s.wp.Submit(workers.APIJob{
+	err = s.wp.Submit(ctx, workers.APIJob{
 		Operation: workers.DeleteTaskOp,
 		TaskID:    id,
 	})
@@ -165,6 +308,16 @@ func (s *taskService) DeleteTaskByID(id int) error {
 	return nil
 }
 
+// publishTask publishes an event of type t carrying the stored task id.
+func (s *taskService) publishTask(t events.Type, id int) {
+	task, err := s.repo.GetTaskByID(id)
+	if err != nil {
+		s.log.Warn("failed to load task for event", "type", t, "task_id", id, "err", err)
+		return
+	}
+	s.bus.Publish(events.Event{Type: t, Task: task})
+}
+
 func (s *taskService) GetTaskGoogleID(id int) (string, error) {
 	return s.repo.GetTaskGoogleID(id)
 }
@@ -174,11 +327,12 @@ func (s *taskService) MarkAsNotified(id int) error {
 }
 
 func (s *taskService) ValidateTask(task *tasks.Task) error {
-	if len(task.Title) > s.cfg.MaxLength.Title {
-		return fmt.Errorf("allowed length of task title - %d", s.cfg.MaxLength.Title)
+	cfg := s.config()
+	if len(task.Title) > cfg.MaxLength.Title {
+		return fmt.Errorf("allowed length of task title - %d", cfg.MaxLength.Title)
 	}
-	if len(task.Description) > s.cfg.MaxLength.Description {
-		return fmt.Errorf("allowed length of task description - %d", s.cfg.MaxLength.Description)
+	if len(task.Description) > cfg.MaxLength.Description {
+		return fmt.Errorf("allowed length of task description - %d", cfg.MaxLength.Description)
 	}
 	return nil
 }
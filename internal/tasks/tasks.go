@@ -8,16 +8,66 @@ import (
 )
 
 type Task struct {
-	ID           int `json:"id"`
-	GoogleID     string
-	TodoistID    string
-	Title        string    `json:"title"`
-	Description  string    `json:"description,omitempty"`
-	Due          time.Time `json:"due"`
+	ID          int       `json:"id"`
+	GoogleID    string    `json:"google_id,omitempty"`
+	TodoistID   string    `json:"todoist_id,omitempty"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Due         time.Time `json:"due"`
+	Priority    Priority  `json:"priority,omitempty"`
+	// Recurrence is an iCalendar RRULE value such as "FREQ=WEEKLY", empty
+	// for tasks due once.
+	Recurrence   string    `json:"recurrence,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
 	Completed    bool      `json:"status"`
 	Notified     bool      `json:"notified"`
 	Deleted      bool      `json:"deleted"`
 	LastModified time.Time `json:"last_modified"`
+	// APIIDs holds the IDs of the task in APIs without a dedicated field,
+	// keyed by API name.
+	APIIDs map[string]string `json:"api_ids,omitempty"`
+}
+
+// APIID returns the ID of the task in the API name, if stored in APIIDs.
+func (t *Task) APIID(name string) string {
+	return t.APIIDs[name]
+}
+
+// SetAPIID stores id as the ID of the task in the API name.
+func (t *Task) SetAPIID(name, id string) {
+	if t.APIIDs == nil {
+		t.APIIDs = make(map[string]string)
+	}
+	t.APIIDs[name] = id
+}
+
+// Priority of a task, from PriorityNone to PriorityHigh.
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = []string{"none", "low", "medium", "high"}
+
+func (p Priority) String() string {
+	if p < PriorityNone || p > PriorityHigh {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority parses a priority name such as "high".
+func ParsePriority(s string) (Priority, error) {
+	for i, name := range priorityNames {
+		if s == name {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("invalid priority '%s', expected one of %v", s, priorityNames)
 }
 
 type Tasks []Task
@@ -110,3 +160,28 @@ type TasksList struct {
 }
 
 type TasksLists []TasksList
+
+// Markers of the matches in SearchResult snippets.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// SearchResult is a task matching a full-text search.
+type SearchResult struct {
+	Task Task
+	// Snippet is the part of the title or description that matched, with
+	// every match between HighlightStart and HighlightEnd.
+	Snippet string
+}
+
+// Group is a group of tasks, as listed by 'goot list --group-by'.
+type Group struct {
+	// Key is the value the tasks share, such as a tag or a day, "" for the
+	// tasks without any.
+	Key string
+	// Count is the number of tasks of the group, of which Tasks may only
+	// hold a page.
+	Count int
+	Tasks Tasks
+}
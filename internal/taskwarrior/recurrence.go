@@ -0,0 +1,102 @@
+package taskwarrior
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const workweek = "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"
+
+// namedRecurrences are the named durations of Taskwarrior and their RRULE
+// values.
+var namedRecurrences = map[string]string{
+	"daily":      "FREQ=DAILY",
+	"day":        "FREQ=DAILY",
+	"weekdays":   workweek,
+	"weekly":     "FREQ=WEEKLY",
+	"week":       "FREQ=WEEKLY",
+	"biweekly":   "FREQ=WEEKLY;INTERVAL=2",
+	"fortnight":  "FREQ=WEEKLY;INTERVAL=2",
+	"monthly":    "FREQ=MONTHLY",
+	"month":      "FREQ=MONTHLY",
+	"bimonthly":  "FREQ=MONTHLY;INTERVAL=2",
+	"quarterly":  "FREQ=MONTHLY;INTERVAL=3",
+	"semiannual": "FREQ=MONTHLY;INTERVAL=6",
+	"yearly":     "FREQ=YEARLY",
+	"annual":     "FREQ=YEARLY",
+	"year":       "FREQ=YEARLY",
+	"biannual":   "FREQ=YEARLY;INTERVAL=2",
+	"biyearly":   "FREQ=YEARLY;INTERVAL=2",
+}
+
+var recurRe = regexp.MustCompile(`^(\d+)\s*(d|days?|w|wks?|weeks?|mo|mos|months?|q|qtrs?|quarters?|y|yrs?|years?)$`)
+
+// ParseRecurrence converts a Taskwarrior recur value, such as "weekly" or
+// "2w", to an RRULE value. Durations shorter than a day are not supported.
+func ParseRecurrence(recur string) (string, bool) {
+	recur = strings.ToLower(strings.TrimSpace(recur))
+	if rrule, ok := namedRecurrences[recur]; ok {
+		return rrule, true
+	}
+	m := recurRe.FindStringSubmatch(recur)
+	if m == nil {
+		return "", false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n < 1 {
+		return "", false
+	}
+	var freq string
+	switch m[2][0] {
+	case 'd':
+		freq = "DAILY"
+	case 'w':
+		freq = "WEEKLY"
+	case 'm':
+		freq = "MONTHLY"
+	case 'q':
+		freq, n = "MONTHLY", n*3
+	case 'y':
+		freq = "YEARLY"
+	}
+	if n == 1 {
+		return "FREQ=" + freq, true
+	}
+	return fmt.Sprintf("FREQ=%s;INTERVAL=%d", freq, n), true
+}
+
+var recurUnits = map[string]string{"DAILY": "d", "WEEKLY": "w", "MONTHLY": "mo", "YEARLY": "y"}
+
+var recurNames = map[string]string{"DAILY": "daily", "WEEKLY": "weekly", "MONTHLY": "monthly", "YEARLY": "yearly"}
+
+// FormatRecurrence converts an RRULE value to a Taskwarrior recur value.
+// Only FREQ and INTERVAL are kept. It returns an empty string if rrule has
+// no known frequency.
+func FormatRecurrence(rrule string) string {
+	if strings.EqualFold(rrule, workweek) {
+		return "weekdays"
+	}
+	var freq string
+	n := 1
+	for _, part := range strings.Split(rrule, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			freq = strings.ToUpper(v)
+		case "INTERVAL":
+			if i, err := strconv.Atoi(v); err == nil && i > 0 {
+				n = i
+			}
+		}
+	}
+	unit, ok := recurUnits[freq]
+	if !ok {
+		return ""
+	}
+	if n == 1 {
+		return recurNames[freq]
+	}
+	return strconv.Itoa(n) + unit
+}
@@ -0,0 +1,275 @@
+// Package taskwarrior converts tasks from and to the JSON read and written
+// by Taskwarrior's import and export commands.
+package taskwarrior
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// Name is the key of the Taskwarrior UUIDs of tasks in tasks.Task.APIIDs.
+const Name = "taskwarrior"
+
+const (
+	StatusPending   = "pending"
+	StatusWaiting   = "waiting"
+	StatusCompleted = "completed"
+	StatusDeleted   = "deleted"
+	StatusRecurring = "recurring"
+)
+
+// projectTag prefixes the tag a Taskwarrior project is kept in.
+const projectTag = "project:"
+
+const timeLayout = "20060102T150405Z"
+
+// Time is a timestamp in Taskwarrior's UTC format, such as
+// "20261020T000000Z".
+type Time struct{ time.Time }
+
+func newTime(t time.Time) *Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &Time{t}
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.UTC().Format(timeLayout))
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(timeLayout, s)
+	if err != nil {
+		// Old versions export dates as Unix timestamps.
+		sec, serr := strconv.ParseInt(s, 10, 64)
+		if serr != nil {
+			return fmt.Errorf("invalid date '%s'", s)
+		}
+		parsed = time.Unix(sec, 0)
+	}
+	t.Time = parsed.Local()
+	return nil
+}
+
+func (t *Time) time() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.Time
+}
+
+type Annotation struct {
+	Entry       *Time  `json:"entry,omitempty"`
+	Description string `json:"description"`
+}
+
+// Task is a task as exported by Taskwarrior. Attributes goot has no use for,
+// such as urgency or user defined attributes, are dropped.
+type Task struct {
+	UUID        string       `json:"uuid"`
+	Description string       `json:"description"`
+	Status      string       `json:"status"`
+	Entry       *Time        `json:"entry,omitempty"`
+	Modified    *Time        `json:"modified,omitempty"`
+	End         *Time        `json:"end,omitempty"`
+	Due         *Time        `json:"due,omitempty"`
+	Project     string       `json:"project,omitempty"`
+	Priority    string       `json:"priority,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+	Recur       string       `json:"recur,omitempty"`
+	// Parent is the UUID of the recurring template a pending instance was
+	// generated from.
+	Parent string `json:"parent,omitempty"`
+}
+
+var priorities = map[string]tasks.Priority{"H": tasks.PriorityHigh, "M": tasks.PriorityMedium, "L": tasks.PriorityLow}
+
+// Task converts t to a goot task with t.UUID as its Name API ID. The
+// project becomes a "project:" tag and the annotations the description.
+func (t Task) Task() tasks.Task {
+	task := tasks.Task{
+		Title:        t.Description,
+		Due:          t.Due.time(),
+		Priority:     priorities[strings.ToUpper(t.Priority)],
+		Tags:         slices.Clone(t.Tags),
+		Completed:    t.Status == StatusCompleted,
+		Deleted:      t.Status == StatusDeleted,
+		LastModified: t.Modified.time(),
+	}
+	if task.LastModified.IsZero() {
+		task.LastModified = t.Entry.time()
+	}
+	if t.Project != "" {
+		task.Tags = append(task.Tags, projectTag+t.Project)
+	}
+	var notes []string
+	for _, a := range t.Annotations {
+		notes = append(notes, a.Description)
+	}
+	task.Description = strings.Join(notes, "\n")
+	if rrule, ok := ParseRecurrence(t.Recur); ok {
+		task.Recurrence = rrule
+	}
+	task.SetAPIID(Name, t.UUID)
+	return task
+}
+
+// FromTask converts task to a Taskwarrior task with the UUID returned by
+// UUID. Every line of the description becomes an annotation, and recurring
+// tasks with a due date become recurring templates.
+func FromTask(task tasks.Task) Task {
+	t := Task{
+		UUID:        UUID(task),
+		Description: task.Title,
+		Status:      StatusPending,
+		Entry:       newTime(task.LastModified),
+		Modified:    newTime(task.LastModified),
+		Due:         newTime(task.Due),
+	}
+	for p, name := range priorities {
+		if name == task.Priority {
+			t.Priority = p
+		}
+	}
+	for _, tag := range task.Tags {
+		if project, ok := strings.CutPrefix(tag, projectTag); ok && t.Project == "" {
+			t.Project = project
+			continue
+		}
+		t.Tags = append(t.Tags, tag)
+	}
+	for line := range strings.Lines(task.Description) {
+		if line = strings.TrimSpace(line); line != "" {
+			t.Annotations = append(t.Annotations, Annotation{Entry: newTime(task.LastModified), Description: line})
+		}
+	}
+	if !task.Due.IsZero() {
+		t.Recur = FormatRecurrence(task.Recurrence)
+	}
+
+	switch {
+	case task.Deleted:
+		t.Status = StatusDeleted
+		t.End = newTime(task.LastModified)
+	case task.Completed:
+		t.Status = StatusCompleted
+		t.End = newTime(task.LastModified)
+	case t.Recur != "":
+		t.Status = StatusRecurring
+	}
+	return t
+}
+
+var namespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/zeerodex/goot"))
+
+// UUID returns the Taskwarrior UUID of task: the stored one if it was
+// imported, else one derived from its ID so that exporting twice gives the
+// same UUIDs. Tasks without an ID get a random one.
+func UUID(task tasks.Task) string {
+	if id := task.APIID(Name); id != "" {
+		return id
+	}
+	if task.ID == 0 {
+		return uuid.NewString()
+	}
+	return uuid.NewSHA1(namespace, []byte(strconv.Itoa(task.ID))).String()
+}
+
+// Decode reads the output of "task export": a JSON array of tasks, or one
+// task per line. Instances of a recurring task are merged into it, its due
+// date becoming the earliest due date of its pending instances.
+func Decode(r io.Reader) (tasks.Tasks, error) {
+	var tws []Task
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid Taskwarrior JSON: %w", err)
+		}
+		var err error
+		if bytes.HasPrefix(raw, []byte("[")) {
+			var batch []Task
+			err = json.Unmarshal(raw, &batch)
+			tws = append(tws, batch...)
+		} else {
+			var t Task
+			err = json.Unmarshal(raw, &t)
+			tws = append(tws, t)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid Taskwarrior task: %w", err)
+		}
+	}
+
+	templates := make(map[string]*Task)
+	for i := range tws {
+		if tws[i].Status == StatusRecurring && tws[i].UUID != "" {
+			templates[tws[i].UUID] = &tws[i]
+		}
+	}
+	next := make(map[string]*Time)
+	for _, t := range tws {
+		if _, ok := templates[t.Parent]; !ok || (t.Status != StatusPending && t.Status != StatusWaiting) || t.Due == nil {
+			continue
+		}
+		if due, ok := next[t.Parent]; !ok || t.Due.Before(due.Time) {
+			next[t.Parent] = t.Due
+		}
+	}
+	for id, due := range next {
+		templates[id].Due = due
+	}
+
+	var ts tasks.Tasks
+	for _, t := range tws {
+		if _, ok := templates[t.Parent]; ok {
+			continue
+		}
+		if t.UUID == "" {
+			return nil, fmt.Errorf("task '%s' has no uuid", t.Description)
+		}
+		ts = append(ts, t.Task())
+	}
+	return ts, nil
+}
+
+// Encode writes ts as a JSON array in the layout of "task export", one
+// task per line.
+func Encode(w io.Writer, ts tasks.Tasks) error {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, task := range ts {
+		data, err := json.Marshal(FromTask(task))
+		if err != nil {
+			return fmt.Errorf("failed to encode task '%s': %w", task.Title, err)
+		}
+		buf.Write(data)
+		if i < len(ts)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("]\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
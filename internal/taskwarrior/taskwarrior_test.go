@@ -0,0 +1,117 @@
+package taskwarrior
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const export = `[
+{"id":1,"description":"Call mom","entry":"20261001T080000Z","modified":"20261002T090000Z","due":"20261020T000000Z","project":"Family","priority":"H","status":"pending","tags":["phone"],"annotations":[{"entry":"20261001T080000Z","description":"about the party"}],"uuid":"5f6e9d2c-1b1a-4b8e-9c3e-0a2f0d9f7a11","urgency":12.3},
+{"id":0,"description":"Water plants","entry":"20261001T080000Z","status":"recurring","recur":"2w","due":"20261005T000000Z","uuid":"0c1d2e3f-4a5b-4c6d-8e7f-8091a2b3c4d5"},
+{"id":2,"description":"Water plants","entry":"20261001T080000Z","status":"completed","end":"20261006T000000Z","due":"20261005T000000Z","parent":"0c1d2e3f-4a5b-4c6d-8e7f-8091a2b3c4d5","uuid":"11111111-2222-4333-8444-555555555555"},
+{"id":3,"description":"Water plants","entry":"20261001T080000Z","status":"pending","due":"20261019T000000Z","parent":"0c1d2e3f-4a5b-4c6d-8e7f-8091a2b3c4d5","uuid":"66666666-7777-4888-9999-aaaaaaaaaaaa"}
+]
+{"description":"Old","status":"deleted","uuid":"bbbbbbbb-cccc-4ddd-8eee-ffffffffffff"}
+`
+
+func TestDecode(t *testing.T) {
+	ts, err := Decode(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(ts) != 3 {
+		t.Fatalf("Decode() returned %d tasks, want 3: %+v", len(ts), ts)
+	}
+
+	call := ts[0]
+	if call.Title != "Call mom" || call.Description != "about the party" || call.Priority != tasks.PriorityHigh {
+		t.Errorf("call = %+v", call)
+	}
+	if !slices.Equal(call.Tags, []string{"phone", "project:Family"}) {
+		t.Errorf("tags = %v", call.Tags)
+	}
+	if !call.Due.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)) || !call.LastModified.Equal(time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("due = %v, last modified = %v", call.Due, call.LastModified)
+	}
+	if call.APIID(Name) != "5f6e9d2c-1b1a-4b8e-9c3e-0a2f0d9f7a11" {
+		t.Errorf("uuid = %q", call.APIID(Name))
+	}
+
+	// Instances are merged into their template.
+	water := ts[1]
+	if water.Recurrence != "FREQ=WEEKLY;INTERVAL=2" || water.Completed || !water.Due.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("water = %+v", water)
+	}
+	if !ts[2].Deleted {
+		t.Errorf("deleted task = %+v", ts[2])
+	}
+}
+
+func TestEncode(t *testing.T) {
+	ts, err := Decode(strings.NewReader(export))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	ts = append(ts[:2], tasks.Task{ID: 4, Title: "Ship release", Completed: true, LastModified: time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC)})
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, ts); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	want := `[
+{"uuid":"5f6e9d2c-1b1a-4b8e-9c3e-0a2f0d9f7a11","description":"Call mom","status":"pending","entry":"20261002T090000Z","modified":"20261002T090000Z","due":"20261020T000000Z","project":"Family","priority":"H","tags":["phone"],"annotations":[{"entry":"20261002T090000Z","description":"about the party"}]},
+{"uuid":"0c1d2e3f-4a5b-4c6d-8e7f-8091a2b3c4d5","description":"Water plants","status":"recurring","entry":"20261001T080000Z","modified":"20261001T080000Z","due":"20261019T000000Z","recur":"2w"},
+{"uuid":"` + UUID(ts[2]) + `","description":"Ship release","status":"completed","entry":"20261003T000000Z","modified":"20261003T000000Z","end":"20261003T000000Z"}
+]
+`
+	if buf.String() != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// Exporting stored tasks twice gives the same UUIDs, and importing the
+	// export maps the tasks back to them.
+	if UUID(ts[2]) != UUID(tasks.Task{ID: 4}) || UUID(ts[2]) == UUID(tasks.Task{ID: 5}) {
+		t.Error("UUID() is not stable")
+	}
+	back, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	for i := range back {
+		if UUID(back[i]) != UUID(ts[i]) {
+			t.Errorf("task %d: UUID %q, want %q", i, UUID(back[i]), UUID(ts[i]))
+		}
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	for recur, want := range map[string]string{
+		"daily":     "FREQ=DAILY",
+		"weekdays":  "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR",
+		"fortnight": "FREQ=WEEKLY;INTERVAL=2",
+		"3mo":       "FREQ=MONTHLY;INTERVAL=3",
+		"2q":        "FREQ=MONTHLY;INTERVAL=6",
+		"1y":        "FREQ=YEARLY",
+		"5min":      "",
+	} {
+		got, ok := ParseRecurrence(recur)
+		if got != want || ok != (want != "") {
+			t.Errorf("ParseRecurrence(%q) = %q, %v, want %q", recur, got, ok, want)
+		}
+	}
+	for rrule, want := range map[string]string{
+		"FREQ=WEEKLY":                      "weekly",
+		"FREQ=MONTHLY;INTERVAL=3":          "3mo",
+		"FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR": "weekdays",
+		"FREQ=HOURLY":                      "",
+	} {
+		if got := FormatRecurrence(rrule); got != want {
+			t.Errorf("FormatRecurrence(%q) = %q, want %q", rrule, got, want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package todotxt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var recRe = regexp.MustCompile(`^\+?(\d*)([dwmyb])$`)
+
+var recUnits = map[string]string{"d": "DAILY", "w": "WEEKLY", "m": "MONTHLY", "y": "YEARLY"}
+
+const workweek = "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"
+
+// ParseRecurrence converts the value of a rec extension, such as "1w" or
+// "+2m", to an RRULE value. Strict recurrence, marked by "+", is not kept
+// and business days are only supported one at a time.
+func ParseRecurrence(rec string) (string, bool) {
+	m := recRe.FindStringSubmatch(rec)
+	if m == nil {
+		return "", false
+	}
+	n := 1
+	if m[1] != "" {
+		var err error
+		if n, err = strconv.Atoi(m[1]); err != nil || n < 1 {
+			return "", false
+		}
+	}
+	if m[2] == "b" {
+		if n != 1 {
+			return "", false
+		}
+		return workweek, true
+	}
+	rrule := "FREQ=" + recUnits[m[2]]
+	if n > 1 {
+		rrule += fmt.Sprintf(";INTERVAL=%d", n)
+	}
+	return rrule, true
+}
+
+// FormatRecurrence converts an RRULE value to the value of a rec
+// extension. Only FREQ and INTERVAL are kept. It returns an empty string
+// if rrule has no known frequency.
+func FormatRecurrence(rrule string) string {
+	if strings.EqualFold(rrule, workweek) {
+		return "1b"
+	}
+	var unit string
+	n := 1
+	for _, part := range strings.Split(rrule, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			for u, freq := range recUnits {
+				if freq == strings.ToUpper(v) {
+					unit = u
+				}
+			}
+		case "INTERVAL":
+			if i, err := strconv.Atoi(v); err == nil && i > 0 {
+				n = i
+			}
+		}
+	}
+	if unit == "" {
+		return ""
+	}
+	return strconv.Itoa(n) + unit
+}
@@ -0,0 +1,352 @@
+// Package todotxt reads and writes tasks in the todo.txt format
+// (https://github.com/todotxt/todo.txt):
+//
+//	x (A) 2026-10-19 2026-10-01 Call mom +family @phone due:2026-10-20 rec:1w
+//
+// Projects are mapped to tags and contexts to tags starting with "@". The
+// due, rec and pri extensions are mapped to the due date, recurrence and
+// priority of completed tasks, other extensions are kept as written.
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	priorityRe = regexp.MustCompile(`^\(([A-Z])\) `)
+	keyRe      = regexp.MustCompile(`^[A-Za-z][\w-]*$`)
+)
+
+// Item is a todo.txt task line.
+type Item struct {
+	Completed      bool
+	CompletionDate time.Time
+	// Priority is a letter from 'A' to 'Z', or 0.
+	Priority     byte
+	CreationDate time.Time
+	// Text is the rest of the line: the description with its projects,
+	// contexts and key:value extensions.
+	Text string
+}
+
+// Parse parses a todo.txt line. It reports false for blank lines.
+func Parse(line string) (Item, bool) {
+	s := strings.TrimSpace(line)
+	if s == "" {
+		return Item{}, false
+	}
+
+	var it Item
+	if rest, ok := strings.CutPrefix(s, "x "); ok {
+		it.Completed = true
+		s = rest
+	}
+	if m := priorityRe.FindStringSubmatch(s); m != nil {
+		it.Priority = m[1][0]
+		s = s[len(m[0]):]
+	}
+	// A completed task has its completion date before its creation date.
+	if date, rest, ok := cutDate(s); ok {
+		if it.Completed {
+			it.CompletionDate, s = date, rest
+			if date, rest, ok := cutDate(s); ok {
+				it.CreationDate, s = date, rest
+			}
+		} else {
+			it.CreationDate, s = date, rest
+		}
+	}
+	it.Text = s
+	return it, true
+}
+
+// cutDate cuts the date s starts with.
+func cutDate(s string) (time.Time, string, bool) {
+	if len(s) < len(dateLayout) || (len(s) > len(dateLayout) && s[len(dateLayout)] != ' ') {
+		return time.Time{}, s, false
+	}
+	date, err := time.ParseInLocation(dateLayout, s[:len(dateLayout)], time.Local)
+	if err != nil {
+		return time.Time{}, s, false
+	}
+	return date, strings.TrimPrefix(s[len(dateLayout):], " "), true
+}
+
+func (it Item) String() string {
+	var parts []string
+	if it.Completed {
+		parts = append(parts, "x")
+	}
+	if it.Priority != 0 {
+		parts = append(parts, "("+string(it.Priority)+")")
+	}
+	if it.Completed && !it.CompletionDate.IsZero() {
+		parts = append(parts, it.CompletionDate.Format(dateLayout))
+	}
+	if !it.CreationDate.IsZero() {
+		parts = append(parts, it.CreationDate.Format(dateLayout))
+	}
+	if it.Text != "" {
+		parts = append(parts, it.Text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// extension splits a key:value word, URLs and times excluded.
+func extension(word string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(word, ":")
+	if !ok || !keyRe.MatchString(key) || value == "" || strings.Contains(value, ":") || strings.HasPrefix(value, "//") {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+// Extension returns the value of the key:value extension of the item.
+func (it Item) Extension(key string) (string, bool) {
+	for _, w := range strings.Fields(it.Text) {
+		if k, v, ok := extension(w); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// SetExtension replaces the value of the key:value extension, removing it
+// if value is empty and appending it if missing.
+func (it *Item) SetExtension(key, value string) {
+	words := strings.Fields(it.Text)
+	found := false
+	for i := 0; i < len(words); i++ {
+		k, _, ok := extension(words[i])
+		if !ok || k != key {
+			continue
+		}
+		if value == "" || found {
+			words = slices.Delete(words, i, i+1)
+			i--
+		} else {
+			words[i] = key + ":" + value
+		}
+		found = true
+	}
+	if !found && value != "" {
+		words = append(words, key+":"+value)
+	}
+	it.Text = strings.Join(words, " ")
+}
+
+// Title returns the description without projects, contexts and
+// extensions.
+func (it Item) Title() string {
+	var words []string
+	for _, w := range strings.Fields(it.Text) {
+		if isTag(w) {
+			continue
+		}
+		if _, _, ok := extension(w); ok {
+			continue
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
+
+// Tags returns the projects of the item followed by its contexts, which
+// keep their "@".
+func (it Item) Tags() []string {
+	var projects, contexts []string
+	for _, w := range strings.Fields(it.Text) {
+		switch {
+		case !isTag(w):
+		case w[0] == '+':
+			projects = append(projects, w[1:])
+		default:
+			contexts = append(contexts, w)
+		}
+	}
+	return append(projects, contexts...)
+}
+
+func isTag(w string) bool {
+	return len(w) > 1 && (w[0] == '+' || w[0] == '@')
+}
+
+var priorities = map[tasks.Priority]byte{
+	tasks.PriorityHigh:   'A',
+	tasks.PriorityMedium: 'B',
+	tasks.PriorityLow:    'C',
+}
+
+func taskPriority(p byte) tasks.Priority {
+	switch {
+	case p == 'A':
+		return tasks.PriorityHigh
+	case p == 'B':
+		return tasks.PriorityMedium
+	case p >= 'C' && p <= 'Z':
+		return tasks.PriorityLow
+	}
+	return tasks.PriorityNone
+}
+
+// priority returns the priority of the item, kept in the pri extension
+// once completed.
+func (it Item) priority() byte {
+	if it.Completed && it.Priority == 0 {
+		if pri, ok := it.Extension("pri"); ok && len(pri) == 1 {
+			return pri[0]
+		}
+	}
+	return it.Priority
+}
+
+// Task returns the task described by the item.
+func (it Item) Task() *tasks.Task {
+	t := &tasks.Task{
+		Title:     it.Title(),
+		Completed: it.Completed,
+		Priority:  taskPriority(it.priority()),
+		Tags:      it.Tags(),
+	}
+	if due, ok := it.Extension("due"); ok {
+		t.Due, _ = time.ParseInLocation(dateLayout, due, time.Local)
+	}
+	if rec, ok := it.Extension("rec"); ok {
+		t.Recurrence, _ = ParseRecurrence(rec)
+	}
+	return t
+}
+
+// Update changes the item to describe task, keeping the parts the task
+// does not change as written, and reports whether it changed.
+func (it *Item) Update(task *tasks.Task, now time.Time) bool {
+	before := it.String()
+	current := it.Task()
+
+	if task.Title != current.Title || !slices.Equal(task.Tags, current.Tags) {
+		words := strings.Fields(task.Title)
+		for _, tag := range task.Tags {
+			if strings.HasPrefix(tag, "@") {
+				words = append(words, tag)
+			} else {
+				words = append(words, "+"+tag)
+			}
+		}
+		for _, w := range strings.Fields(it.Text) {
+			if _, _, ok := extension(w); ok {
+				words = append(words, w)
+			}
+		}
+		it.Text = strings.Join(words, " ")
+	}
+	if task.Completed != current.Completed {
+		it.SetCompleted(task.Completed, now)
+	}
+	if task.Priority != current.Priority {
+		if it.Completed {
+			pri := ""
+			if p, ok := priorities[task.Priority]; ok {
+				pri = string(p)
+			}
+			it.Priority = 0
+			it.SetExtension("pri", pri)
+		} else {
+			it.Priority = priorities[task.Priority]
+		}
+	}
+	if task.Recurrence != current.Recurrence {
+		it.SetExtension("rec", FormatRecurrence(task.Recurrence))
+	}
+	if !sameDay(task.Due, current.Due) {
+		due := ""
+		if !task.Due.IsZero() {
+			due = task.Due.Local().Format(dateLayout)
+		}
+		it.SetExtension("due", due)
+	}
+	return it.String() != before
+}
+
+// SetCompleted marks the item as completed on the day of now, moving its
+// priority to the pri extension as the format recommends, or as not
+// completed.
+func (it *Item) SetCompleted(completed bool, now time.Time) {
+	if completed == it.Completed {
+		return
+	}
+	it.Completed = completed
+	if completed {
+		it.CompletionDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		if it.Priority != 0 {
+			it.SetExtension("pri", string(it.Priority))
+			it.Priority = 0
+		}
+		return
+	}
+	it.CompletionDate = time.Time{}
+	if pri, ok := it.Extension("pri"); ok && len(pri) == 1 {
+		it.Priority = pri[0]
+		it.SetExtension("pri", "")
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return a.IsZero() == b.IsZero()
+	}
+	return a.Local().Format(dateLayout) == b.Local().Format(dateLayout)
+}
+
+// FromTask returns the item describing task, created on the day it was
+// last modified.
+func FromTask(task *tasks.Task) Item {
+	var it Item
+	modified := task.LastModified
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	modified = modified.Local()
+	it.CreationDate = time.Date(modified.Year(), modified.Month(), modified.Day(), 0, 0, 0, 0, time.Local)
+	it.Update(task, modified)
+	return it
+}
+
+// Decode reads the tasks of a todo.txt file.
+func Decode(r io.Reader) (tasks.Tasks, error) {
+	var ts tasks.Tasks
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if it, ok := Parse(sc.Text()); ok {
+			ts = append(ts, *it.Task())
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo.txt: %w", err)
+	}
+	return ts, nil
+}
+
+// Encode writes ts in the todo.txt format.
+func Encode(w io.Writer, ts tasks.Tasks) error {
+	bw := bufio.NewWriter(w)
+	for i := range ts {
+		it := FromTask(&ts[i])
+		if _, err := fmt.Fprintln(bw, it.String()); err != nil {
+			return fmt.Errorf("failed to write todo.txt: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write todo.txt: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package todotxt
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Item
+	}{
+		{
+			line: "(A) 2026-10-01 Call mom +family @phone due:2026-10-20",
+			want: Item{Priority: 'A', CreationDate: date(2026, 10, 1), Text: "Call mom +family @phone due:2026-10-20"},
+		},
+		{
+			line: "x 2026-10-19 2026-10-01 Pay rent pri:B",
+			want: Item{Completed: true, CompletionDate: date(2026, 10, 19), CreationDate: date(2026, 10, 1), Text: "Pay rent pri:B"},
+		},
+		{
+			line: "x 2026-10-19 Done",
+			want: Item{Completed: true, CompletionDate: date(2026, 10, 19), Text: "Done"},
+		},
+		{
+			line: "xylophone lesson (B) 2026-10-01",
+			want: Item{Text: "xylophone lesson (B) 2026-10-01"},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.line)
+		if !ok || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+		if got.String() != tt.line {
+			t.Errorf("String() = %q, want %q", got.String(), tt.line)
+		}
+	}
+	if _, ok := Parse("   "); ok {
+		t.Error("blank line parsed")
+	}
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func TestTask(t *testing.T) {
+	it, _ := Parse("(B) Meet at 10:30 +work @office see https://example.com due:2026-10-20 rec:+2w t:2026-10-18")
+	task := it.Task()
+	if task.Title != "Meet at 10:30 see https://example.com" {
+		t.Errorf("title = %q", task.Title)
+	}
+	if !slices.Equal(task.Tags, []string{"work", "@office"}) {
+		t.Errorf("tags = %v", task.Tags)
+	}
+	if task.Priority != tasks.PriorityMedium || task.Recurrence != "FREQ=WEEKLY;INTERVAL=2" {
+		t.Errorf("priority = %v, recurrence = %q", task.Priority, task.Recurrence)
+	}
+	if !task.Due.Equal(date(2026, 10, 20)) {
+		t.Errorf("due = %v", task.Due)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	now := time.Date(2026, 10, 21, 15, 0, 0, 0, time.Local)
+	it, _ := Parse("(A) 2026-10-01 Call mom +family due:2026-10-20 t:2026-10-18")
+
+	task := it.Task()
+	task.Completed = true
+	if !it.Update(task, now) {
+		t.Fatal("completion not applied")
+	}
+	if want := "x 2026-10-21 2026-10-01 Call mom +family due:2026-10-20 t:2026-10-18 pri:A"; it.String() != want {
+		t.Errorf("completed = %q, want %q", it.String(), want)
+	}
+	if it.Task().Priority != tasks.PriorityHigh {
+		t.Error("priority lost on completion")
+	}
+
+	task.Completed = false
+	task.Title = "Call dad"
+	task.Tags = []string{"family", "@phone"}
+	task.Due = time.Time{}
+	task.Recurrence = "FREQ=MONTHLY"
+	if !it.Update(task, now) {
+		t.Fatal("update not applied")
+	}
+	if want := "(A) 2026-10-01 Call dad +family @phone t:2026-10-18 rec:1m"; it.String() != want {
+		t.Errorf("updated = %q, want %q", it.String(), want)
+	}
+	if it.Update(it.Task(), now) {
+		t.Error("unchanged task reported as changed")
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	ts := tasks.Tasks{
+		{Title: "Buy milk", Tags: []string{"@store"}, Priority: tasks.PriorityLow, LastModified: date(2026, 10, 2)},
+		{Title: "Ship release", Due: date(2026, 11, 1), Recurrence: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", LastModified: date(2026, 10, 3)},
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, ts); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	want := "(C) 2026-10-02 Buy milk @store\n2026-10-03 Ship release rec:1b due:2026-11-01\n"
+	if buf.String() != want {
+		t.Fatalf("Encode() = %q, want %q", buf.String(), want)
+	}
+
+	got, err := Decode(strings.NewReader(buf.String() + "\n\n"))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(got) != 2 || got[0].Title != "Buy milk" || got[1].Recurrence != ts[1].Recurrence || !got[1].Due.Equal(ts[1].Due) {
+		t.Errorf("Decode() = %+v", got)
+	}
+}
@@ -2,6 +2,9 @@ package components
 
 import (
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -55,6 +58,8 @@ type listKeyMap struct {
 	updateTask     key.Binding
 	toogleComplete key.Binding
 	syncTasks      key.Binding
+	nextView       key.Binding
+	nextSort       key.Binding
 }
 
 func newListKeyMap() *listKeyMap {
@@ -79,25 +84,144 @@ func newListKeyMap() *listKeyMap {
 			key.WithKeys("s"),
 			key.WithHelp("s", "sync tasks"),
 		),
+		nextView: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "next view"),
+		),
+		nextSort: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "next sort order"),
+		),
 	}
 }
 
 type ListModel struct {
 	list list.Model
 	keys *listKeyMap
+	ids  *itemIDs
 
 	Method   string
 	Selected item
 }
 
+// itemIDs are the task IDs of the list items, in order. The list filters
+// in a goroutine, so they are guarded by a mutex.
+type itemIDs struct {
+	mu  sync.Mutex
+	ids []int
+}
+
 func (m *ListModel) SetTasks(tasks tasks.Tasks) tea.Cmd {
 	items := make([]list.Item, len(tasks))
+	ids := make([]int, len(tasks))
 	for i, task := range tasks {
 		items[i] = item{id: task.ID, title: task.FullTitle(), desc: task.Description, completed: task.Completed}
+		ids[i] = task.ID
 	}
+	m.ids.mu.Lock()
+	m.ids.ids = ids
+	m.ids.mu.Unlock()
 	return m.list.SetItems(items)
 }
 
+// SetTitle shows the named view listed and its sort order in the title,
+// none if empty.
+func (m *ListModel) SetTitle(view, sort string) {
+	m.list.Title = "Goot"
+	if view != "" {
+		m.list.Title += " @" + view
+	}
+	if sort != "" {
+		m.list.Title += " by " + sort
+	}
+}
+
+// SearchFunc returns the tasks matching a full-text search query, best
+// matches first.
+type SearchFunc func(query string, limit int) ([]tasks.SearchResult, error)
+
+// SetSearch makes the '/' filter match tasks by search, in titles and
+// descriptions, instead of fuzzy matching titles.
+func (m *ListModel) SetSearch(search SearchFunc) {
+	ids := m.ids
+	m.list.Filter = func(term string, targets []string) []list.Rank {
+		results, err := search(prefixQuery(term), 0)
+		if err != nil {
+			return list.DefaultFilter(term, targets)
+		}
+
+		ids.mu.Lock()
+		index := make(map[int]int, len(ids.ids))
+		for i, id := range ids.ids {
+			index[id] = i
+		}
+		ids.mu.Unlock()
+
+		ranks := make([]list.Rank, 0, len(results))
+		for _, res := range results {
+			i, ok := index[res.Task.ID]
+			if !ok || i >= len(targets) {
+				continue
+			}
+			ranks = append(ranks, list.Rank{Index: i, MatchedIndexes: matchedIndexes(targets[i], term)})
+		}
+		return ranks
+	}
+}
+
+// prefixQuery matches the last word of a query being typed as a prefix.
+func prefixQuery(q string) string {
+	fields := strings.Fields(q)
+	if last, _ := utf8.DecodeLastRuneInString(q); len(fields) == 0 || strings.Count(q, `"`)%2 == 1 || unicode.IsSpace(last) {
+		return q
+	}
+	switch last := fields[len(fields)-1]; {
+	case last == "OR", last == "NOT", strings.HasSuffix(last, "*"), strings.HasSuffix(last, `"`):
+		return q
+	}
+	return q + "*"
+}
+
+// matchedIndexes returns the indexes of the runes of target matching the
+// words of query, ignoring case.
+func matchedIndexes(target, query string) []int {
+	lower := func(s string) []rune {
+		rs := []rune(s)
+		for i, r := range rs {
+			rs[i] = unicode.ToLower(r)
+		}
+		return rs
+	}
+	t := lower(target)
+	matched := make([]bool, len(t))
+	negated := false
+	for _, word := range strings.Fields(query) {
+		if word == "OR" || word == "NOT" {
+			negated = word == "NOT"
+			continue
+		}
+		w := lower(strings.Trim(word, `"*`))
+		if len(w) == 0 || negated {
+			negated = false
+			continue
+		}
+		for i := 0; i+len(w) <= len(t); i++ {
+			if string(t[i:i+len(w)]) == string(w) {
+				for j := i; j < i+len(w); j++ {
+					matched[j] = true
+				}
+			}
+		}
+	}
+	var indexes []int
+	for i, ok := range matched {
+		if ok {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
 func (m ListModel) Init() tea.Cmd {
 	return nil
 }
@@ -141,6 +265,12 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case key.Matches(msg, m.keys.syncTasks):
 				m.Method = "sync"
 				return m, nil
+			case key.Matches(msg, m.keys.nextView):
+				m.Method = "view"
+				return m, nil
+			case key.Matches(msg, m.keys.nextSort):
+				m.Method = "sort"
+				return m, nil
 			}
 		}
 	}
@@ -177,6 +307,8 @@ func InitialListModel() ListModel {
 			listKeys.deleteTask,
 			listKeys.toogleComplete,
 			listKeys.syncTasks,
+			listKeys.nextView,
+			listKeys.nextSort,
 		}
 	}
 	list.AdditionalFullHelpKeys = func() []key.Binding {
@@ -186,10 +318,12 @@ func InitialListModel() ListModel {
 			listKeys.deleteTask,
 			listKeys.toogleComplete,
 			listKeys.syncTasks,
+			listKeys.nextView,
+			listKeys.nextSort,
 		}
 	}
 
-	m = ListModel{list: list, keys: listKeys}
+	m = ListModel{list: list, keys: listKeys, ids: &itemIDs{}}
 
 	return m
 }
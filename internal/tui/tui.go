@@ -1,8 +1,13 @@
 package tui
 
 import (
+	"context"
+	"maps"
+	"slices"
+
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/zeerodex/goot/internal/query"
 	"github.com/zeerodex/goot/internal/services"
 	"github.com/zeerodex/goot/internal/tasks"
 	"github.com/zeerodex/goot/internal/tui/components"
@@ -25,68 +30,93 @@ type MainModel struct {
 	listModel     components.ListModel
 	creationModel components.CreationModel
 
+	// view is the named view listed, all tasks if empty.
+	view string
+	// sort is the order of the list, one of sortOrders.
+	sort string
+
 	tasks tasks.Tasks
 	s     services.TaskService
+	ctx   context.Context
 	err   error
 }
 
-func syncTasksCmd(s services.TaskService) tea.Cmd {
+func syncTasksCmd(ctx context.Context, s services.TaskService) tea.Cmd {
 	return func() tea.Msg {
-		err := s.Sync()
+		err := s.Sync(ctx)
 		if err != nil {
 			return errMsg{err: err}
 		}
-		return fetchTasksCmd(s)()
+		return fetchTasksMsg{}
 	}
 }
 
-func updateTaskCmd(s services.TaskService, task *tasks.Task) tea.Cmd {
+func updateTaskCmd(ctx context.Context, s services.TaskService, task *tasks.Task) tea.Cmd {
 	return func() tea.Msg {
-		_, err := s.UpdateTask(task)
+		_, err := s.UpdateTask(ctx, task)
 		if err != nil {
 			return errMsg{err: err}
 		}
-		return fetchTasksCmd(s)()
+		return fetchTasksMsg{}
 	}
 }
 
-func fetchTasksCmd(s services.TaskService) tea.Cmd {
+// sortOrders are the orders the list cycles through, as sort keys of
+// query.ParseSort. The first one is the default order.
+var sortOrders = []string{"", "due", "-priority", "-created", "-modified", "title"}
+
+// fetchTasksCmd fetches the tasks of the named view, all tasks if view is
+// empty, ordered by sort.
+func fetchTasksCmd(s services.TaskService, view, sort string) tea.Cmd {
 	return func() tea.Msg {
-		tasks, err := s.GetAllTasks()
+		q := ""
+		if view != "" {
+			q = "@" + view
+		}
+		var opts query.Options
+		var err error
+		if opts.Sort, err = query.ParseSort(sort); err != nil {
+			return errMsg{err: err}
+		}
+		groups, err := s.ListTasks(q, opts)
 		if err != nil {
 			return errMsg{err: err}
 		}
-		return fetchedTasksMsg{Tasks: tasks}
+		var ts tasks.Tasks
+		if len(groups) > 0 {
+			ts = groups[0].Tasks
+		}
+		return fetchedTasksMsg{Tasks: ts}
 	}
 }
 
-func createTaskCmd(s services.TaskService, task *tasks.Task) tea.Cmd {
+func createTaskCmd(ctx context.Context, s services.TaskService, task *tasks.Task) tea.Cmd {
 	return func() tea.Msg {
-		_, err := s.CreateTask(task)
+		_, err := s.CreateTask(ctx, task)
 		if err != nil {
 			return errMsg{err: err}
 		}
-		return fetchTasksCmd(s)()
+		return fetchTasksMsg{}
 	}
 }
 
-func deleteTaskCmd(s services.TaskService, id int) tea.Cmd {
+func deleteTaskCmd(ctx context.Context, s services.TaskService, id int) tea.Cmd {
 	return func() tea.Msg {
-		err := s.DeleteTaskByID(id)
+		err := s.DeleteTaskByID(ctx, id)
 		if err != nil {
 			return errMsg{err: err}
 		}
-		return fetchTasksCmd(s)()
+		return fetchTasksMsg{}
 	}
 }
 
-func setTaskCompletedCmd(s services.TaskService, id int, completed bool) tea.Cmd {
+func setTaskCompletedCmd(ctx context.Context, s services.TaskService, id int, completed bool) tea.Cmd {
 	return func() tea.Msg {
-		err := s.SetTaskCompleted(id, completed)
+		err := s.SetTaskCompleted(ctx, id, completed)
 		if err != nil {
 			return errMsg{err: err}
 		}
-		return fetchTasksCmd(s)()
+		return fetchTasksMsg{}
 	}
 }
 
@@ -126,7 +156,7 @@ type errMsg struct {
 }
 
 func (m MainModel) Init() tea.Cmd {
-	return tea.Batch(fetchTasksCmd(m.s), m.listenForAPIWorkerResults())
+	return tea.Batch(fetchTasksCmd(m.s, m.view, m.sort), m.listenForAPIWorkerResults())
 }
 
 func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -155,16 +185,16 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 	case syncTasksMsg:
-		cmds = append(cmds, syncTasksCmd(m.s), m.listenForAPIWorkerResults())
+		cmds = append(cmds, syncTasksCmd(m.ctx, m.s), m.listenForAPIWorkerResults())
 
 	case deleteTaskMsg:
-		cmds = append(cmds, deleteTaskCmd(m.s, msg.id), m.listenForAPIWorkerResults())
+		cmds = append(cmds, deleteTaskCmd(m.ctx, m.s, msg.id), m.listenForAPIWorkerResults())
 
 	case setTaskCompletedMsg:
-		cmds = append(cmds, setTaskCompletedCmd(m.s, msg.id, msg.completed), m.listenForAPIWorkerResults())
+		cmds = append(cmds, setTaskCompletedCmd(m.ctx, m.s, msg.id, msg.completed), m.listenForAPIWorkerResults())
 
 	case fetchTasksMsg:
-		cmds = append(cmds, fetchTasksCmd(m.s))
+		cmds = append(cmds, fetchTasksCmd(m.s, m.view, m.sort))
 
 	case fetchedTasksMsg:
 		m.tasks = msg.Tasks
@@ -181,7 +211,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case updatedTaskMsg:
 		m.creationModel = components.InitialCreationModel()
-		cmds = append(cmds, updateTaskCmd(m.s, msg.Task), m.listenForAPIWorkerResults())
+		cmds = append(cmds, updateTaskCmd(m.ctx, m.s, msg.Task), m.listenForAPIWorkerResults())
 
 		m.currentState = m.previuosState
 
@@ -193,7 +223,7 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case createdTaskMsg:
 		m.creationModel = components.InitialCreationModel()
-		cmds = append(cmds, createTaskCmd(m.s, msg.Task), m.listenForAPIWorkerResults())
+		cmds = append(cmds, createTaskCmd(m.ctx, m.s, msg.Task), m.listenForAPIWorkerResults())
 
 		m.currentState = m.previuosState
 
@@ -238,6 +268,16 @@ func (m MainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, func() tea.Msg {
 				return syncTasksMsg{}
 			})
+		case "view":
+			m.listModel.Method = ""
+			m.view = nextView(m.s.Views(), m.view)
+			m.listModel.SetTitle(m.view, m.sort)
+			cmds = append(cmds, fetchTasksCmd(m.s, m.view, m.sort))
+		case "sort":
+			m.listModel.Method = ""
+			m.sort = sortOrders[(slices.Index(sortOrders, m.sort)+1)%len(sortOrders)]
+			m.listModel.SetTitle(m.view, m.sort)
+			cmds = append(cmds, fetchTasksCmd(m.s, m.view, m.sort))
 		}
 
 	case CreationView:
@@ -274,8 +314,20 @@ func (m MainModel) View() string {
 	return ""
 }
 
-func InitialMainModel(s services.TaskService) MainModel {
+// nextView returns the name of the view following current in views, in
+// alphabetical order, or "" for all tasks after the last one.
+func nextView(views map[string]string, current string) string {
+	names := slices.Sorted(maps.Keys(views))
+	i := slices.Index(names, current)
+	if i+1 < len(names) {
+		return names[i+1]
+	}
+	return ""
+}
+
+func InitialMainModel(ctx context.Context, s services.TaskService) MainModel {
 	listModel := components.InitialListModel()
+	listModel.SetSearch(s.SearchTasks)
 	creationModel := components.InitialCreationModel()
 
 	m := MainModel{
@@ -283,7 +335,8 @@ func InitialMainModel(s services.TaskService) MainModel {
 		listModel:     listModel,
 		creationModel: creationModel,
 
-		s: s,
+		s:   s,
+		ctx: ctx,
 	}
 
 	return m
@@ -1,49 +1,139 @@
 package workers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/metrics"
 	"github.com/zeerodex/goot/internal/repositories"
 	"github.com/zeerodex/goot/internal/tasks"
 )
 
-func processMissingAPITasks(atasks, ltasks tasks.Tasks, api apis.API, repo repositories.TaskRepository) error {
+// findByAPIID returns the task of ts whose ID in provider p is id.
+func findByAPIID(ts tasks.Tasks, p apis.Provider, id string) (*tasks.Task, bool) {
+	if id == "" {
+		return nil, false
+	}
+	for i := range ts {
+		if p.ID(&ts[i]) == id {
+			return &ts[i], true
+		}
+	}
+	return nil, false
+}
+
+func processMissingAPITasks(ctx context.Context, log *slog.Logger, bus *events.Bus, p apis.Provider, atasks, ltasks tasks.Tasks, api apis.API, repo repositories.TaskRepository) error {
+	caps := api.Capabilities()
+	if caps.ReadMostly {
+		return nil
+	}
+	var missing []*tasks.Task
 	for _, task := range ltasks {
-		if _, found := atasks.FindTaskByGoogleID(task.GoogleID); found || task.Deleted {
+		if _, found := findByAPIID(atasks, p, p.ID(&task)); found || task.Deleted {
 			continue
 		}
+		if !caps.Tombstones && p.ID(&task) != "" {
+			// APIs without tombstones leave completed and deleted tasks out
+			// of their lists, so a task synced before is likely done
+			// remotely rather than missing.
+			if err := resolveMissingTask(ctx, log, bus, p, &task, api, repo); err != nil {
+				return err
+			}
+			continue
+		}
+		missing = append(missing, &task)
+	}
+
+	var createErr error
+	if batch, ok := api.(apis.BatchAPI); ok && len(missing) > 1 {
+		if _, err := batch.CreateTasks(ctx, missing); err != nil {
+			createErr = fmt.Errorf("failed to create %d missing api tasks: %w", len(missing), err)
+		} else {
+			log.Debug("sync: created missing api tasks in batch", "count", len(missing))
+		}
+	} else {
+		for _, task := range missing {
+			if _, err := api.CreateTask(ctx, task); err != nil {
+				createErr = fmt.Errorf("failed to create api task for task ID %d: %w", task.ID, err)
+				break
+			}
+		}
+	}
 
-		_, err := api.CreateTask(&task)
+	// Store the IDs of the tasks created before a failure too, or the next
+	// sync would create them again.
+	for _, task := range missing {
+		if p.ID(task) == "" {
+			continue
+		}
+		err := repo.UpdateTaskAPIID(task.ID, p.ID(task), p.Name)
 		if err != nil {
-			return fmt.Errorf("failed to create local task for Google ID '%s': %w", task.GoogleID, err)
+			return errors.Join(createErr, fmt.Errorf("failed to update %s ID '%s' of task ID %d: %w", p.Name, p.ID(task), task.ID, err))
 		}
+		log.Debug("sync: created missing api task", "task_id", task.ID, "api_id", p.ID(task))
+	}
 
-		ltasks = append(ltasks, task)
+	return createErr
+}
 
-		err = repo.UpdateGoogleID(task.ID, task.GoogleID)
-		if err != nil {
-			return fmt.Errorf("failed to update Google ID '%s' of task ID %d: %w", task.GoogleID, task.ID, err)
+// resolveMissingTask handles task, synced before but missing from the live
+// tasks of api. The task is looked up to tell whether it was completed or
+// deleted remotely, in which case it is completed locally unless it was
+// reopened locally since, or just left out of an incomplete listing.
+func resolveMissingTask(ctx context.Context, log *slog.Logger, bus *events.Bus, p apis.Provider, task *tasks.Task, api apis.API, repo repositories.TaskRepository) error {
+	if task.Completed {
+		return nil
+	}
+	id := p.ID(task)
+	atask, err := api.GetTaskByID(ctx, id)
+	switch {
+	case errors.Is(err, apis.ErrNotFound):
+		// Deleted remotely.
+	case err != nil:
+		return fmt.Errorf("failed to get %s task '%s' missing from the list: %w", p.Name, id, err)
+	case !atask.Completed && !atask.Deleted:
+		log.Debug("sync: task missing from api list is still active", "task_id", task.ID, "api_id", id)
+		return nil
+	case !atask.Deleted && !atask.LastModified.IsZero() && task.LastModified.After(atask.LastModified):
+		if err := api.SetTaskCompleted(ctx, id, false); err != nil {
+			return fmt.Errorf("failed to reopen %s task '%s': %w", p.Name, id, err)
 		}
+		log.Debug("sync: reopened api task reopened locally", "task_id", task.ID, "api_id", id)
+		return nil
 	}
 
+	if err := repo.SetTaskCompleted(task.ID, true); err != nil {
+		return fmt.Errorf("failed to complete task ID %d missing from %s: %w", task.ID, p.Name, err)
+	}
+	task.Completed = true
+	bus.Publish(events.Event{Type: events.TaskCompleted, Source: p.Name, Task: task})
+	log.Debug("sync: completed task missing from api", "task_id", task.ID, "api_id", p.ID(task))
 	return nil
 }
 
-func processMissingLocalTasks(atasks, ltasks tasks.Tasks, api apis.API, repo repositories.TaskRepository) error {
+func processMissingLocalTasks(ctx context.Context, log *slog.Logger, bus *events.Bus, p apis.Provider, atasks, ltasks tasks.Tasks, api apis.API, repo repositories.TaskRepository) error {
+	apiName := p.Name
+	caps := api.Capabilities()
 	for _, atask := range atasks {
-		task, found := ltasks.FindTaskByGoogleID(atask.GoogleID)
+		apiID := p.ID(&atask)
+		task, found := findByAPIID(ltasks, p, apiID)
 		var err error
 		if !found {
 			if atask.Deleted {
 				continue
 			}
 
-			_, err = repo.CreateTask(&atask)
+			created, err := repo.CreateTask(&atask)
 			if err != nil {
-				return fmt.Errorf("failed to create local task for Google ID '%s': %w", atask.GoogleID, err)
+				return fmt.Errorf("failed to create local task for %s ID '%s': %w", apiName, apiID, err)
 			}
+			bus.Publish(events.Event{Type: events.TaskCreated, Source: apiName, Task: created})
+			log.Debug("sync: created missing local task", "task_id", atask.ID, "api_id", apiID)
 			continue
 		}
 
@@ -53,12 +143,15 @@ func processMissingLocalTasks(atasks, ltasks tasks.Tasks, api apis.API, repo rep
 				if err != nil {
 					return fmt.Errorf("failed to delete marked as deleted local task ID %d: %w", task.ID, err)
 				}
+				bus.Publish(events.Event{Type: events.TaskDeleted, Source: apiName, Task: task})
+				log.Debug("sync: deleted local task deleted remotely", "task_id", task.ID, "api_id", apiID)
 			}
 			if task.Deleted {
-				err = api.DeleteTaskByID(atask.GoogleID)
+				err = api.DeleteTaskByID(ctx, apiID)
 				if err != nil {
-					return fmt.Errorf("failed to delete marked as deleted google task Google ID '%s': %w", atask.GoogleID, err)
+					return fmt.Errorf("failed to delete marked as deleted %s task '%s': %w", apiName, apiID, err)
 				}
+				log.Debug("sync: deleted api task deleted locally", "task_id", task.ID, "api_id", apiID)
 			}
 		}
 
@@ -67,18 +160,43 @@ func processMissingLocalTasks(atasks, ltasks tasks.Tasks, api apis.API, repo rep
 			atask.ID = task.ID
 			switch timeDiff {
 			case -1:
-				_, err = api.PatchTask(task)
+				metrics.SyncConflicts.WithLabelValues(apiName, "local").Inc()
+				_, err = api.PatchTask(ctx, task)
 				if err != nil {
-					return fmt.Errorf("failed to patch google task (Google ID '%s') with newer local task (ID %d): %w", task.GoogleID, task.ID, err)
+					return fmt.Errorf("failed to patch %s task '%s' with newer local task (ID %d): %w", apiName, apiID, task.ID, err)
 				}
+				log.Debug("sync: patched api task with newer local task", "task_id", task.ID, "api_id", apiID)
 			case 1:
-				if atask.Due.Truncate(24 * time.Hour).Equal(task.Due.Truncate(24 * time.Hour)) {
+				metrics.SyncConflicts.WithLabelValues(apiName, "api").Inc()
+				// Keep the local fields the API could not store.
+				if !caps.DueTime && atask.Due.Truncate(24*time.Hour).Equal(task.Due.Truncate(24*time.Hour)) {
 					atask.Due = task.Due
 				}
-				_, err = repo.UpdateTask(&atask)
+				if !caps.Description {
+					atask.Description = task.Description
+				}
+				if !caps.Priority {
+					atask.Priority = task.Priority
+				}
+				if !caps.Labels {
+					atask.Tags = task.Tags
+				}
+				if !caps.Recurrence {
+					atask.Recurrence = task.Recurrence
+				}
+				updated, err := repo.UpdateTask(&atask)
 				if err != nil {
-					return fmt.Errorf("failed to update local task (ID %d) with newer Google task (Google ID '%s'): %w", task.ID, atask.GoogleID, err)
+					return fmt.Errorf("failed to update local task (ID %d) with newer %s task '%s': %w", task.ID, apiName, apiID, err)
+				}
+				bus.Publish(events.Event{Type: events.TaskUpdated, Source: apiName, Task: updated})
+				if updated.Completed != task.Completed {
+					e := events.Event{Type: events.TaskCompleted, Source: apiName, Task: updated}
+					if !updated.Completed {
+						e.Type = events.TaskUncompleted
+					}
+					bus.Publish(e)
 				}
+				log.Debug("sync: updated local task with newer api task", "task_id", task.ID, "api_id", apiID)
 			}
 		}
 	}
@@ -86,35 +204,54 @@ func processMissingLocalTasks(atasks, ltasks tasks.Tasks, api apis.API, repo rep
 	return nil
 }
 
-func (w *Worker) SyncAPITasks() error {
-	for _, api := range w.apis {
-		var tasks, deletedTasks, atasks tasks.Tasks
-		var err error
-
-		tasks, err = w.repo.GetAllTasks()
-		if err != nil {
-			return fmt.Errorf("failed to get all local tasks: %w", err)
-		}
-		deletedTasks, err = w.repo.GetAllDeletedTasks()
-		if err != nil {
-			return fmt.Errorf("failed to get all deleted local tasks: %w", err)
+func (w *Worker) SyncAPITasks(ctx context.Context, log *slog.Logger) error {
+	for name, api := range w.apis {
+		if err := w.syncAPITasks(ctx, log.With("api", name), name, api); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		atasks, err = api.GetAllTasksWithDeleted()
-		if err != nil {
-			return fmt.Errorf("failed to get all google tasks: %w", err)
-		}
+func (w *Worker) syncAPITasks(ctx context.Context, log *slog.Logger, apiName string, api apis.API) error {
+	var tasks, deletedTasks, atasks tasks.Tasks
+	var err error
 
-		tasks = append(tasks, deletedTasks...)
+	tasks, err = w.repo.GetAllTasks()
+	if err != nil {
+		return fmt.Errorf("failed to get all local tasks: %w", err)
+	}
+	deletedTasks, err = w.repo.GetAllDeletedTasks()
+	if err != nil {
+		return fmt.Errorf("failed to get all deleted local tasks: %w", err)
+	}
 
-		if err = processMissingAPITasks(atasks, tasks, api, w.repo); err != nil {
-			return fmt.Errorf("failed to process missing google tasks: %w", err)
-		}
+	// Without tombstones remote deletions cannot be told apart from tasks
+	// not synced yet, so only live tasks are fetched.
+	if api.Capabilities().Tombstones {
+		atasks, err = api.GetAllTasksWithDeleted(ctx)
+	} else {
+		atasks, err = api.GetAllTasks(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get all %s tasks: %w", apiName, err)
+	}
 
-		if err = processMissingLocalTasks(atasks, tasks, api, w.repo); err != nil {
-			return fmt.Errorf("failed to process missing local tasks: %w", err)
-		}
+	tasks = append(tasks, deletedTasks...)
+	log.Debug("sync: fetched tasks", "local_count", len(tasks), "api_count", len(atasks))
+
+	p, ok := apis.Lookup(apiName)
+	if !ok {
+		return fmt.Errorf("API '%s' has no registered provider", apiName)
+	}
 
+	if err = processMissingAPITasks(ctx, log, w.bus, p, atasks, tasks, api, w.repo); err != nil {
+		return fmt.Errorf("failed to process missing %s tasks: %w", apiName, err)
 	}
+
+	if err = processMissingLocalTasks(ctx, log, w.bus, p, atasks, tasks, api, w.repo); err != nil {
+		return fmt.Errorf("failed to process missing local tasks: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,275 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/database"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/repositories"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+const fakeName = "fake"
+
+func init() {
+	apis.Register(apis.Provider{
+		Name: fakeName,
+		New:  func(any, apis.Options) (apis.API, error) { return &fakeAPI{}, nil },
+	})
+}
+
+// fakeAPI keeps its tasks in memory. Deleted tasks are listed as such
+// only if caps has tombstones, otherwise completed tasks are not listed
+// either. Tasks titled "fail" cannot be created.
+type fakeAPI struct {
+	caps    apis.Capabilities
+	tasks   tasks.Tasks
+	created int
+	// listLimit truncates the listing of live tasks if set, as an API
+	// returning its first page only would.
+	listLimit int
+}
+
+func (f *fakeAPI) CreateTask(_ context.Context, task *tasks.Task) (*tasks.Task, error) {
+	if task.Title == "fail" {
+		return nil, errors.New("invalid task")
+	}
+	f.created++
+	task.SetAPIID(fakeName, strconv.Itoa(len(f.tasks)+1))
+	f.tasks = append(f.tasks, *task)
+	return task, nil
+}
+
+func (f *fakeAPI) find(id string) *tasks.Task {
+	for i := range f.tasks {
+		if f.tasks[i].APIID(fakeName) == id {
+			return &f.tasks[i]
+		}
+	}
+	return nil
+}
+
+func (f *fakeAPI) GetTaskByID(_ context.Context, id string) (*tasks.Task, error) {
+	t := f.find(id)
+	if t == nil || t.Deleted && !f.caps.Tombstones {
+		return nil, fmt.Errorf("task '%s': %w", id, apis.ErrNotFound)
+	}
+	return t, nil
+}
+
+func (f *fakeAPI) GetAllTasks(context.Context) (tasks.Tasks, error) {
+	var live tasks.Tasks
+	for _, t := range f.tasks {
+		if !t.Deleted && (f.caps.Tombstones || !t.Completed) {
+			live = append(live, t)
+		}
+	}
+	if f.listLimit > 0 && len(live) > f.listLimit {
+		live = live[:f.listLimit]
+	}
+	return live, nil
+}
+
+func (f *fakeAPI) GetAllTasksWithDeleted(context.Context) (tasks.Tasks, error) {
+	return append(tasks.Tasks(nil), f.tasks...), nil
+}
+
+func (f *fakeAPI) PatchTask(_ context.Context, task *tasks.Task) (*tasks.Task, error) {
+	if t := f.find(task.APIID(fakeName)); t != nil {
+		*t = *task
+	}
+	return task, nil
+}
+
+func (f *fakeAPI) SetTaskCompleted(_ context.Context, id string, completed bool) error {
+	if t := f.find(id); t != nil {
+		t.Completed = completed
+	}
+	return nil
+}
+
+func (f *fakeAPI) DeleteTaskByID(_ context.Context, id string) error {
+	if t := f.find(id); t != nil {
+		t.Deleted = true
+	}
+	return nil
+}
+
+func (f *fakeAPI) Capabilities() apis.Capabilities {
+	return f.caps
+}
+
+func newSyncWorker(t *testing.T, api apis.API) (*Worker, repositories.TaskRepository) {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("database.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := repositories.NewTaskRepository(db, logger)
+	return NewWorker(0, nil, nil, map[string]apis.API{fakeName: api}, repo, events.NewBus(logger), logger), repo
+}
+
+func syncFake(t *testing.T, w *Worker) {
+	t.Helper()
+	if err := w.syncAPITasks(context.Background(), w.log, fakeName, w.apis[fakeName]); err != nil {
+		t.Fatalf("syncAPITasks() error = %v", err)
+	}
+}
+
+// TestSyncWithoutTombstones checks that tasks synced before and missing from
+// an API without tombstones are completed rather than created again.
+func TestSyncWithoutTombstones(t *testing.T) {
+	api := &fakeAPI{caps: apis.Capabilities{Description: true}}
+	w, repo := newSyncWorker(t, api)
+
+	synced := &tasks.Task{Title: "synced"}
+	synced.SetAPIID(fakeName, "gone")
+	if _, err := repo.CreateTask(synced); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateTask(&tasks.Task{Title: "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		syncFake(t, w)
+	}
+	if api.created != 1 || api.tasks[0].Title != "new" {
+		t.Errorf("created %d api tasks %v, want only 'new'", api.created, api.tasks)
+	}
+	got, err := repo.GetTaskByID(synced.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Completed {
+		t.Error("task missing from the api was not completed")
+	}
+}
+
+// TestSyncConfirmsMissingTasks checks that a task missing from the list of
+// an API without tombstones is only completed once the API confirms it.
+func TestSyncConfirmsMissingTasks(t *testing.T) {
+	api := &fakeAPI{caps: apis.Capabilities{Description: true}}
+	w, repo := newSyncWorker(t, api)
+	var local []*tasks.Task
+	for _, title := range []string{"a", "b", "c"} {
+		task := &tasks.Task{Title: title}
+		if _, err := repo.CreateTask(task); err != nil {
+			t.Fatal(err)
+		}
+		local = append(local, task)
+	}
+	syncFake(t, w)
+
+	completed := func(task *tasks.Task) bool {
+		t.Helper()
+		got, err := repo.GetTaskByID(task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.Completed
+	}
+
+	// Only the first page is listed, the other tasks are still active.
+	api.listLimit = 1
+	syncFake(t, w)
+	if completed(local[1]) || completed(local[2]) {
+		t.Error("tasks left out of a truncated listing were completed")
+	}
+
+	// b was completed remotely, c completed remotely and reopened locally.
+	api.listLimit = 0
+	past := time.Now().Add(-time.Hour)
+	api.tasks[1].Completed, api.tasks[1].LastModified = true, time.Now().Add(time.Hour)
+	api.tasks[2].Completed, api.tasks[2].LastModified = true, past
+	syncFake(t, w)
+	if !completed(local[1]) {
+		t.Error("task completed remotely was not completed locally")
+	}
+	if completed(local[2]) || api.tasks[2].Completed {
+		t.Error("task reopened locally was completed again")
+	}
+}
+
+// TestSyncKeepsUnsupportedFields checks that a newer api task does not clear
+// the local fields its API cannot store.
+func TestSyncKeepsUnsupportedFields(t *testing.T) {
+	api := &fakeAPI{caps: apis.Capabilities{Description: true, Tombstones: true}}
+	w, repo := newSyncWorker(t, api)
+
+	task := &tasks.Task{Title: "rent", Priority: tasks.PriorityHigh, Tags: []string{"home"}, Recurrence: "RRULE:FREQ=MONTHLY"}
+	if _, err := repo.CreateTask(task); err != nil {
+		t.Fatal(err)
+	}
+	syncFake(t, w)
+
+	local, err := repo.GetTaskByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &api.tasks[0]
+	remote.Title = "pay rent"
+	remote.Priority, remote.Tags, remote.Recurrence = tasks.PriorityNone, nil, ""
+	remote.LastModified = local.LastModified.Add(time.Minute)
+	syncFake(t, w)
+
+	got, err := repo.GetTaskByID(task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "pay rent" {
+		t.Errorf("title = %q, want the api title", got.Title)
+	}
+	if got.Priority != tasks.PriorityHigh || !slices.Equal(got.Tags, task.Tags) || got.Recurrence != task.Recurrence {
+		t.Errorf("priority, tags, recurrence = %v, %v, %q, want the local ones", got.Priority, got.Tags, got.Recurrence)
+	}
+}
+
+// fakeBatchAPI creates tasks in batch, creating the valid ones even if
+// others fail.
+type fakeBatchAPI struct {
+	*fakeAPI
+}
+
+func (f fakeBatchAPI) CreateTasks(ctx context.Context, ts []*tasks.Task) ([]*tasks.Task, error) {
+	var errs []error
+	for _, task := range ts {
+		if _, err := f.CreateTask(ctx, task); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return ts, errors.Join(errs...)
+}
+
+// TestSyncStoresPartialBatch checks that the tasks a failed batch created
+// are not created again by the next sync.
+func TestSyncStoresPartialBatch(t *testing.T) {
+	api := fakeBatchAPI{&fakeAPI{caps: apis.Capabilities{Description: true, Tombstones: true}}}
+	w, repo := newSyncWorker(t, api)
+	for _, title := range []string{"a", "fail", "b"} {
+		if _, err := repo.CreateTask(&tasks.Task{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 2 {
+		if err := w.syncAPITasks(context.Background(), w.log, fakeName, api); err == nil {
+			t.Fatal("syncAPITasks() succeeded, want the batch error")
+		}
+	}
+	if api.created != 2 {
+		t.Errorf("created %d api tasks, want 2", api.created)
+	}
+}
@@ -2,9 +2,16 @@ package workers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/logging"
+	"github.com/zeerodex/goot/internal/metrics"
 	"github.com/zeerodex/goot/internal/repositories"
 	"github.com/zeerodex/goot/internal/tasks"
 )
@@ -16,9 +23,11 @@ type Worker struct {
 
 	apis map[string]apis.API
 	repo repositories.TaskRepository
+	bus  *events.Bus
+	log  *slog.Logger
 }
 
-func NewWorker(id int, jobChan <-chan APIJob, resChan chan<- APIJobResult, apis map[string]apis.API, repo repositories.TaskRepository) *Worker {
+func NewWorker(id int, jobChan <-chan APIJob, resChan chan<- APIJobResult, apis map[string]apis.API, repo repositories.TaskRepository, bus *events.Bus, logger *slog.Logger) *Worker {
 	return &Worker{
 		ID:       id,
 		jobQueue: jobChan,
@@ -26,10 +35,15 @@ func NewWorker(id int, jobChan <-chan APIJob, resChan chan<- APIJobResult, apis
 
 		apis: apis,
 		repo: repo,
+		bus:  bus,
+		log:  logger.With("worker_id", id),
 	}
 }
 
-func (w *Worker) Start(ctx context.Context, wg *sync.WaitGroup) {
+// Start processes jobs until ctx is done or quit is closed. Cancelling ctx
+// also cancels the job in flight, while quit lets it finish and deliver its
+// result first.
+func (w *Worker) Start(ctx context.Context, quit <-chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for {
@@ -38,7 +52,7 @@ func (w *Worker) Start(ctx context.Context, wg *sync.WaitGroup) {
 			if !ok {
 				return
 			}
-			result := w.processAPIJob(job)
+			result := w.processAPIJob(ctx, job)
 
 			select {
 			case w.resultCh <- result:
@@ -47,30 +61,69 @@ func (w *Worker) Start(ctx context.Context, wg *sync.WaitGroup) {
 			}
 		case <-ctx.Done():
 			return
+		case <-quit:
+			return
 		}
 	}
 }
 
 // TODO: implement retry logic
-func (w *Worker) processAPIJob(job APIJob) APIJobResult {
+func (w *Worker) processAPIJob(poolCtx context.Context, job APIJob) APIJobResult {
+	log := w.log.With("job_id", job.ID, "op", job.Operation)
+	if job.TaskID != 0 {
+		log = log.With("task_id", job.TaskID)
+	}
+	if job.API != "" {
+		log = log.With("api", job.API)
+	}
+
+	ctx := job.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(logging.WithLogger(ctx, log))
+	defer cancel()
+	defer context.AfterFunc(poolCtx, cancel)()
+	log.Debug("job started")
+	start := time.Now()
+
 	var err error
 	switch job.Operation {
 	case SetTaskCompletedOp:
-		err = w.processSetTaskCompletedOp(job.TaskID, job.Completed)
+		err = w.processSetTaskCompletedOp(ctx, job.TaskID, job.Completed)
 	case UpdateTaskOp:
-		err = w.processUpdateTaskOp(job.Task)
+		err = w.processUpdateTaskOp(ctx, job.Task)
 	case DeleteTaskOp:
-		err = w.processDeleteTaskOp(job.TaskID)
+		err = w.processDeleteTaskOp(ctx, job.TaskID)
 	case CreateTaskOp:
-		err = w.processCreateTaskOp(job.Task)
+		err = w.processCreateTaskOp(ctx, job.Task)
 	case SyncTasksOp:
-		err = w.processSyncTasksOp()
+		err = w.processSyncTasksOp(ctx, log, job.API)
+	}
+
+	duration := time.Since(start)
+	if err != nil {
+		log.Warn("job failed", "duration", duration, "err", err)
+		metrics.JobDuration.WithLabelValues(string(job.Operation), "failure").Observe(duration.Seconds())
+	} else {
+		log.Debug("job done", "duration", duration)
+		metrics.JobDuration.WithLabelValues(string(job.Operation), "success").Observe(duration.Seconds())
+	}
+
+	if job.Operation == SyncTasksOp {
+		e := events.Event{Type: events.SyncCompleted, API: job.API}
+		if err != nil {
+			e.Type = events.SyncFailed
+			e.Error = err.Error()
+		}
+		w.bus.Publish(e)
 	}
 
 	res := APIJobResult{
 		JobID:     job.ID,
 		Operation: job.Operation,
 		TaskID:    job.TaskID,
+		API:       job.API,
 		Success:   err == nil,
 		Err:       err,
 	}
@@ -78,13 +131,16 @@ func (w *Worker) processAPIJob(job APIJob) APIJobResult {
 	return res
 }
 
-func (w *Worker) processDeleteTaskOp(id int) error {
+func (w *Worker) processDeleteTaskOp(ctx context.Context, id int) error {
 	for apiName, api := range w.apis {
 		apiId, err := w.repo.GetTaskAPIID(id, apiName)
+		if errors.Is(err, repositories.ErrNoAPIID) {
+			continue
+		}
 		if err != nil {
 			return err
 		}
-		err = api.DeleteTaskByID(apiId)
+		err = api.DeleteTaskByID(ctx, apiId)
 		if err != nil {
 			return err
 		}
@@ -93,22 +149,25 @@ func (w *Worker) processDeleteTaskOp(id int) error {
 	return nil
 }
 
-func (w *Worker) processCreateTaskOp(task *tasks.Task) error {
+func (w *Worker) processCreateTaskOp(ctx context.Context, task *tasks.Task) error {
 	for apiName, api := range w.apis {
-		apiTask, err := api.CreateTask(task)
+		if api.Capabilities().ReadMostly {
+			continue
+		}
+		p, ok := apis.Lookup(apiName)
+		if !ok {
+			return fmt.Errorf("API '%s' has no registered provider", apiName)
+		}
+		// Imported tasks may already exist in the API.
+		if p.ID(task) != "" {
+			continue
+		}
+		apiTask, err := api.CreateTask(ctx, task)
 		if err != nil {
 			return err
 		}
 
-		var apiId string
-		switch apiName {
-		case "gtasks":
-			apiId = apiTask.GoogleID
-		case "todoist":
-			apiId = apiTask.TodoistID
-		}
-
-		err = w.repo.UpdateTaskAPIID(task.ID, apiId, apiName)
+		err = w.repo.UpdateTaskAPIID(task.ID, p.ID(apiTask), apiName)
 		if err != nil {
 			return err
 		}
@@ -116,9 +175,12 @@ func (w *Worker) processCreateTaskOp(task *tasks.Task) error {
 	return nil
 }
 
-func (w *Worker) processUpdateTaskOp(task *tasks.Task) error {
-	for _, api := range w.apis {
-		_, err := api.PatchTask(task)
+func (w *Worker) processUpdateTaskOp(ctx context.Context, task *tasks.Task) error {
+	for apiName, api := range w.apis {
+		if p, ok := apis.Lookup(apiName); ok && p.ID(task) == "" {
+			continue
+		}
+		_, err := api.PatchTask(ctx, task)
 		if err != nil {
 			return err
 		}
@@ -126,14 +188,17 @@ func (w *Worker) processUpdateTaskOp(task *tasks.Task) error {
 	return nil
 }
 
-func (w *Worker) processSetTaskCompletedOp(id int, completed bool) error {
+func (w *Worker) processSetTaskCompletedOp(ctx context.Context, id int, completed bool) error {
 	for apiName, api := range w.apis {
 		apiId, err := w.repo.GetTaskAPIID(id, apiName)
+		if errors.Is(err, repositories.ErrNoAPIID) {
+			continue
+		}
 		if err != nil {
 			return err
 		}
 
-		err = api.SetTaskCompleted(apiId, completed)
+		err = api.SetTaskCompleted(ctx, apiId, completed)
 		if err != nil {
 			return err
 		}
@@ -142,7 +207,13 @@ func (w *Worker) processSetTaskCompletedOp(id int, completed bool) error {
 	return nil
 }
 
-func (w *Worker) processSyncTasksOp() error {
-	err := w.SyncAPITasks()
-	return err
+func (w *Worker) processSyncTasksOp(ctx context.Context, log *slog.Logger, apiName string) error {
+	if apiName == "" {
+		return w.SyncAPITasks(ctx, log)
+	}
+	api, ok := w.apis[apiName]
+	if !ok {
+		return fmt.Errorf("API '%s' is not enabled", apiName)
+	}
+	return w.syncAPITasks(ctx, log, apiName, api)
 }
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/events"
 	"github.com/zeerodex/goot/internal/repositories"
 	"github.com/zeerodex/goot/internal/tasks"
 )
@@ -28,13 +33,19 @@ type APIJob struct {
 	Task      *tasks.Task
 	TaskID    int
 	Completed bool
-	Retry     int
+	// API limits SyncTasksOp to a single API. All APIs are synced if empty.
+	API string
+
+	// ctx carries the values, but not the cancellation, of the context the
+	// job was submitted with.
+	ctx context.Context
 }
 
 type APIJobResult struct {
 	JobID     int
 	Operation APIOperation
 	TaskID    int
+	API       string
 	Success   bool
 	Err       error
 }
@@ -42,6 +53,9 @@ type APIJobResult struct {
 func (res *APIJobResult) ParseErr() error {
 	if res.Err != nil {
 		errStr := fmt.Sprintf("API: failed to process '%s' operation", res.Operation)
+		if res.API != "" {
+			errStr += fmt.Sprintf(" for %s", res.API)
+		}
 		if res.TaskID != 0 {
 			errStr += fmt.Sprintf(" on task ID %d", res.TaskID)
 		}
@@ -61,25 +75,62 @@ type APIWorkerPool struct {
 	cancel     context.CancelFunc
 	started    bool
 	mu         sync.RWMutex
+
+	// workersCancel stops the current generation of workers only, so that
+	// Reload can replace them while queued jobs stay in jobQueue.
+	// workersDone is done once they returned.
+	workersCancel context.CancelFunc
+	workersDone   *sync.WaitGroup
+	// reloadMu serializes reloads, which wait for workers without mu.
+	reloadMu sync.Mutex
+
+	apis map[string]apis.API
+	repo repositories.TaskRepository
+	bus  *events.Bus
+	log  *slog.Logger
+
+	nextJobID atomic.Int64
 }
 
-func NewAPIWorkerPool(numWorkers int, queueSize int, apis map[string]apis.API, repo repositories.TaskRepository) *APIWorkerPool {
+func NewAPIWorkerPool(numWorkers int, queueSize int, apis map[string]apis.API, repo repositories.TaskRepository, bus *events.Bus, logger *slog.Logger) *APIWorkerPool {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	wp := &APIWorkerPool{
 		numWorkers: numWorkers,
-		workers:    make([]*Worker, numWorkers),
 		jobQueue:   make(chan APIJob, queueSize),
 		resQueue:   make(chan APIJobResult, queueSize),
 		ctx:        ctx,
 		cancel:     cancel,
 		started:    false,
+		repo:       repo,
+		bus:        bus,
+		log:        logger.With("component", "workers"),
 	}
+	wp.newWorkers(apis)
 
+	return wp
+}
+
+func (wp *APIWorkerPool) newWorkers(apis map[string]apis.API) {
+	wp.apis = apis
+	wp.workers = make([]*Worker, wp.numWorkers)
 	for i := range wp.numWorkers {
-		wp.workers[i] = NewWorker(i, wp.jobQueue, wp.resQueue, apis, repo)
+		wp.workers[i] = NewWorker(i, wp.jobQueue, wp.resQueue, apis, wp.repo, wp.bus, wp.log)
+	}
+}
+
+func (wp *APIWorkerPool) startWorkers() {
+	ctx, cancel := context.WithCancel(wp.ctx)
+	done := &sync.WaitGroup{}
+	wp.workersCancel, wp.workersDone = cancel, done
+	for _, w := range wp.workers {
+		wp.wg.Add(1)
+		done.Add(1)
+		go func() {
+			defer wp.wg.Done()
+			w.Start(wp.ctx, ctx.Done(), done)
+		}()
 	}
-	return wp
 }
 
 func (wp *APIWorkerPool) Start() {
@@ -91,13 +142,37 @@ func (wp *APIWorkerPool) Start() {
 	}
 
 	wp.started = true
+	wp.startWorkers()
+}
 
-	for _, w := range wp.workers {
-		wp.wg.Add(1)
-		go w.Start(wp.ctx, &wp.wg)
+// Reload replaces the pool workers with numWorkers new ones using apis once
+// their current jobs are done and their results delivered. Jobs already in
+// the queue are kept and picked up by the new workers.
+func (wp *APIWorkerPool) Reload(numWorkers int, apis map[string]apis.API) {
+	wp.reloadMu.Lock()
+	defer wp.reloadMu.Unlock()
+
+	wp.mu.Lock()
+	if wp.started {
+		wp.workersCancel()
+		done := wp.workersDone
+		// Jobs can still be submitted while the workers finish theirs.
+		wp.mu.Unlock()
+		done.Wait()
+		wp.mu.Lock()
+	}
+	defer wp.mu.Unlock()
+
+	wp.numWorkers = numWorkers
+	wp.newWorkers(apis)
+
+	if wp.started {
+		wp.startWorkers()
 	}
 }
 
+// Stop cancels the jobs in flight, drops the queued ones and waits for the
+// workers to return.
 func (wp *APIWorkerPool) Stop() {
 	wp.mu.Lock()
 	defer wp.mu.Unlock()
@@ -113,7 +188,16 @@ func (wp *APIWorkerPool) Stop() {
 	wp.started = false
 }
 
-func (wp *APIWorkerPool) Submit(job APIJob) error {
+// NewJobID reserves a job ID, so that the caller can tell the results of a
+// job it submits with this ID from the others.
+func (wp *APIWorkerPool) NewJobID() int {
+	return int(wp.nextJobID.Add(1))
+}
+
+// Submit queues job, waiting for room in the queue until ctx is done. The
+// job runs with the values of ctx, such as its logger, but is only
+// cancelled by Stop: a job outlives the request that submitted it.
+func (wp *APIWorkerPool) Submit(ctx context.Context, job APIJob) error {
 	wp.mu.Lock()
 	defer wp.mu.Unlock()
 
@@ -121,16 +205,60 @@ func (wp *APIWorkerPool) Submit(job APIJob) error {
 		return errors.New("API worker pool not started")
 	}
 
+	if job.ID == 0 {
+		job.ID = wp.NewJobID()
+	}
+	job.ctx = context.WithoutCancel(ctx)
+
 	select {
 	case wp.jobQueue <- job:
+		wp.log.Debug("job queued", "job_id", job.ID, "op", job.Operation, "task_id", job.TaskID, "queue_len", len(wp.jobQueue))
 		return nil
 	case <-wp.ctx.Done():
 		return errors.New("failed to submit job due to context cancellation")
+	case <-ctx.Done():
+		return fmt.Errorf("failed to submit job: %w", ctx.Err())
 	case <-time.After(5 * time.Second):
 		return errors.New("job submission timeout")
 	}
 }
 
+// APINames returns the sorted names of the APIs the workers talk to.
+func (wp *APIWorkerPool) APINames() []string {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+	return slices.Sorted(maps.Keys(wp.apis))
+}
+
+// Capabilities returns the capabilities of the APIs the workers talk to.
+func (wp *APIWorkerPool) Capabilities() map[string]apis.Capabilities {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+	caps := make(map[string]apis.Capabilities, len(wp.apis))
+	for name, api := range wp.apis {
+		caps[name] = api.Capabilities()
+	}
+	return caps
+}
+
+// Watchers returns the APIs the workers talk to that watch their tasks.
+func (wp *APIWorkerPool) Watchers() map[string]apis.Watcher {
+	wp.mu.RLock()
+	defer wp.mu.RUnlock()
+	watchers := make(map[string]apis.Watcher)
+	for name, api := range wp.apis {
+		if w, ok := apis.AsWatcher(api); ok {
+			watchers[name] = w
+		}
+	}
+	return watchers
+}
+
+// QueueLen returns the number of jobs waiting to be picked up by a worker.
+func (wp *APIWorkerPool) QueueLen() int {
+	return len(wp.jobQueue)
+}
+
 func (wp *APIWorkerPool) Results() <-chan APIJobResult {
 	return wp.resQueue
 }
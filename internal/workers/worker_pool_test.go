@@ -0,0 +1,166 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	"github.com/zeerodex/goot/internal/events"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// blockingAPI blocks every call until its context is done.
+type blockingAPI struct {
+	started chan struct{}
+}
+
+func (b *blockingAPI) wait(ctx context.Context) error {
+	b.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *blockingAPI) CreateTask(ctx context.Context, _ *tasks.Task) (*tasks.Task, error) {
+	return nil, b.wait(ctx)
+}
+
+func (b *blockingAPI) GetTaskByID(ctx context.Context, _ string) (*tasks.Task, error) {
+	return nil, b.wait(ctx)
+}
+
+func (b *blockingAPI) GetAllTasks(ctx context.Context) (tasks.Tasks, error) {
+	return nil, b.wait(ctx)
+}
+
+func (b *blockingAPI) GetAllTasksWithDeleted(ctx context.Context) (tasks.Tasks, error) {
+	return nil, b.wait(ctx)
+}
+
+func (b *blockingAPI) PatchTask(ctx context.Context, _ *tasks.Task) (*tasks.Task, error) {
+	return nil, b.wait(ctx)
+}
+
+func (b *blockingAPI) SetTaskCompleted(ctx context.Context, _ string, _ bool) error {
+	return b.wait(ctx)
+}
+
+func (b *blockingAPI) DeleteTaskByID(ctx context.Context, _ string) error {
+	return b.wait(ctx)
+}
+
+func (b *blockingAPI) Capabilities() apis.Capabilities {
+	return apis.Capabilities{}
+}
+
+func newTestPool(api apis.API) *APIWorkerPool {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewAPIWorkerPool(1, 1, map[string]apis.API{"test": api}, nil, events.NewBus(logger), logger)
+}
+
+func TestStopInterruptsJob(t *testing.T) {
+	api := &blockingAPI{started: make(chan struct{}, 1)}
+	wp := newTestPool(api)
+	wp.Start()
+
+	if err := wp.Submit(context.Background(), APIJob{Operation: UpdateTaskOp, Task: &tasks.Task{}}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-api.started
+
+	stopped := make(chan struct{})
+	go func() {
+		wp.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not interrupt the job in flight")
+	}
+}
+
+func TestAPITimeout(t *testing.T) {
+	api := &blockingAPI{started: make(chan struct{}, 1)}
+	wp := newTestPool(apis.WithTimeout(api, 10*time.Millisecond))
+	wp.Start()
+	defer wp.Stop()
+
+	if err := wp.Submit(context.Background(), APIJob{Operation: UpdateTaskOp, Task: &tasks.Task{}}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	select {
+	case res := <-wp.Results():
+		if !errors.Is(res.Err, context.DeadlineExceeded) {
+			t.Errorf("job error = %v, want %v", res.Err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not time out")
+	}
+}
+
+// gatedAPI blocks PatchTask until release is closed, whatever its context.
+type gatedAPI struct {
+	blockingAPI
+	release chan struct{}
+}
+
+func (g *gatedAPI) PatchTask(_ context.Context, task *tasks.Task) (*tasks.Task, error) {
+	g.started <- struct{}{}
+	<-g.release
+	return task, nil
+}
+
+func TestReloadKeepsResults(t *testing.T) {
+	api := &gatedAPI{blockingAPI{started: make(chan struct{}, 2)}, make(chan struct{})}
+	wp := newTestPool(api)
+	wp.Start()
+	defer wp.Stop()
+
+	if err := wp.Submit(context.Background(), APIJob{ID: 1, Operation: UpdateTaskOp, Task: &tasks.Task{}}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	<-api.started
+
+	reloaded := make(chan struct{})
+	go func() {
+		wp.Reload(1, map[string]apis.API{"test": api})
+		close(reloaded)
+	}()
+	// Let Reload stop the workers, then submit while it waits for the job in
+	// flight.
+	time.Sleep(50 * time.Millisecond)
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- wp.Submit(context.Background(), APIJob{ID: 2, Operation: UpdateTaskOp, Task: &tasks.Task{}})
+	}()
+	select {
+	case err := <-submitted:
+		if err != nil {
+			t.Errorf("Submit() during reload error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Submit() blocked by Reload()")
+	}
+	close(api.release)
+
+	for _, id := range []int{1, 2} {
+		select {
+		case res := <-wp.Results():
+			if res.JobID != id || !res.Success {
+				t.Errorf("result of job %d success %v, want job %d to succeed", res.JobID, res.Success, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no result of job %d", id)
+		}
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reload() did not return")
+	}
+}
@@ -0,0 +1,171 @@
+package goot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIVersion is the version of the goot REST API used by remote clients.
+const APIVersion = "v1"
+
+var (
+	// ErrNotFound is returned when a task does not exist or was deleted.
+	ErrNotFound = errors.New("goot: task not found")
+	// ErrModified is returned by conditional updates, see TaskPatch.IfMatch,
+	// when the task was modified since it was read.
+	ErrModified = errors.New("goot: task has been modified")
+)
+
+// Task is a goot task.
+type Task struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Due         time.Time `json:"due,omitzero"`
+	// Priority is none, low, medium or high.
+	Priority string   `json:"priority"`
+	Tags     []string `json:"tags"`
+	// Recurrence is an iCalendar RRULE value such as "FREQ=WEEKLY", empty
+	// for tasks due once.
+	Recurrence   string    `json:"recurrence,omitempty"`
+	Completed    bool      `json:"completed"`
+	LastModified time.Time `json:"last_modified"`
+	// RemoteIDs holds the IDs of the task in the APIs it is synced with,
+	// keyed by API name such as "gtasks" or "caldav".
+	RemoteIDs map[string]string `json:"api_ids,omitempty"`
+	// ETag identifies the version of the task. It is opaque and only
+	// comparable between tasks read through the same kind of client.
+	ETag string `json:"etag"`
+}
+
+// NewTask holds the fields of a task to create.
+type NewTask struct {
+	Title       string
+	Description string
+	// Due defaults to today if zero.
+	Due time.Time
+	// Priority is none, low, medium or high, none if empty.
+	Priority   string
+	Tags       []string
+	Recurrence string
+}
+
+// TaskPatch holds the fields of a task to change. Nil fields are left as is.
+type TaskPatch struct {
+	Title       *string
+	Description *string
+	// Due is cleared if it points to a zero time.
+	Due      *time.Time
+	Priority *string
+	// Tags replace the tags of the task, an empty slice clears them.
+	Tags *[]string
+	// Recurrence is cleared if it points to an empty string.
+	Recurrence *string
+	Completed  *bool
+	// IfMatch makes the update fail with ErrModified unless the task still
+	// has this ETag. The task is updated unconditionally if empty.
+	IfMatch string
+}
+
+// Filter selects tasks returned by Client.List. Zero fields match every task.
+type Filter struct {
+	Completed *bool
+	DueAfter  time.Time
+	DueBefore time.Time
+	// Text matches tasks whose title or description contains it, ignoring case.
+	Text     string
+	Priority string
+	// Tags match tasks with at least one of them.
+	Tags      []string
+	Recurring *bool
+}
+
+// Match reports whether task is selected by f.
+func (f Filter) Match(task Task) bool {
+	if f.Completed != nil && task.Completed != *f.Completed {
+		return false
+	}
+	if !f.DueAfter.IsZero() && task.Due.Before(f.DueAfter) {
+		return false
+	}
+	if !f.DueBefore.IsZero() && task.Due.After(f.DueBefore) {
+		return false
+	}
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		if !strings.Contains(strings.ToLower(task.Title), text) && !strings.Contains(strings.ToLower(task.Description), text) {
+			return false
+		}
+	}
+	if f.Priority != "" && task.Priority != f.Priority {
+		return false
+	}
+	if len(f.Tags) > 0 && !slices.ContainsFunc(task.Tags, func(tag string) bool { return slices.Contains(f.Tags, tag) }) {
+		return false
+	}
+	if f.Recurring != nil && (task.Recurrence != "") != *f.Recurring {
+		return false
+	}
+	return true
+}
+
+type backend interface {
+	list(ctx context.Context, f Filter) ([]Task, error)
+	get(ctx context.Context, id int) (Task, error)
+	create(ctx context.Context, t NewTask) (Task, error)
+	update(ctx context.Context, id int, p TaskPatch) (Task, error)
+	delete(ctx context.Context, id int) error
+	sync(ctx context.Context) error
+	close() error
+}
+
+// Client manages goot tasks. It is safe for concurrent use.
+type Client struct {
+	b backend
+}
+
+// List returns the tasks matching f.
+func (c *Client) List(ctx context.Context, f Filter) ([]Task, error) {
+	return c.b.list(ctx, f)
+}
+
+// Get returns the task with the given ID.
+func (c *Client) Get(ctx context.Context, id int) (Task, error) {
+	return c.b.get(ctx, id)
+}
+
+// Create adds a new task and returns it as stored.
+func (c *Client) Create(ctx context.Context, t NewTask) (Task, error) {
+	if strings.TrimSpace(t.Title) == "" {
+		return Task{}, errors.New("goot: task title is required")
+	}
+	return c.b.create(ctx, t)
+}
+
+// Update changes the fields of a task set in p and returns it as stored.
+func (c *Client) Update(ctx context.Context, id int, p TaskPatch) (Task, error) {
+	return c.b.update(ctx, id, p)
+}
+
+// SetCompleted marks a task completed or uncompleted.
+func (c *Client) SetCompleted(ctx context.Context, id int, completed bool) (Task, error) {
+	return c.b.update(ctx, id, TaskPatch{Completed: &completed})
+}
+
+// Delete removes a task.
+func (c *Client) Delete(ctx context.Context, id int) error {
+	return c.b.delete(ctx, id)
+}
+
+// Sync queues a sync of the tasks with every enabled API.
+func (c *Client) Sync(ctx context.Context) error {
+	return c.b.sync(ctx)
+}
+
+// Close releases the resources held by the client.
+func (c *Client) Close() error {
+	return c.b.close()
+}
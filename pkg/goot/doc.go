@@ -0,0 +1,16 @@
+// Package goot is the public Go client for goot tasks.
+//
+// A Client either opens the local goot database directly with OpenLocal, or
+// talks to a running 'goot serve' instance with Dial. Both expose the same
+// context-aware methods, so tools can switch between them freely.
+//
+// # Compatibility
+//
+// This package follows semantic versioning together with the module: within
+// a major version, exported identifiers are neither removed nor changed in an
+// incompatible way. New methods, options and struct fields may be added in
+// minor versions, so construct structs with field names. Everything under
+// internal/ is exempt from these guarantees and must not be relied upon.
+//
+// The remote client speaks the REST API version named by APIVersion.
+package goot
@@ -0,0 +1,189 @@
+package goot_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/database"
+	"github.com/zeerodex/goot/internal/repositories"
+	"github.com/zeerodex/goot/internal/server"
+	"github.com/zeerodex/goot/internal/services"
+	"github.com/zeerodex/goot/pkg/goot"
+)
+
+func openLocal(t *testing.T) *goot.Client {
+	c, err := goot.OpenLocal(filepath.Join(t.TempDir(), "goot.db"))
+	if err != nil {
+		t.Fatalf("OpenLocal() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func dialRemote(t *testing.T) *goot.Client {
+	db, err := database.Open(filepath.Join(t.TempDir(), "goot.db"))
+	if err != nil {
+		t.Fatalf("database.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{}
+	cfg.MaxLength.Title = 1024
+	cfg.MaxLength.Description = 8192
+	cfg.Workers.Count = 1
+	cfg.Workers.QueueSize = 16
+	s, err := services.NewTaskService(repositories.NewTaskRepository(db, logger), cfg, logger)
+	if err != nil {
+		t.Fatalf("services.NewTaskService() error = %v", err)
+	}
+	t.Cleanup(s.Close)
+	go func() {
+		for range s.WP().Results() {
+		}
+	}()
+
+	ts := httptest.NewServer(server.New(s, "token", logger).Handler())
+	t.Cleanup(ts.Close)
+
+	c, err := goot.Dial(ts.URL, "token")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient(t *testing.T) {
+	for name, open := range map[string]func(*testing.T) *goot.Client{
+		"local":  openLocal,
+		"remote": dialRemote,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := open(t)
+			ctx := context.Background()
+			due := time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC)
+
+			created, err := c.Create(ctx, goot.NewTask{Title: "Write report", Due: due})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err := c.Create(ctx, goot.NewTask{Title: "Buy milk"}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if !created.Due.Equal(due) {
+				t.Errorf("Create() due = %v, want %v", created.Due, due)
+			}
+
+			done, err := c.SetCompleted(ctx, created.ID, true)
+			if err != nil || !done.Completed {
+				t.Fatalf("SetCompleted() = %+v, %v", done, err)
+			}
+
+			completed := true
+			list, err := c.List(ctx, goot.Filter{Completed: &completed})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(list) != 1 || list[0].ID != created.ID {
+				t.Errorf("List(completed) = %+v, want only task %d", list, created.ID)
+			}
+
+			title := "Write final report"
+			updated, err := c.Update(ctx, created.ID, goot.TaskPatch{Title: &title})
+			if err != nil || updated.Title != title {
+				t.Fatalf("Update() = %+v, %v", updated, err)
+			}
+
+			stale := goot.TaskPatch{Title: &title, IfMatch: created.ETag}
+			if _, err := c.Update(ctx, created.ID, stale); !errors.Is(err, goot.ErrModified) {
+				t.Errorf("Update() with stale ETag error = %v, want ErrModified", err)
+			}
+			current := goot.TaskPatch{Description: &title, IfMatch: updated.ETag}
+			if _, err := c.Update(ctx, created.ID, current); err != nil {
+				t.Errorf("Update() with current ETag error = %v", err)
+			}
+
+			planned, err := c.Create(ctx, goot.NewTask{
+				Title:      "Water plants",
+				Priority:   "low",
+				Tags:       []string{"home", "garden"},
+				Recurrence: "FREQ=WEEKLY",
+			})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if planned.Priority != "low" || !slices.Equal(planned.Tags, []string{"home", "garden"}) || planned.Recurrence != "FREQ=WEEKLY" {
+				t.Errorf("Create() priority, tags, recurrence = %q, %q, %q", planned.Priority, planned.Tags, planned.Recurrence)
+			}
+
+			recurring := true
+			list, err = c.List(ctx, goot.Filter{Priority: "low", Tags: []string{"garden"}, Recurring: &recurring})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(list) != 1 || list[0].ID != planned.ID {
+				t.Errorf("List(low, garden, recurring) = %+v, want only task %d", list, planned.ID)
+			}
+
+			high, noTags, once := "high", []string{}, ""
+			patched, err := c.Update(ctx, planned.ID, goot.TaskPatch{Priority: &high, Tags: &noTags, Recurrence: &once})
+			if err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if patched.Priority != "high" || len(patched.Tags) != 0 || patched.Recurrence != "" {
+				t.Errorf("Update() priority, tags, recurrence = %q, %q, %q", patched.Priority, patched.Tags, patched.Recurrence)
+			}
+			if got, err := c.Get(ctx, planned.ID); err != nil || got.Priority != "high" || len(got.Tags) != 0 || got.Recurrence != "" {
+				t.Errorf("Get() after Update() = %+v, %v", got, err)
+			}
+
+			if err := c.Delete(ctx, created.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := c.Get(ctx, created.ID); !errors.Is(err, goot.ErrNotFound) {
+				t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+// TestOpenLocalConfig checks that local clients read their own config
+// rather than the global one.
+func TestOpenLocalConfig(t *testing.T) {
+	dirs := []string{t.TempDir(), t.TempDir()}
+	for i, dir := range dirs {
+		data := fmt.Sprintf(`{"workers": {"count": %d}}`, i+1)
+		if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := goot.OpenLocal(filepath.Join(dir, "goot.db"), goot.WithConfigDir(dir))
+		if err != nil {
+			t.Fatalf("OpenLocal() error = %v", err)
+		}
+		c.Close()
+	}
+	if f := config.File(); f != "" {
+		t.Errorf("global config file = %q, want none", f)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	c := openLocal(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.List(ctx, goot.Filter{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("List() with canceled context error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,243 @@
+package goot
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zeerodex/goot/internal/apis"
+	_ "github.com/zeerodex/goot/internal/apis/providers"
+	"github.com/zeerodex/goot/internal/config"
+	"github.com/zeerodex/goot/internal/database"
+	"github.com/zeerodex/goot/internal/repositories"
+	"github.com/zeerodex/goot/internal/services"
+	"github.com/zeerodex/goot/internal/tasks"
+)
+
+// OpenLocal returns a client working directly on the goot database at dbPath.
+func OpenLocal(dbPath string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+
+	cfg := &config.Config{}
+	cfg.MaxLength.Title = 1024
+	cfg.MaxLength.Description = 8196
+	cfg.Workers.Count = 1
+	cfg.Workers.QueueSize = 16
+	if o.configDir != "" {
+		var err error
+		if cfg, err = config.Read(o.configDir); err != nil {
+			return nil, fmt.Errorf("goot: %w", err)
+		}
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("goot: failed to open database '%s': %w", dbPath, err)
+	}
+
+	s, err := services.NewTaskService(repositories.NewTaskRepository(db, o.logger), cfg, o.logger)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("goot: %w", err)
+	}
+
+	l := &local{db: db, s: s}
+	l.wg.Add(1)
+	go l.drainResults()
+	return &Client{b: l}, nil
+}
+
+type local struct {
+	db *sql.DB
+	s  services.TaskService
+
+	// mu serializes writes, so that updates read and write a consistent task.
+	mu sync.Mutex
+	wg sync.WaitGroup
+}
+
+// drainResults consumes worker results so that workers never block on them.
+func (l *local) drainResults() {
+	defer l.wg.Done()
+	for range l.s.WP().Results() {
+	}
+}
+
+func (l *local) list(ctx context.Context, f Filter) ([]Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	all, err := l.s.GetAllTasks()
+	if err != nil {
+		return nil, fmt.Errorf("goot: %w", err)
+	}
+
+	list := []Task{}
+	for _, t := range all {
+		if task := fromTask(&t); f.Match(task) {
+			list = append(list, task)
+		}
+	}
+	return list, nil
+}
+
+func (l *local) get(ctx context.Context, id int) (Task, error) {
+	t, err := l.task(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	return fromTask(t), nil
+}
+
+func (l *local) task(ctx context.Context, id int) (*tasks.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t, err := l.s.GetTaskByID(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTaskNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("goot: %w", err)
+	}
+	if t.Deleted {
+		return nil, ErrNotFound
+	}
+	return t, nil
+}
+
+func (l *local) create(ctx context.Context, nt NewTask) (Task, error) {
+	if err := ctx.Err(); err != nil {
+		return Task{}, err
+	}
+	due := nt.Due
+	if due.IsZero() {
+		now := time.Now()
+		due = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	priority := tasks.PriorityNone
+	if nt.Priority != "" {
+		var err error
+		if priority, err = tasks.ParsePriority(nt.Priority); err != nil {
+			return Task{}, fmt.Errorf("goot: %w", err)
+		}
+	}
+
+	created, err := l.s.CreateTask(ctx, &tasks.Task{
+		Title:       nt.Title,
+		Description: nt.Description,
+		Due:         due,
+		Priority:    priority,
+		Tags:        nt.Tags,
+		Recurrence:  nt.Recurrence,
+	})
+	if err != nil {
+		return Task{}, fmt.Errorf("goot: %w", err)
+	}
+	return l.get(ctx, created.ID)
+}
+
+func (l *local) update(ctx context.Context, id int, p TaskPatch) (Task, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	t, err := l.task(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if p.IfMatch != "" && p.IfMatch != fromTask(t).ETag {
+		return Task{}, ErrModified
+	}
+
+	if p.Title != nil || p.Description != nil || p.Due != nil ||
+		p.Priority != nil || p.Tags != nil || p.Recurrence != nil {
+		if p.Title != nil {
+			t.Title = *p.Title
+		}
+		if p.Description != nil {
+			t.Description = *p.Description
+		}
+		if p.Due != nil {
+			t.Due = *p.Due
+		}
+		if p.Priority != nil {
+			if t.Priority, err = tasks.ParsePriority(*p.Priority); err != nil {
+				return Task{}, fmt.Errorf("goot: %w", err)
+			}
+		}
+		if p.Tags != nil {
+			t.Tags = *p.Tags
+		}
+		if p.Recurrence != nil {
+			t.Recurrence = *p.Recurrence
+		}
+		if _, err := l.s.UpdateTask(ctx, t); err != nil {
+			return Task{}, fmt.Errorf("goot: %w", err)
+		}
+	}
+	if p.Completed != nil && *p.Completed != t.Completed {
+		if err := l.s.SetTaskCompleted(ctx, id, *p.Completed); err != nil {
+			return Task{}, fmt.Errorf("goot: %w", err)
+		}
+	}
+	return l.get(ctx, id)
+}
+
+func (l *local) delete(ctx context.Context, id int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.task(ctx, id); err != nil {
+		return err
+	}
+	if err := l.s.DeleteTaskByID(ctx, id); err != nil {
+		return fmt.Errorf("goot: %w", err)
+	}
+	return nil
+}
+
+func (l *local) sync(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := l.s.Sync(ctx); err != nil {
+		return fmt.Errorf("goot: %w", err)
+	}
+	return nil
+}
+
+func (l *local) close() error {
+	l.s.Close()
+	l.wg.Wait()
+	return l.db.Close()
+}
+
+func fromTask(t *tasks.Task) Task {
+	tags := t.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	task := Task{
+		ID:           t.ID,
+		Title:        t.Title,
+		Description:  t.Description,
+		Due:          t.Due,
+		Priority:     t.Priority.String(),
+		Tags:         tags,
+		Recurrence:   t.Recurrence,
+		Completed:    t.Completed,
+		LastModified: t.LastModified,
+		RemoteIDs:    apis.IDs(t),
+	}
+	b, _ := json.Marshal(task)
+	sum := sha256.Sum256(b)
+	task.ETag = `"` + hex.EncodeToString(sum[:8]) + `"`
+	return task
+}
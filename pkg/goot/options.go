@@ -0,0 +1,45 @@
+package goot
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// Option configures a Client.
+type Option func(*options)
+
+type options struct {
+	configDir  string
+	logger     *slog.Logger
+	httpClient *http.Client
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithConfigDir makes a local client load config.json from dir and sync
+// with the APIs enabled there. Every client reads its own config, API
+// credentials are taken from the environment. Without it, a local client
+// never syncs.
+func WithConfigDir(dir string) Option {
+	return func(o *options) { o.configDir = dir }
+}
+
+// WithLogger sets the logger used by a local client. Logs are discarded by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithHTTPClient sets the HTTP client used by a remote client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) { o.httpClient = client }
+}
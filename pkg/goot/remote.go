@@ -0,0 +1,223 @@
+package goot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dial returns a client talking to a running 'goot serve' instance. addr is
+// either a base URL such as "http://127.0.0.1:8765" or "unix:" followed by
+// the path of the server socket.
+func Dial(addr, token string, opts ...Option) (*Client, error) {
+	o := newOptions(opts)
+	r := &remote{token: token, client: o.httpClient}
+
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		r.baseURL = "http://goot"
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+		client := *o.httpClient
+		client.Transport = transport
+		r.client = &client
+	} else {
+		u, err := url.Parse(addr)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("goot: invalid server address '%s'", addr)
+		}
+		r.baseURL = strings.TrimSuffix(u.String(), "/")
+	}
+	r.baseURL += "/" + APIVersion
+	return &Client{b: r}, nil
+}
+
+type remote struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+type remoteError struct {
+	Error string `json:"error"`
+}
+
+func (r *remote) do(ctx context.Context, method, path string, body, out any) error {
+	return r.doHeader(ctx, method, path, nil, body, out)
+}
+
+// doHeader is like do, sending header with the request.
+func (r *remote) doHeader(ctx context.Context, method, path string, header http.Header, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("goot: failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("goot: failed to create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("goot: %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return ErrModified
+	case resp.StatusCode >= 400:
+		var e remoteError
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("goot: %s %s failed with status %d: %s", method, path, resp.StatusCode, e.Error)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("goot: failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *remote) list(ctx context.Context, f Filter) ([]Task, error) {
+	q := url.Values{}
+	if f.Completed != nil {
+		q.Set("completed", strconv.FormatBool(*f.Completed))
+	}
+	if !f.DueAfter.IsZero() {
+		q.Set("due_after", f.DueAfter.Format(time.RFC3339))
+	}
+	if !f.DueBefore.IsZero() {
+		q.Set("due_before", f.DueBefore.Format(time.RFC3339))
+	}
+	if f.Text != "" {
+		q.Set("q", f.Text)
+	}
+	if f.Priority != "" {
+		q.Set("priority", f.Priority)
+	}
+	for _, tag := range f.Tags {
+		q.Add("tag", tag)
+	}
+	if f.Recurring != nil {
+		q.Set("recurring", strconv.FormatBool(*f.Recurring))
+	}
+
+	var list struct {
+		Tasks []Task `json:"tasks"`
+	}
+	if err := r.do(ctx, "GET", "/tasks?"+q.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Tasks, nil
+}
+
+func (r *remote) get(ctx context.Context, id int) (Task, error) {
+	var task Task
+	err := r.do(ctx, "GET", "/tasks/"+strconv.Itoa(id), nil, &task)
+	return task, err
+}
+
+type remoteNewTask struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Due         string   `json:"due,omitempty"`
+	Priority    string   `json:"priority,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty"`
+}
+
+func (r *remote) create(ctx context.Context, nt NewTask) (Task, error) {
+	body := remoteNewTask{
+		Title:       nt.Title,
+		Description: nt.Description,
+		Priority:    nt.Priority,
+		Tags:        nt.Tags,
+		Recurrence:  nt.Recurrence,
+	}
+	if !nt.Due.IsZero() {
+		body.Due = nt.Due.Format(time.RFC3339)
+	}
+
+	var task Task
+	err := r.do(ctx, "POST", "/tasks", body, &task)
+	return task, err
+}
+
+type remotePatch struct {
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Due         *string   `json:"due,omitempty"`
+	Priority    *string   `json:"priority,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+	Recurrence  *string   `json:"recurrence,omitempty"`
+	Completed   *bool     `json:"completed,omitempty"`
+}
+
+func (r *remote) update(ctx context.Context, id int, p TaskPatch) (Task, error) {
+	body := remotePatch{
+		Title:       p.Title,
+		Description: p.Description,
+		Priority:    p.Priority,
+		Tags:        p.Tags,
+		Recurrence:  p.Recurrence,
+		Completed:   p.Completed,
+	}
+	if p.Due != nil {
+		due := ""
+		if !p.Due.IsZero() {
+			due = p.Due.Format(time.RFC3339)
+		}
+		body.Due = &due
+	}
+
+	var header http.Header
+	if p.IfMatch != "" {
+		header = http.Header{"If-Match": {p.IfMatch}}
+	}
+
+	var task Task
+	err := r.doHeader(ctx, "PATCH", "/tasks/"+strconv.Itoa(id), header, body, &task)
+	return task, err
+}
+
+func (r *remote) delete(ctx context.Context, id int) error {
+	return r.do(ctx, "DELETE", "/tasks/"+strconv.Itoa(id), nil, nil)
+}
+
+func (r *remote) sync(ctx context.Context) error {
+	return r.do(ctx, "POST", "/sync", nil, nil)
+}
+
+func (r *remote) close() error {
+	r.client.CloseIdleConnections()
+	return nil
+}